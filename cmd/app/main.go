@@ -1,20 +1,208 @@
 package main
 
 import (
+	"context"
+	"log"
+	"log/slog"
+	"net"
+	"os"
+
+	"github.com/vier21/tefa-ch3/config"
 	"github.com/vier21/tefa-ch3/db"
+	"github.com/vier21/tefa-ch3/db/migration"
+	"github.com/vier21/tefa-ch3/internal/apikey"
+	"github.com/vier21/tefa-ch3/internal/auth"
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/credential"
+	"github.com/vier21/tefa-ch3/internal/graphql"
+	"github.com/vier21/tefa-ch3/internal/grpcserver"
+	"github.com/vier21/tefa-ch3/internal/health"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/mail"
+	"github.com/vier21/tefa-ch3/internal/metrics"
+	"github.com/vier21/tefa-ch3/internal/otp"
+	"github.com/vier21/tefa-ch3/internal/ratelimit"
 	"github.com/vier21/tefa-ch3/internal/repository"
 	"github.com/vier21/tefa-ch3/internal/server"
+	"github.com/vier21/tefa-ch3/internal/sms"
+	"github.com/vier21/tefa-ch3/internal/tracing"
 	"github.com/vier21/tefa-ch3/internal/usecase"
+	"github.com/vier21/tefa-ch3/internal/verification"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 func main() {
-	db.InitMongoDB()
+	cfg := config.GetConfig()
+
+	logger := logging.FromConfig(cfg, os.Stdout)
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.FromConfig(cfg))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tokens, err := auth.NewTokenService(auth.FromConfig(cfg))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	m := metrics.New()
+
+	db.InitMongoDB(options.Client().SetPoolMonitor(m.MongoPoolMonitor()).SetMonitor(otelmongo.NewMonitor()))
 	db.InitMysqlDB()
+	if err := m.CollectDBStats(db.DB.DB, "user"); err != nil {
+		log.Fatal(err)
+	}
+
+	bootstrapMongo(context.Background())
+
+	mailer := mail.FromConfig(cfg)
+
+	verifier, err := verification.NewService(verification.FromConfig(cfg), mailer, cfg.PublicURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	credentials, err := credential.NewService(repository.NewCredentialRepository(), credential.MailNotifier{Mailer: mailer}, credential.FromConfig(cfg))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mysqlRepo := repository.InstrumentMysql(repository.NewMysqlRepository(), m.RepositoryHook, tracing.RepositoryHook)
+	mongoRepo := repository.InstrumentMongo(repository.NewMongoRepository(), m.RepositoryHook, tracing.RepositoryHook)
+
+	gateway, err := sms.FromConfig(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	otps, err := otp.NewService(mysqlRepo, repository.NewOTPRepository(), gateway, otp.FromConfig(cfg))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	usecase := usecase.NewAuthorizedUsecase(
+		usecase.NewMetricsUsecase(
+			usecase.NewVerificationUsecase(
+				usecase.NewCredentialUsecase(
+					usecase.NewOTPUsecase(usecase.NewTracingUsecase(usecase.NewUserUsecase(mysqlRepo, mongoRepo)), otps),
+					credentials,
+				),
+				verifier,
+			),
+			m,
+		),
+		authz.DefaultPolicy,
+	)
 
-	mysqlRepo := repository.NewMysqlRepository()
-	mongoRepo := repository.NewMongoRepository()
-	usecase := usecase.NewUserUsecase(mysqlRepo, mongoRepo)
+	scopes := make([]string, 0, len(authz.APIKeyScopes))
+	for _, s := range authz.APIKeyScopes {
+		scopes = append(scopes, string(s))
+	}
+	apiKeys := apikey.NewService(repository.NewAPIKeyRepository(), scopes)
+
+	schema, err := graphql.NewSchema(usecase, graphql.FromConfig(cfg))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	limits := ratelimit.FromConfig(cfg)
+	buckets, err := ratelimit.NewStore(limits.Backend)
+	if err != nil {
+		log.Fatal(err)
+	}
+	limiter := ratelimit.NewLimiter(buckets, limits)
+
+	opts := []server.Option{
+		server.WithAddr(cfg.ServerPort),
+		server.WithHTTP(server.HTTPFromConfig(cfg)),
+		server.WithLogger(logger),
+		server.WithMiddleware(server.MiddlewareFromConfig(cfg)),
+		server.WithMetrics(m),
+		server.WithAuth(tokens),
+		server.WithAPIKeys(apiKeys),
+		server.WithCredentials(credentials),
+		server.WithVerification(verifier),
+		server.WithGraphQL(schema),
+		server.WithRateLimit(limiter),
+		server.WithReadinessChecks(health.MySQL(db.DB), health.Mongo(db.MongoCLI)),
+		server.WithShutdownTimeout(cfg.ShutdownTimeout),
+		server.WithDrainDelay(cfg.DrainDelay),
+		server.WithShutdownHooks(
+			db.Disconnect,
+			func(ctx context.Context) error { return db.CloseMysqlDB() },
+			shutdownTracing,
+		),
+	}
+
+	var certs *server.CertReloader
+	if tlsConfig := server.TLSFromConfig(cfg); tlsConfig.Enabled() {
+		certs, err = server.NewCertReloader(tlsConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, server.WithTLS(certs))
+	}
+
+	server := server.NewServer(usecase, opts...)
+	if certs != nil {
+		server.Go(certs.Run)
+	}
+	server.Go(apiKeys.Run)
+	server.Go(otps.Run)
+	server.Go(limiter.Run)
+
+	metricsLn, err := net.Listen("tcp", cfg.MetricsPort)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server.Go(func(ctx context.Context) {
+		if err := m.Serve(ctx, metricsLn); err != nil {
+			logger.Error("metrics server error", "error", err)
+		}
+	})
+
+	ln, err := net.Listen("tcp", cfg.GRPCPort)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	grpcOpts := []grpcserver.Option{
+		grpcserver.WithAuth(tokens),
+		grpcserver.WithAPIKeys(apiKeys),
+		grpcserver.WithRateLimit(limiter),
+		grpcserver.WithLogger(logger),
+	}
+	if certs != nil {
+		grpcOpts = append(grpcOpts, grpcserver.WithTLS(certs.ServerConfig(true)))
+	}
+	grpcServer := grpcserver.NewServer(usecase, grpcOpts...)
+	server.Go(func(ctx context.Context) {
+		if err := grpcServer.Serve(ctx, ln); err != nil {
+			logger.Error("grpc server error", "error", err)
+		}
+	})
 
-	server := server.NewServer(usecase)
 	server.Run()
 }
+
+func bootstrapMongo(ctx context.Context) {
+	database := db.MongoCLI.Database(db.MongoDBName)
+
+	if err := migration.EnsureMongoSchema(ctx, database); err != nil {
+		slog.Error("mongo schema bootstrap failed", "error", err)
+		return
+	}
+
+	drift, err := migration.MongoIndexDrift(ctx, database)
+	if err != nil {
+		slog.Error("mongo index drift check failed", "error", err)
+		return
+	}
+
+	for _, d := range drift {
+		slog.Warn("mongo index drift", "drift", d.String())
+	}
+}
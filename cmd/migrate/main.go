@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/vier21/tefa-ch3/db"
+	"github.com/vier21/tefa-ch3/db/migration"
+)
+
+const usage = `usage: migrate [-store mysql|mongo|all] <command> [arg]
+
+commands:
+  up [N]       apply all or N pending migrations
+  down [N]     roll back all or N applied migrations
+  status       list migrations and whether they are applied
+  force V      set the version to V and clear the dirty flag
+  drift        report differences between declared and actual Mongo indexes
+`
+
+func main() {
+	store := flag.String("store", "all", "store to migrate: mysql, mongo or all")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	runners := map[string]*migration.Runner{}
+
+	if *store == "mysql" || *store == "all" {
+		if err := db.InitMysqlDB(); err != nil {
+			log.Fatal(err)
+		}
+		defer db.CloseMysqlDB()
+
+		r, err := migration.NewMysql(ctx, db.DB)
+		if err != nil {
+			log.Fatal(err)
+		}
+		runners["mysql"] = r
+	}
+
+	if *store == "mongo" || *store == "all" {
+		if err := db.InitMongoDB(); err != nil {
+			log.Fatal(err)
+		}
+		defer db.Disconnect(ctx)
+
+		r, err := migration.NewMongo(db.MongoCLI.Database(db.MongoDBName))
+		if err != nil {
+			log.Fatal(err)
+		}
+		runners["mongo"] = r
+	}
+
+	if len(runners) == 0 {
+		log.Fatalf("unknown store %q", *store)
+	}
+
+	if flag.Arg(0) == "drift" {
+		if _, ok := runners["mongo"]; !ok {
+			log.Fatal("drift is only supported for the mongo store")
+		}
+		if err := drift(ctx); err != nil {
+			log.Fatalf("mongo: %s", err)
+		}
+		return
+	}
+
+	for _, name := range []string{"mysql", "mongo"} {
+		r, ok := runners[name]
+		if !ok {
+			continue
+		}
+		if err := run(ctx, name, r, flag.Arg(0), flag.Arg(1)); err != nil {
+			log.Fatalf("%s: %s", name, err)
+		}
+	}
+}
+
+func run(ctx context.Context, name string, r *migration.Runner, cmd, arg string) error {
+	switch cmd {
+	case "up", "down":
+		n := 0
+		if arg != "" {
+			var err error
+			if n, err = strconv.Atoi(arg); err != nil || n < 1 {
+				return fmt.Errorf("invalid N %q", arg)
+			}
+		}
+
+		exec := r.Up
+		if cmd == "down" {
+			exec = r.Down
+		}
+
+		versions, err := exec(ctx, n)
+		for _, v := range versions {
+			log.Printf("%s: %s %d done", name, cmd, v)
+		}
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			log.Printf("%s: no change", name)
+		}
+		return nil
+
+	case "status":
+		statuses, err := r.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied"
+			}
+			if s.Dirty {
+				state = "dirty"
+			}
+			fmt.Printf("%-6s %06d_%-30s %s\n", name, s.Version, s.Name, state)
+		}
+		return nil
+
+	case "force":
+		v, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", arg)
+		}
+		if err := r.Force(ctx, v); err != nil {
+			return err
+		}
+		log.Printf("%s: forced version %d", name, v)
+		return nil
+	}
+
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+func drift(ctx context.Context) error {
+	drift, err := migration.MongoIndexDrift(ctx, db.MongoCLI.Database(db.MongoDBName))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range drift {
+		fmt.Println(d)
+	}
+	if len(drift) == 0 {
+		log.Print("mongo: no index drift")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Command token mints a JWT with the keys from the environment, e.g. for
+// operators or to bootstrap the first admin.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/vier21/tefa-ch3/config"
+	"github.com/vier21/tefa-ch3/internal/auth"
+)
+
+func main() {
+	sub := flag.String("sub", "", "token subject (user ID)")
+	roles := flag.String("roles", "", "comma separated roles")
+	ttl := flag.Duration("ttl", 0, "token lifetime, JWT_TTL when zero")
+	flag.Parse()
+
+	if *sub == "" {
+		log.Fatal("-sub is required")
+	}
+
+	cfg := auth.FromConfig(config.GetConfig())
+	if *ttl > 0 {
+		cfg.TTL = *ttl
+	}
+
+	tokens, err := auth.NewTokenService(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var roleList []string
+	if *roles != "" {
+		roleList = strings.Split(*roles, ",")
+	}
+
+	token, expires, err := tokens.Issue(*sub, roleList...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(token)
+	log.Printf("expires at %s", expires.Format(time.RFC3339))
+}
@@ -1,35 +1,294 @@
 package config
 
 import (
-	"fmt"
+	"log/slog"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	MongoDBURL string
-	SecretKey  []byte
-	ServerPort string
-	UserDBName string
+	MongoDBURL      string
+	SecretKey       []byte
+	ServerPort      string
+	GRPCPort        string
+	MetricsPort     string
+	UserDBName      string
+	ShutdownTimeout time.Duration
+	DrainDelay      time.Duration
+	LogLevel        string
+
+	ServiceName      string
+	TracesExporter   string
+	OTLPEndpoint     string
+	TraceSampleRatio float64
+
+	JWTKeys         []JWTKey
+	JWTSigningKeyID string
+	JWTIssuer       string
+	JWTAudience     string
+	JWTTTL          time.Duration
+
+	PasswordMaxAttempts int
+	PasswordLockout     time.Duration
+	PasswordResetTTL    time.Duration
+	PasswordBcryptCost  int
+
+	PublicURL    string
+	VerifyTTL    time.Duration
+	MailFrom     string
+	MailDir      string
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+
+	OTPLength       int
+	OTPTTL          time.Duration
+	OTPMaxAttempts  int
+	SMSGatewayURL   string
+	SMSGatewayToken string
+	// SMSFake opts into writing text messages to SMSDir when no gateway is
+	// set, for local development.
+	SMSFake bool
+	SMSDir  string
+
+	RateLimitDefault int
+	RateLimitWrite   int
+	RateLimitBackend string
+
+	RequestTimeout       time.Duration
+	RouteTimeouts        map[string]time.Duration
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+	TrustProxyHeaders    bool
+	GzipLevel            int
+	AccessLog            bool
+
+	HTTPReadHeaderTimeout time.Duration
+	HTTPReadTimeout       time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	HTTPMaxBodyBytes      int
+	HTTP2Enabled          bool
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSClientCAFile       string
+	TLSRequireClientCert  bool
+	TLSReloadInterval     time.Duration
+
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int
+}
+
+type JWTKey struct {
+	ID     string
+	Secret []byte
 }
 
 func init() {
 	if err := godotenv.Load(); err != nil {
-		fmt.Println(err)
+		slog.Warn("no .env file loaded", "error", err)
 		return
 	}
 }
 
 func GetConfig() *Config {
 	return &Config{
-		MongoDBURL: getDBURL(),
-		SecretKey:  getSecretKey(),
-		ServerPort: os.Getenv("SERVER_PORT"),
-		UserDBName: getDBName("USER_DB"),
+		MongoDBURL:      getDBURL(),
+		SecretKey:       getSecretKey(),
+		ServerPort:      os.Getenv("SERVER_PORT"),
+		GRPCPort:        getString("GRPC_PORT", ":3002"),
+		MetricsPort:     getString("METRICS_PORT", ":9090"),
+		UserDBName:      getDBName("USER_DB"),
+		ShutdownTimeout: getDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		DrainDelay:      getDuration("SHUTDOWN_DRAIN_DELAY", 0),
+		LogLevel:        getString("LOG_LEVEL", "info"),
+
+		ServiceName:      getString("OTEL_SERVICE_NAME", "tefa-ch3"),
+		TracesExporter:   getString("OTEL_TRACES_EXPORTER", "none"),
+		OTLPEndpoint:     os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+		TraceSampleRatio: getFloat("OTEL_TRACES_SAMPLER_ARG", 1),
+
+		JWTKeys:         getJWTKeys(),
+		JWTSigningKeyID: os.Getenv("JWT_SIGNING_KEY_ID"),
+		JWTIssuer:       getString("JWT_ISSUER", "tefa-ch3"),
+		JWTAudience:     getString("JWT_AUDIENCE", "tefa-ch3"),
+		JWTTTL:          getDuration("JWT_TTL", 15*time.Minute),
+
+		PasswordMaxAttempts: getInt("PASSWORD_MAX_ATTEMPTS", 5),
+		PasswordLockout:     getDuration("PASSWORD_LOCKOUT", 15*time.Minute),
+		PasswordResetTTL:    getDuration("PASSWORD_RESET_TTL", time.Hour),
+		PasswordBcryptCost:  getInt("PASSWORD_BCRYPT_COST", 12),
+
+		PublicURL:    getString("PUBLIC_URL", "http://localhost:3001"),
+		VerifyTTL:    getDuration("VERIFY_TTL", 24*time.Hour),
+		MailFrom:     getString("MAIL_FROM", "no-reply@tefa-ch3.local"),
+		MailDir:      getString("MAIL_DIR", "mail"),
+		SMTPAddr:     os.Getenv("SMTP_ADDR"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+
+		OTPLength:       getInt("OTP_LENGTH", 6),
+		OTPTTL:          getDuration("OTP_TTL", 5*time.Minute),
+		OTPMaxAttempts:  getInt("OTP_MAX_ATTEMPTS", 5),
+		SMSGatewayURL:   os.Getenv("SMS_GATEWAY_URL"),
+		SMSGatewayToken: os.Getenv("SMS_GATEWAY_TOKEN"),
+		SMSFake:         getBool("SMS_FAKE", false),
+		SMSDir:          getString("SMS_DIR", "sms"),
+
+		RateLimitDefault: getInt("RATE_LIMIT_DEFAULT", 300),
+		RateLimitWrite:   getInt("RATE_LIMIT_WRITE", 30),
+		RateLimitBackend: getString("RATE_LIMIT_BACKEND", "memory"),
+
+		RequestTimeout:       getDuration("HTTP_REQUEST_TIMEOUT", 0),
+		RouteTimeouts:        getDurationMap("HTTP_ROUTE_TIMEOUTS"),
+		CORSAllowedOrigins:   getList("CORS_ALLOWED_ORIGINS"),
+		CORSAllowedMethods:   getList("CORS_ALLOWED_METHODS"),
+		CORSAllowedHeaders:   getList("CORS_ALLOWED_HEADERS"),
+		CORSAllowCredentials: getBool("CORS_ALLOW_CREDENTIALS", false),
+		CORSMaxAge:           getDuration("CORS_MAX_AGE", 0),
+		TrustProxyHeaders:    getBool("TRUST_PROXY_HEADERS", false),
+		GzipLevel:            getInt("GZIP_LEVEL", 0),
+		AccessLog:            getBool("ACCESS_LOG", false),
+
+		HTTPReadHeaderTimeout: getDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		HTTPReadTimeout:       getDuration("HTTP_READ_TIMEOUT", 30*time.Second),
+		HTTPWriteTimeout:      getDuration("HTTP_WRITE_TIMEOUT", 60*time.Second),
+		HTTPIdleTimeout:       getDuration("HTTP_IDLE_TIMEOUT", 120*time.Second),
+		HTTPMaxBodyBytes:      getInt("HTTP_MAX_BODY_BYTES", 1<<20),
+		HTTP2Enabled:          getBool("HTTP2_ENABLED", true),
+		TLSCertFile:           os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:            os.Getenv("TLS_KEY_FILE"),
+		TLSClientCAFile:       os.Getenv("TLS_CLIENT_CA_FILE"),
+		TLSRequireClientCert:  getBool("TLS_REQUIRE_CLIENT_CERT", false),
+		TLSReloadInterval:     getDuration("TLS_RELOAD_INTERVAL", time.Minute),
+
+		GraphQLMaxDepth:      getInt("GRAPHQL_MAX_DEPTH", 12),
+		GraphQLMaxComplexity: getInt("GRAPHQL_MAX_COMPLEXITY", 100),
+	}
+}
+
+func getString(key, def string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return def
+}
+
+// getJWTKeys reads JWT_KEYS as "kid:secret,kid:secret". Without any keys
+// there, SECRET_KEY is used under the "default" kid so existing deployments
+// keep working. Once JWT_KEYS is set, SECRET_KEY is no longer accepted, so
+// it can be retired by leaving it out of JWT_KEYS.
+func getJWTKeys() []JWTKey {
+	var keys []JWTKey
+
+	for _, pair := range strings.Split(os.Getenv("JWT_KEYS"), ",") {
+		id, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || id == "" || secret == "" {
+			continue
+		}
+		keys = append(keys, JWTKey{ID: id, Secret: []byte(secret)})
+	}
+
+	if len(keys) > 0 {
+		return keys
+	}
+
+	if secret := getSecretKey(); len(secret) > 0 {
+		keys = append(keys, JWTKey{ID: "default", Secret: secret})
+	}
+
+	return keys
+}
+
+func getDuration(key string, def time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		slog.Warn("invalid config value, using default", "key", key, "value", val, "default", def.String())
+		return def
+	}
+	return d
+}
+
+func getInt(key string, def int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		slog.Warn("invalid config value, using default", "key", key, "value", val, "default", def)
+		return def
+	}
+	return n
+}
+
+func getFloat(key string, def float64) float64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		slog.Warn("invalid config value, using default", "key", key, "value", val, "default", def)
+		return def
+	}
+	return f
+}
+
+func getBool(key string, def bool) bool {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		slog.Warn("invalid config value, using default", "key", key, "value", val, "default", def)
+		return def
+	}
+	return b
+}
+
+// getList reads a comma separated list, skipping empty entries.
+func getList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getDurationMap reads "key=duration" pairs separated by commas, e.g.
+// HTTP_ROUTE_TIMEOUTS="POST /user=10s,GET /{id}/user/mongo=2s".
+func getDurationMap(key string) map[string]time.Duration {
+	m := map[string]time.Duration{}
+	for _, pair := range getList(key) {
+		k, v, ok := strings.Cut(pair, "=")
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if !ok || err != nil {
+			slog.Warn("invalid config value, skipping", "key", key, "value", pair)
+			continue
+		}
+		m[strings.TrimSpace(k)] = d
 	}
+	return m
 }
 
 func getSecretKey() []byte {
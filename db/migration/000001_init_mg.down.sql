@@ -1 +1 @@
-DROP TABLE IF EXIST user;
\ No newline at end of file
+DROP TABLE IF EXISTS user;
@@ -3,5 +3,4 @@ CREATE TABLE IF NOT EXISTS user (
     name VARCHAR(50) NOT NULL,
     address VARCHAR(50) NOT NULL,
     email VARCHAR(254) NOT NULL
-)
-
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS account (
+    id VARCHAR(50) PRIMARY KEY,
+    msisdn_customer VARCHAR(20) NOT NULL,
+    user_id VARCHAR(50) NOT NULL,
+    UNIQUE INDEX idx_account_msisdn_customer (msisdn_customer),
+    INDEX idx_account_user_id (user_id),
+    CONSTRAINT fk_account_user FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS user_credential (
+    user_id VARCHAR(50) PRIMARY KEY,
+    email VARCHAR(254) NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    failed_attempts INT NOT NULL DEFAULT 0,
+    locked_until DATETIME NULL,
+    updated_at DATETIME NOT NULL,
+    UNIQUE INDEX idx_user_credential_email (email),
+    CONSTRAINT fk_user_credential_user FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS password_reset (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME NULL,
+    INDEX idx_password_reset_user_id (user_id),
+    CONSTRAINT fk_password_reset_user FOREIGN KEY (user_id) REFERENCES user (id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE user ADD COLUMN verified BOOLEAN NOT NULL DEFAULT FALSE;
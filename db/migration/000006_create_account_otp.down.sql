@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS account_otp;
+
+ALTER TABLE account DROP COLUMN status;
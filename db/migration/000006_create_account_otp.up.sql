@@ -0,0 +1,11 @@
+ALTER TABLE account ADD COLUMN status VARCHAR(10) NOT NULL DEFAULT 'active';
+
+CREATE TABLE IF NOT EXISTS account_otp (
+    account_id VARCHAR(50) PRIMARY KEY,
+    code_hash CHAR(64) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    INDEX idx_account_otp_expires_at (expires_at),
+    CONSTRAINT fk_account_otp_account FOREIGN KEY (account_id) REFERENCES account (id) ON DELETE CASCADE
+);
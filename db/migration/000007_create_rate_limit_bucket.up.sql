@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS rate_limit_bucket (
+    bucket_key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE NOT NULL,
+    updated_at DATETIME(6) NOT NULL,
+    INDEX idx_rate_limit_bucket_updated_at (updated_at)
+);
@@ -0,0 +1,5 @@
+DELETE pending FROM account pending JOIN account other ON other.msisdn_customer = pending.msisdn_customer AND other.id <> pending.id WHERE pending.status <> 'active';
+
+ALTER TABLE account DROP INDEX idx_account_msisdn_customer, ADD UNIQUE INDEX idx_account_msisdn_customer (msisdn_customer);
+
+ALTER TABLE account DROP INDEX idx_account_active_msisdn, DROP COLUMN active_msisdn;
@@ -0,0 +1,5 @@
+ALTER TABLE account ADD COLUMN active_msisdn VARCHAR(20) GENERATED ALWAYS AS (IF(status = 'active', msisdn_customer, NULL)) STORED;
+
+ALTER TABLE account ADD UNIQUE INDEX idx_account_active_msisdn (active_msisdn);
+
+ALTER TABLE account DROP INDEX idx_account_msisdn_customer, ADD INDEX idx_account_msisdn_customer (msisdn_customer);
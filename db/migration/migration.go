@@ -0,0 +1,185 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrDirty            = errors.New("database is dirty, fix it manually and use force")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+)
+
+// Migration is a single versioned schema change. Up and Down must be
+// idempotent enough to be retried after a forced version.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(ctx context.Context) error
+	Down    func(ctx context.Context) error
+}
+
+// VersionStore persists the currently applied version of a database.
+// A version of 0 means no migration has been applied.
+type VersionStore interface {
+	Version(ctx context.Context) (version int64, dirty bool, err error)
+	SetVersion(ctx context.Context, version int64, dirty bool) error
+}
+
+type Status struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+	Dirty   bool   `json:"dirty"`
+}
+
+type Runner struct {
+	store      VersionStore
+	migrations []Migration
+}
+
+func NewRunner(store VersionStore, migrations []Migration) (*Runner, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Version == sorted[i-1].Version {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, sorted[i].Version)
+		}
+	}
+
+	return &Runner{
+		store:      store,
+		migrations: sorted,
+	}, nil
+}
+
+func (r *Runner) Migrations() []Migration {
+	return r.migrations
+}
+
+// Up applies at most n pending migrations, or all of them when n <= 0.
+// It returns the versions that were applied.
+func (r *Runner) Up(ctx context.Context, n int) ([]int64, error) {
+	current, dirty, err := r.store.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, fmt.Errorf("%w (version %d)", ErrDirty, current)
+	}
+
+	var applied []int64
+	for _, m := range r.migrations {
+		if m.Version <= current {
+			continue
+		}
+		if n > 0 && len(applied) >= n {
+			break
+		}
+
+		if err := r.apply(ctx, m.Version, m.Version, m.Up); err != nil {
+			return applied, fmt.Errorf("migration %d_%s up: %w", m.Version, m.Name, err)
+		}
+		applied = append(applied, m.Version)
+	}
+
+	return applied, nil
+}
+
+// Down rolls back at most n applied migrations, or all of them when n <= 0.
+// It returns the versions that were rolled back.
+func (r *Runner) Down(ctx context.Context, n int) ([]int64, error) {
+	current, dirty, err := r.store.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, fmt.Errorf("%w (version %d)", ErrDirty, current)
+	}
+
+	var reverted []int64
+	for i := len(r.migrations) - 1; i >= 0; i-- {
+		m := r.migrations[i]
+		if m.Version > current {
+			continue
+		}
+		if n > 0 && len(reverted) >= n {
+			break
+		}
+
+		var prev int64
+		if i > 0 {
+			prev = r.migrations[i-1].Version
+		}
+
+		if err := r.apply(ctx, m.Version, prev, m.Down); err != nil {
+			return reverted, fmt.Errorf("migration %d_%s down: %w", m.Version, m.Name, err)
+		}
+		reverted = append(reverted, m.Version)
+	}
+
+	return reverted, nil
+}
+
+// Force sets the stored version without running any migration and clears the
+// dirty flag. Use it after fixing a failed migration by hand.
+func (r *Runner) Force(ctx context.Context, version int64) error {
+	if version != 0 && r.find(version) < 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	return r.store.SetVersion(ctx, version, false)
+}
+
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	current, dirty, err := r.store.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		statuses = append(statuses, Status{
+			Version: m.Version,
+			Name:    m.Name,
+			Applied: m.Version <= current,
+			Dirty:   dirty && m.Version == current,
+		})
+	}
+
+	return statuses, nil
+}
+
+func (r *Runner) Version(ctx context.Context) (int64, bool, error) {
+	return r.store.Version(ctx)
+}
+
+// apply marks the database dirty at version, runs fn, and records target as
+// the new clean version once fn succeeds.
+func (r *Runner) apply(ctx context.Context, version, target int64, fn func(ctx context.Context) error) error {
+	if err := r.store.SetVersion(ctx, version, true); err != nil {
+		return err
+	}
+
+	if fn != nil {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+	}
+
+	return r.store.SetVersion(ctx, target, false)
+}
+
+func (r *Runner) find(version int64) int {
+	for i, m := range r.migrations {
+		if m.Version == version {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,121 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryStore struct {
+	version int64
+	dirty   bool
+}
+
+func (s *memoryStore) Version(ctx context.Context) (int64, bool, error) {
+	return s.version, s.dirty, nil
+}
+
+func (s *memoryStore) SetVersion(ctx context.Context, version int64, dirty bool) error {
+	s.version, s.dirty = version, dirty
+	return nil
+}
+
+func recorder(log *[]string, entry string, err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		*log = append(*log, entry)
+		return err
+	}
+}
+
+func TestRunner_UpDown(t *testing.T) {
+	var calls []string
+	store := &memoryStore{}
+	runner, err := NewRunner(store, []Migration{
+		{Version: 2, Name: "b", Up: recorder(&calls, "up 2", nil), Down: recorder(&calls, "down 2", nil)},
+		{Version: 1, Name: "a", Up: recorder(&calls, "up 1", nil), Down: recorder(&calls, "down 1", nil)},
+		{Version: 3, Name: "c", Up: recorder(&calls, "up 3", nil), Down: recorder(&calls, "down 3", nil)},
+	})
+	assert.NoError(t, err)
+
+	applied, err := runner.Up(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, applied)
+	assert.Equal(t, int64(2), store.version)
+
+	applied, err = runner.Up(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3}, applied)
+
+	reverted, err := runner.Down(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 2}, reverted)
+	assert.Equal(t, int64(1), store.version)
+
+	assert.Equal(t, []string{"up 1", "up 2", "up 3", "down 3", "down 2"}, calls)
+
+	statuses, err := runner.Status(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []Status{
+		{Version: 1, Name: "a", Applied: true},
+		{Version: 2, Name: "b"},
+		{Version: 3, Name: "c"},
+	}, statuses)
+}
+
+func TestRunner_DirtyAndForce(t *testing.T) {
+	store := &memoryStore{}
+	runner, err := NewRunner(store, []Migration{
+		{Version: 1, Name: "a", Up: recorder(new([]string), "up 1", nil)},
+		{Version: 2, Name: "b", Up: recorder(new([]string), "up 2", errors.New("boom"))},
+	})
+	assert.NoError(t, err)
+
+	applied, err := runner.Up(context.Background(), 0)
+	assert.Error(t, err)
+	assert.Equal(t, []int64{1}, applied)
+	assert.Equal(t, int64(2), store.version)
+	assert.True(t, store.dirty)
+
+	_, err = runner.Up(context.Background(), 0)
+	assert.ErrorIs(t, err, ErrDirty)
+
+	assert.ErrorIs(t, runner.Force(context.Background(), 7), ErrUnknownVersion)
+	assert.NoError(t, runner.Force(context.Background(), 1))
+	assert.Equal(t, int64(1), store.version)
+	assert.False(t, store.dirty)
+}
+
+func TestNewRunner_DuplicateVersion(t *testing.T) {
+	_, err := NewRunner(&memoryStore{}, []Migration{{Version: 1, Name: "a"}, {Version: 1, Name: "b"}})
+	assert.ErrorIs(t, err, ErrDuplicateVersion)
+}
+
+func TestLoadSQL_Embedded(t *testing.T) {
+	migrations, err := LoadSQL(sqlFiles, nil)
+	assert.NoError(t, err)
+
+	var versions []int64
+	for _, m := range migrations {
+		assert.NotNil(t, m.Up, "migration %d has no up", m.Version)
+		assert.NotNil(t, m.Down, "migration %d has no down", m.Version)
+		versions = append(versions, m.Version)
+	}
+	assert.Equal(t, []int64{1, 2}, versions[:2])
+}
+
+func TestLoadSQL_MissingUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"000001_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	}
+
+	_, err := LoadSQL(fsys, nil)
+	assert.Error(t, err)
+}
+
+func TestSplitStatements(t *testing.T) {
+	stmts := SplitStatements("CREATE TABLE a (id INT);\n\nCREATE INDEX i ON a (id);\n")
+	assert.Equal(t, []string{"CREATE TABLE a (id INT)", "CREATE INDEX i ON a (id)"}, stmts)
+}
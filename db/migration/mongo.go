@@ -0,0 +1,108 @@
+package migration
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const mongoVersionCollection = "schema_migrations"
+
+type mongoStore struct {
+	coll *mongo.Collection
+}
+
+// NewMongo returns a runner for the Mongo index and validator migrations. The applied
+// version is tracked in the schema_migrations collection of database.
+func NewMongo(database *mongo.Database) (*Runner, error) {
+	return NewRunner(&mongoStore{coll: database.Collection(mongoVersionCollection)}, MongoMigrations(database))
+}
+
+// MongoMigrations mirrors the MySQL migrations for the Mongo user store.
+func MongoMigrations(database *mongo.Database) []Migration {
+	user := database.Collection(UserCollection)
+
+	return []Migration{
+		{
+			Version: 1,
+			Name:    "user_email_index",
+			Up: func(ctx context.Context) error {
+				return createIndexes(ctx, user, []IndexSpec{
+					{Name: "idx_user_email", Keys: bson.D{{Key: "email", Value: 1}}},
+				})
+			},
+			Down: func(ctx context.Context) error {
+				return dropIndex(ctx, user, "idx_user_email")
+			},
+		},
+		{
+			Version: 2,
+			Name:    "user_schema_validator",
+			Up: func(ctx context.Context) error {
+				return applyValidator(ctx, database, UserCollection, UserValidator)
+			},
+			Down: func(ctx context.Context) error {
+				return removeValidator(ctx, database, UserCollection)
+			},
+		},
+		{
+			Version: 3,
+			Name:    "user_verified_field",
+			Up: func(ctx context.Context) error {
+				if err := applyValidator(ctx, database, UserCollection, UserValidator); err != nil {
+					return err
+				}
+				_, err := user.UpdateMany(ctx,
+					bson.M{"verified": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"verified": false}},
+				)
+				return err
+			},
+			// the validator allows documents without verified, so it can
+			// stay as it is
+			Down: func(ctx context.Context) error {
+				_, err := user.UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"verified": ""}})
+				return err
+			},
+		},
+	}
+}
+
+func dropIndex(ctx context.Context, coll *mongo.Collection, name string) error {
+	_, err := coll.Indexes().DropOne(ctx, name)
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound") {
+		return nil
+	}
+	return err
+}
+
+func (s *mongoStore) Version(ctx context.Context) (int64, bool, error) {
+	var doc struct {
+		Version int64 `bson:"version"`
+		Dirty   bool  `bson:"dirty"`
+	}
+
+	err := s.coll.FindOne(ctx, bson.M{"_id": "version"}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return doc.Version, doc.Dirty, nil
+}
+
+func (s *mongoStore) SetVersion(ctx context.Context, version int64, dirty bool) error {
+	_, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": "version"},
+		bson.M{"$set": bson.M{"version": version, "dirty": dirty}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
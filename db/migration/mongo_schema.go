@@ -0,0 +1,192 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const UserCollection = "user"
+
+type IndexSpec struct {
+	Name   string
+	Keys   bson.D
+	Unique bool
+}
+
+func (s IndexSpec) model() mongo.IndexModel {
+	opts := options.Index().SetName(s.Name)
+	if s.Unique {
+		opts.SetUnique(true)
+	}
+	return mongo.IndexModel{Keys: s.Keys, Options: opts}
+}
+
+// UserIndexes are the indexes the user collection is expected to have, on
+// top of the implicit _id index.
+var UserIndexes = []IndexSpec{
+	{Name: "idx_user_email", Keys: bson.D{{Key: "email", Value: 1}}},
+}
+
+// UserValidator is the $jsonSchema validator matching model.User.
+var UserValidator = bson.M{
+	"$jsonSchema": bson.M{
+		"bsonType": "object",
+		"required": bson.A{"_id", "name", "address", "email"},
+		"properties": bson.M{
+			"_id":      bson.M{"bsonType": "string", "maxLength": 50},
+			"name":     bson.M{"bsonType": "string", "maxLength": 50},
+			"address":  bson.M{"bsonType": "string", "maxLength": 50},
+			"email":    bson.M{"bsonType": "string", "maxLength": 254},
+			"verified": bson.M{"bsonType": "bool"},
+		},
+	},
+}
+
+// IndexDrift describes a difference between a declared and an actual index.
+type IndexDrift struct {
+	Collection string
+	Index      string
+	Problem    string
+}
+
+func (d IndexDrift) String() string {
+	return fmt.Sprintf("%s.%s: %s", d.Collection, d.Index, d.Problem)
+}
+
+// EnsureMongoSchema creates the user collection with its validator, or
+// updates the validator when the collection already exists, and creates the
+// declared indexes. It is safe to call on every startup.
+func EnsureMongoSchema(ctx context.Context, database *mongo.Database) error {
+	if err := applyValidator(ctx, database, UserCollection, UserValidator); err != nil {
+		return err
+	}
+
+	return createIndexes(ctx, database.Collection(UserCollection), UserIndexes)
+}
+
+// MongoIndexDrift compares the declared indexes with the ones present in the
+// database and reports missing, unexpected and mismatching indexes.
+func MongoIndexDrift(ctx context.Context, database *mongo.Database) ([]IndexDrift, error) {
+	return indexDrift(ctx, database.Collection(UserCollection), UserIndexes)
+}
+
+func applyValidator(ctx context.Context, database *mongo.Database, name string, validator bson.M) error {
+	names, err := database.ListCollectionNames(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		opts := options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel("strict").
+			SetValidationAction("error")
+		return database.CreateCollection(ctx, name, opts)
+	}
+
+	cmd := bson.D{
+		{Key: "collMod", Value: name},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "strict"},
+		{Key: "validationAction", Value: "error"},
+	}
+	return database.RunCommand(ctx, cmd).Err()
+}
+
+func removeValidator(ctx context.Context, database *mongo.Database, name string) error {
+	cmd := bson.D{
+		{Key: "collMod", Value: name},
+		{Key: "validator", Value: bson.M{}},
+		{Key: "validationLevel", Value: "off"},
+	}
+
+	err := database.RunCommand(ctx, cmd).Err()
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Name == "NamespaceNotFound" {
+		return nil
+	}
+	return err
+}
+
+func createIndexes(ctx context.Context, coll *mongo.Collection, specs []IndexSpec) error {
+	if len(specs) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.IndexModel, 0, len(specs))
+	for _, s := range specs {
+		models = append(models, s.model())
+	}
+
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+func indexDrift(ctx context.Context, coll *mongo.Collection, specs []IndexSpec) ([]IndexDrift, error) {
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var actual []struct {
+		Name   string `bson:"name"`
+		Key    bson.D `bson:"key"`
+		Unique bool   `bson:"unique"`
+	}
+	if err := cursor.All(ctx, &actual); err != nil {
+		return nil, err
+	}
+
+	var drift []IndexDrift
+	seen := map[string]bool{}
+
+	for _, spec := range specs {
+		seen[spec.Name] = true
+
+		found := false
+		for _, idx := range actual {
+			if idx.Name != spec.Name {
+				continue
+			}
+			found = true
+
+			if !sameKeys(spec.Keys, idx.Key) {
+				drift = append(drift, IndexDrift{coll.Name(), spec.Name, fmt.Sprintf("keys are %v, want %v", idx.Key, spec.Keys)})
+			}
+			if spec.Unique != idx.Unique {
+				drift = append(drift, IndexDrift{coll.Name(), spec.Name, fmt.Sprintf("unique is %t, want %t", idx.Unique, spec.Unique)})
+			}
+		}
+
+		if !found {
+			drift = append(drift, IndexDrift{coll.Name(), spec.Name, "missing"})
+		}
+	}
+
+	for _, idx := range actual {
+		if idx.Name == "_id_" || seen[idx.Name] {
+			continue
+		}
+		drift = append(drift, IndexDrift{coll.Name(), idx.Name, "not declared"})
+	}
+
+	return drift, nil
+}
+
+func sameKeys(want, got bson.D) bool {
+	if len(want) != len(got) {
+		return false
+	}
+
+	for i := range want {
+		if want[i].Key != got[i].Key || fmt.Sprint(want[i].Value) != fmt.Sprint(got[i].Value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package migration
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUserValidator_MatchesModel(t *testing.T) {
+	schema := UserValidator["$jsonSchema"].(bson.M)
+	properties := schema["properties"].(bson.M)
+
+	typ := reflect.TypeOf(model.User{})
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("bson"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		assert.Contains(t, properties, name, "validator is missing field %s", name)
+	}
+
+	for _, field := range schema["required"].(bson.A) {
+		assert.Contains(t, properties, field)
+	}
+}
+
+func TestSameKeys(t *testing.T) {
+	want := bson.D{{Key: "email", Value: 1}}
+
+	assert.True(t, sameKeys(want, bson.D{{Key: "email", Value: int32(1)}}))
+	assert.False(t, sameKeys(want, bson.D{{Key: "email", Value: -1}}))
+	assert.False(t, sameKeys(want, bson.D{{Key: "name", Value: 1}}))
+	assert.False(t, sameKeys(want, bson.D{{Key: "email", Value: 1}, {Key: "name", Value: 1}}))
+}
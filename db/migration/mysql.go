@@ -0,0 +1,161 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed *.sql
+var sqlFiles embed.FS
+
+var fileNameRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+const createVersionTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT NOT NULL PRIMARY KEY,
+    dirty BOOLEAN NOT NULL
+)`
+
+type mysqlStore struct {
+	db *sqlx.DB
+}
+
+// NewMysql returns a runner for the SQL migrations embedded in this package.
+// The applied version is tracked in the schema_migrations table.
+func NewMysql(ctx context.Context, db *sqlx.DB) (*Runner, error) {
+	if _, err := db.ExecContext(ctx, createVersionTable); err != nil {
+		return nil, err
+	}
+
+	migrations, err := LoadSQL(sqlFiles, db)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRunner(&mysqlStore{db: db}, migrations)
+}
+
+// LoadSQL reads NNNNNN_name.up.sql / NNNNNN_name.down.sql pairs from fsys.
+// Each file may hold several statements separated by semicolons.
+func LoadSQL(fsys fs.FS, db *sqlx.DB) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	var order []int64
+
+	for _, entry := range entries {
+		match := fileNameRe.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+			order = append(order, version)
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("%w: %d (%s, %s)", ErrDuplicateVersion, version, m.Name, match[2])
+		}
+
+		fn := execStatements(db, SplitStatements(string(content)))
+		if match[3] == "up" {
+			m.Up = fn
+		} else {
+			m.Down = fn
+		}
+	}
+
+	migrations := make([]Migration, 0, len(order))
+	for _, v := range order {
+		m := byVersion[v]
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	return migrations, nil
+}
+
+// SplitStatements splits a SQL script on semicolons. Migration files must not
+// contain semicolons inside string literals.
+func SplitStatements(script string) []string {
+	var stmts []string
+	for _, part := range strings.Split(script, ";") {
+		if stmt := strings.TrimSpace(part); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+func execStatements(db *sqlx.DB, stmts []string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for _, stmt := range stmts {
+			if _, err := db.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func (s *mysqlStore) Version(ctx context.Context) (int64, bool, error) {
+	var row struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+
+	err := s.db.GetContext(ctx, &row, "SELECT version, dirty FROM schema_migrations LIMIT 1")
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return row.Version, row.Dirty, nil
+}
+
+func (s *mysqlStore) SetVersion(ctx context.Context, version int64, dirty bool) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+
+	if version > 0 || dirty {
+		sqlstr := "INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)"
+		if _, err := tx.ExecContext(ctx, sqlstr, version, dirty); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -2,8 +2,8 @@ package db
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"log/slog"
 
 	_ "github.com/go-sql-driver/mysql"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -13,11 +13,17 @@ import (
 
 var MongoCLI *mongo.Client
 
-func InitMongoDB() (err error) {
+// MongoDBName is the database holding the user collection.
+var MongoDBName = "user"
 
-	MongoCLI, err = mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017/user").SetMaxPoolSize(50))
+// InitMongoDB connects MongoCLI. opts are applied after the defaults, e.g.
+// to set a pool monitor.
+func InitMongoDB(opts ...*options.ClientOptions) (err error) {
+	base := options.Client().ApplyURI("mongodb://localhost:27017/user").SetMaxPoolSize(50)
+
+	MongoCLI, err = mongo.Connect(context.Background(), append([]*options.ClientOptions{base}, opts...)...)
 	if err != nil {
-		fmt.Printf("connect DB failed, err:%v\n", err)
+		slog.Error("connect mongo failed", "error", err)
 		return
 	}
 
@@ -28,8 +34,6 @@ func InitMongoDB() (err error) {
 	return
 }
 
-func Disconnect() {
-	if err := MongoCLI.Disconnect(context.Background()); err != nil {
-		panic(err)
-	}
+func Disconnect(ctx context.Context) error {
+	return MongoCLI.Disconnect(ctx)
 }
@@ -1,22 +1,36 @@
 package db
 
 import (
-	"fmt"
+	"log/slog"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 var DB *sqlx.DB
 
 func InitMysqlDB() (err error) {
-	dsn := "root@tcp(127.0.0.1:3306)/user"
-	DB, err = sqlx.Connect("mysql", dsn)
+	dsn := "root@tcp(127.0.0.1:3306)/user?parseTime=true"
+
+	// statements are traced with the global tracer provider
+	sqlDB, err := otelsql.Open("mysql", dsn, otelsql.WithAttributes(semconv.DBSystemMySQL))
 	if err != nil {
-		fmt.Printf("connect DB failed, err:%v\n", err)
+		slog.Error("connect mysql failed", "error", err)
+		return
+	}
+
+	DB = sqlx.NewDb(sqlDB, "mysql")
+	if err = DB.Ping(); err != nil {
+		slog.Error("connect mysql failed", "error", err)
 		return
 	}
 
 	DB.SetMaxOpenConns(50)
 	return
 }
+
+func CloseMysqlDB() error {
+	return DB.Close()
+}
@@ -0,0 +1,221 @@
+// Package apikey issues and checks long-lived API keys for partner systems.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+// Header carries the API key on requests.
+const Header = "X-API-Key"
+
+const (
+	keyPrefix     = "tefa_"
+	touchInterval = 30 * time.Second
+)
+
+var (
+	ErrInvalidKey   = errors.New("invalid api key")
+	ErrRevoked      = errors.New("api key revoked")
+	ErrInvalidScope = errors.New("invalid scope")
+	ErrInvalidName  = errors.New("api key name is required")
+)
+
+// Principal is the caller authenticated by key, allowed exactly the scopes of
+// the key.
+func Principal(key model.APIKey) authz.Principal {
+	scopes := make([]authz.Permission, 0, len(key.Scopes))
+	for _, s := range key.Scopes {
+		scopes = append(scopes, authz.Permission(s))
+	}
+
+	return authz.Principal{
+		Subject: "apikey:" + key.ID,
+		Scopes:  scopes,
+	}
+}
+
+// RateLimitError is returned by Authenticate when the key exhausted its
+// per-minute budget.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("api key rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+type Service struct {
+	repo   repository.APIKeyRepositoryInterface
+	scopes map[string]bool
+	now    func() time.Time
+
+	limiter *limiter
+
+	mu       sync.Mutex
+	lastUsed map[string]time.Time
+}
+
+type Option func(*Service)
+
+// WithClock makes the service read the current time from now instead of
+// time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+// NewService returns a service that only grants the given scopes.
+func NewService(repo repository.APIKeyRepositoryInterface, allowedScopes []string, opts ...Option) *Service {
+	scopes := make(map[string]bool, len(allowedScopes))
+	for _, s := range allowedScopes {
+		scopes[s] = true
+	}
+
+	s := &Service{
+		repo:     repo,
+		scopes:   scopes,
+		now:      time.Now,
+		limiter:  newLimiter(),
+		lastUsed: map[string]time.Time{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Create stores a new key and returns it together with the plaintext key,
+// which is never available again.
+func (s *Service) Create(ctx context.Context, name string, scopes []string, rateLimit int) (model.APIKey, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return model.APIKey{}, "", ErrInvalidName
+	}
+	if rateLimit < 0 {
+		return model.APIKey{}, "", fmt.Errorf("rate limit must not be negative")
+	}
+	for _, scope := range scopes {
+		if !s.scopes[scope] {
+			return model.APIKey{}, "", fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+
+	plain, err := generate()
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+
+	key, err := s.repo.InsertAPIKey(ctx, model.APIKey{
+		Name:      name,
+		Prefix:    plain[:len(keyPrefix)+8],
+		KeyHash:   hash(plain),
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+		CreatedAt: s.now().UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+
+	return key, plain, nil
+}
+
+func (s *Service) List(ctx context.Context) ([]model.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx)
+}
+
+func (s *Service) Revoke(ctx context.Context, id string) error {
+	return s.repo.RevokeAPIKey(ctx, id, s.now().UTC().Truncate(time.Second))
+}
+
+// Authenticate resolves a plaintext key, rejecting unknown and revoked keys
+// and keys over their rate limit.
+func (s *Service) Authenticate(ctx context.Context, plain string) (model.APIKey, error) {
+	if !strings.HasPrefix(plain, keyPrefix) {
+		return model.APIKey{}, ErrInvalidKey
+	}
+
+	key, err := s.repo.GetAPIKeyByHash(ctx, hash(plain))
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return model.APIKey{}, ErrInvalidKey
+	}
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	if key.RevokedAt != nil {
+		return model.APIKey{}, ErrRevoked
+	}
+
+	now := s.now()
+	if wait := s.limiter.take(key.ID, key.RateLimit, now); wait > 0 {
+		return model.APIKey{}, &RateLimitError{RetryAfter: wait}
+	}
+
+	s.mu.Lock()
+	s.lastUsed[key.ID] = now
+	s.mu.Unlock()
+
+	return key, nil
+}
+
+// Run periodically writes last-used timestamps until ctx is cancelled, then
+// flushes once more. Batching keeps authentication off the write path.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(touchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush(ctx)
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			s.Flush(flushCtx)
+			cancel()
+			return
+		}
+	}
+}
+
+// Flush writes pending last-used timestamps.
+func (s *Service) Flush(ctx context.Context) {
+	s.mu.Lock()
+	pending := s.lastUsed
+	s.lastUsed = map[string]time.Time{}
+	s.mu.Unlock()
+
+	for id, at := range pending {
+		if err := s.repo.TouchAPIKey(ctx, id, at.UTC().Truncate(time.Second)); err != nil {
+			logging.FromContext(ctx).Error("error recording api key use", "api_key_id", id, "error", err)
+		}
+	}
+}
+
+func generate() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hash(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/internal/clocktest"
+	"github.com/vier21/tefa-ch3/internal/repository/memory"
+)
+
+func newTestService(t *testing.T) (*Service, *memory.APIKeyRepository, *clocktest.Clock) {
+	t.Helper()
+
+	clock := clocktest.New()
+	repo := memory.NewAPIKeyRepository()
+	svc := NewService(repo, []string{"user:read", "account:read"}, WithClock(clock.Now))
+
+	return svc, repo, clock
+}
+
+func TestCreate(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	key, plain, err := svc.Create(ctx, "billing", []string{"user:read"}, 10)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, key.ID)
+	assert.Equal(t, plain[:len(key.Prefix)], key.Prefix)
+	assert.Equal(t, hash(plain), key.KeyHash)
+	assert.NotContains(t, key.KeyHash, plain)
+
+	_, _, err = svc.Create(ctx, "billing", []string{"apikey:manage"}, 10)
+	assert.ErrorIs(t, err, ErrInvalidScope)
+
+	_, _, err = svc.Create(ctx, " ", nil, 10)
+	assert.ErrorIs(t, err, ErrInvalidName)
+}
+
+func TestAuthenticate(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	key, plain, err := svc.Create(ctx, "billing", []string{"user:read"}, 0)
+	assert.NoError(t, err)
+
+	got, err := svc.Authenticate(ctx, plain)
+	assert.NoError(t, err)
+	assert.Equal(t, key.ID, got.ID)
+	assert.Equal(t, []string{"user:read"}, got.Scopes)
+
+	_, err = svc.Authenticate(ctx, plain+"x")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = svc.Authenticate(ctx, "not-a-key")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	assert.NoError(t, svc.Revoke(ctx, key.ID))
+	_, err = svc.Authenticate(ctx, plain)
+	assert.ErrorIs(t, err, ErrRevoked)
+}
+
+func TestAuthenticate_RateLimit(t *testing.T) {
+	svc, _, clock := newTestService(t)
+	ctx := context.Background()
+
+	_, plain, err := svc.Create(ctx, "billing", nil, 2)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.Authenticate(ctx, plain)
+		assert.NoError(t, err)
+	}
+
+	_, err = svc.Authenticate(ctx, plain)
+	var limited *RateLimitError
+	if assert.True(t, errors.As(err, &limited)) {
+		assert.Equal(t, 30*time.Second, limited.RetryAfter)
+	}
+
+	clock.Advance(30 * time.Second)
+	_, err = svc.Authenticate(ctx, plain)
+	assert.NoError(t, err)
+}
+
+func TestFlush(t *testing.T) {
+	svc, repo, clock := newTestService(t)
+	ctx := context.Background()
+
+	key, plain, err := svc.Create(ctx, "billing", nil, 0)
+	assert.NoError(t, err)
+
+	_, err = svc.Authenticate(ctx, plain)
+	assert.NoError(t, err)
+
+	stored, err := repo.GetAPIKeyByHash(ctx, key.KeyHash)
+	assert.NoError(t, err)
+	assert.Nil(t, stored.LastUsedAt, "last use should only be written on flush")
+
+	svc.Flush(ctx)
+
+	stored, err = repo.GetAPIKeyByHash(ctx, key.KeyHash)
+	assert.NoError(t, err)
+	if assert.NotNil(t, stored.LastUsedAt) {
+		assert.True(t, clock.Now().Equal(*stored.LastUsedAt))
+	}
+}
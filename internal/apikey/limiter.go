@@ -0,0 +1,55 @@
+package apikey
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter is a per-key token bucket refilled at perMinute tokens per minute
+// with a burst of perMinute.
+type limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter() *limiter {
+	return &limiter{buckets: map[string]*bucket{}}
+}
+
+// take consumes a token for id and returns how long to wait when none is
+// left. A perMinute of 0 disables limiting.
+func (l *limiter) take(id string, perMinute int, now time.Time) time.Duration {
+	if perMinute <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	capacity := float64(perMinute)
+	rate := capacity / float64(time.Minute)
+
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[id] = b
+	}
+
+	b.tokens += float64(now.Sub(b.last)) * rate
+	if b.tokens > capacity {
+		b.tokens = capacity
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / rate)
+	}
+
+	b.tokens--
+	return 0
+}
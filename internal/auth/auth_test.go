@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	oldKey = Key{ID: "2023-01", Secret: []byte("old-secret")}
+	newKey = Key{ID: "2024-01", Secret: []byte("new-secret")}
+)
+
+func newTestService(t *testing.T, cfg Config) *TokenService {
+	t.Helper()
+
+	if cfg.Keys == nil {
+		cfg.Keys = []Key{newKey}
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = "tefa-ch3"
+	}
+	if cfg.Audience == "" {
+		cfg.Audience = "tefa-ch3"
+	}
+
+	s, err := NewTokenService(cfg)
+	require.NoError(t, err)
+	return s
+}
+
+func TestTokenService_IssueVerify(t *testing.T) {
+	s := newTestService(t, Config{TTL: time.Minute})
+
+	token, expires, err := s.Issue("user-1", "admin")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expires, time.Second)
+
+	claims, err := s.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+}
+
+func TestTokenService_Expired(t *testing.T) {
+	s := newTestService(t, Config{})
+
+	token, _, err := s.IssueWithTTL("user-1", -time.Minute)
+	require.NoError(t, err)
+
+	_, err = s.Verify(token)
+	assert.Error(t, err)
+}
+
+func TestTokenService_IssuerAndAudience(t *testing.T) {
+	other := newTestService(t, Config{Issuer: "someone-else", Audience: "another-api"})
+	s := newTestService(t, Config{})
+
+	token, _, err := other.Issue("user-1")
+	require.NoError(t, err)
+
+	_, err = s.Verify(token)
+	assert.Error(t, err)
+}
+
+func TestTokenService_KeyRotation(t *testing.T) {
+	before := newTestService(t, Config{Keys: []Key{oldKey}})
+	after := newTestService(t, Config{Keys: []Key{oldKey, newKey}, SigningKeyID: newKey.ID})
+	retired := newTestService(t, Config{Keys: []Key{newKey}})
+
+	oldToken, _, err := before.Issue("user-1")
+	require.NoError(t, err)
+
+	_, err = after.Verify(oldToken)
+	assert.NoError(t, err, "tokens signed with a still configured key stay valid")
+
+	_, err = retired.Verify(oldToken)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	newToken, _, err := after.Issue("user-1")
+	require.NoError(t, err)
+	_, err = retired.Verify(newToken)
+	assert.NoError(t, err)
+}
+
+func TestNewTokenService_Errors(t *testing.T) {
+	_, err := NewTokenService(Config{})
+	assert.ErrorIs(t, err, ErrNoKeys)
+
+	_, err = NewTokenService(Config{Keys: []Key{newKey}, SigningKeyID: "nope"})
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestMiddleware(t *testing.T) {
+	s := newTestService(t, Config{})
+	handler := s.Middleware(RequireRole("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFrom(r.Context())
+		assert.True(t, ok)
+		w.Write([]byte(claims.Subject))
+	})))
+
+	admin, _, _ := s.Issue("admin-1", "admin")
+	customer, _, _ := s.Issue("user-1", "customer")
+
+	tests := []struct {
+		name   string
+		header string
+		code   int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"not bearer", "Basic Zm9vOmJhcg==", http.StatusUnauthorized},
+		{"garbage token", "Bearer abc.def.ghi", http.StatusUnauthorized},
+		{"wrong role", "Bearer " + customer, http.StatusForbidden},
+		{"ok", "Bearer " + admin, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.code != http.StatusOK {
+				var res ErrorResponse
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+				assert.NotEmpty(t, res.Error)
+			}
+		})
+	}
+}
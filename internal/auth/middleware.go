@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+type ErrorResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+}
+
+// ClaimsFrom returns the claims of the authenticated caller.
+func ClaimsFrom(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// Middleware rejects requests without a valid bearer token with 401 and
+// stores the token claims in the request context.
+func (s *TokenService) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := bearerToken(r)
+		if !ok {
+			Unauthorized(w, "missing bearer token")
+			return
+		}
+
+		claims, err := s.Verify(raw)
+		if err != nil {
+			Unauthorized(w, err.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+	})
+}
+
+// RequireRole answers 403 unless the caller has one of roles.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFrom(r.Context())
+			if !ok {
+				Unauthorized(w, "missing bearer token")
+				return
+			}
+
+			for _, have := range claims.Roles {
+				for _, want := range roles {
+					if have == want {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+			}
+
+			Forbidden(w, "insufficient role")
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func Unauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	writeError(w, http.StatusUnauthorized, "Unauthorized", msg)
+}
+
+func Forbidden(w http.ResponseWriter, msg string) {
+	writeError(w, http.StatusForbidden, "Forbidden", msg)
+}
+
+func writeError(w http.ResponseWriter, code int, text, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Status: fmt.Sprintf("%s (%d)", text, code),
+		Error:  msg,
+	})
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/vier21/tefa-ch3/config"
+)
+
+var (
+	ErrNoKeys         = errors.New("auth: no signing keys configured")
+	ErrUnknownKey     = errors.New("auth: unknown key id")
+	ErrMissingSubject = errors.New("auth: token has no subject")
+)
+
+// Key is an HMAC secret identified by the kid header of the tokens it signs.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+type Config struct {
+	// Keys are all keys accepted when verifying. Keep a retired key here
+	// until every token it signed has expired.
+	Keys []Key
+	// SigningKeyID selects the key used for new tokens, the first key when
+	// empty.
+	SigningKeyID string
+	Issuer       string
+	Audience     string
+	TTL          time.Duration
+}
+
+// FromConfig builds the token service configuration from the environment.
+func FromConfig(cfg *config.Config) Config {
+	keys := make([]Key, 0, len(cfg.JWTKeys))
+	for _, k := range cfg.JWTKeys {
+		keys = append(keys, Key{ID: k.ID, Secret: k.Secret})
+	}
+
+	return Config{
+		Keys:         keys,
+		SigningKeyID: cfg.JWTSigningKeyID,
+		Issuer:       cfg.JWTIssuer,
+		Audience:     cfg.JWTAudience,
+		TTL:          cfg.JWTTTL,
+	}
+}
+
+type Claims struct {
+	Roles []string `json:"roles,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type TokenService struct {
+	keys     map[string][]byte
+	signing  Key
+	issuer   string
+	audience string
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+func NewTokenService(cfg Config) (*TokenService, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	s := &TokenService{
+		keys:     make(map[string][]byte, len(cfg.Keys)),
+		signing:  cfg.Keys[0],
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      cfg.TTL,
+		now:      time.Now,
+	}
+
+	for _, k := range cfg.Keys {
+		if len(k.Secret) == 0 {
+			return nil, fmt.Errorf("auth: key %q has an empty secret", k.ID)
+		}
+		s.keys[k.ID] = k.Secret
+		if k.ID == cfg.SigningKeyID {
+			s.signing = k
+		}
+	}
+
+	if cfg.SigningKeyID != "" && s.signing.ID != cfg.SigningKeyID {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, cfg.SigningKeyID)
+	}
+
+	if s.ttl <= 0 {
+		s.ttl = 15 * time.Minute
+	}
+
+	return s, nil
+}
+
+// Issue signs a token for subject with the current signing key.
+func (s *TokenService) Issue(subject string, roles ...string) (string, time.Time, error) {
+	return s.IssueWithTTL(subject, s.ttl, roles...)
+}
+
+func (s *TokenService) IssueWithTTL(subject string, ttl time.Duration, roles ...string) (string, time.Time, error) {
+	now := s.now()
+	expires := now.Add(ttl)
+
+	claims := Claims{
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    s.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	}
+	if s.audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.audience}
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = s.signing.ID
+
+	signed, err := token.SignedString(s.signing.Secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expires, nil
+}
+
+// Verify checks the signature, expiry, issuer and audience of raw.
+func (s *TokenService) Verify(raw string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	}
+	if s.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.issuer))
+	}
+	if s.audience != "" {
+		opts = append(opts, jwt.WithAudience(s.audience))
+	}
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(raw, &claims, s.keyFunc, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" {
+		return nil, ErrMissingSubject
+	}
+
+	return &claims, nil
+}
+
+func (s *TokenService) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = s.signing.ID
+	}
+
+	secret, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+
+	return secret, nil
+}
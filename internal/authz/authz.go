@@ -0,0 +1,168 @@
+// Package authz decides what an authenticated caller may do with users and
+// accounts.
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/vier21/tefa-ch3/internal/auth"
+)
+
+const (
+	RoleAdmin    = "admin"
+	RoleCustomer = "customer"
+	RoleSupport  = "support"
+)
+
+type Permission string
+
+const (
+	UserRead      Permission = "user:read"
+	UserVerify    Permission = "user:verify"
+	UserDelete    Permission = "user:delete"
+	AccountCreate Permission = "account:create"
+	AccountRead   Permission = "account:read"
+	APIKeyManage  Permission = "apikey:manage"
+)
+
+// APIKeyScopes are the permissions that may be granted to an API key.
+var APIKeyScopes = []Permission{UserRead, AccountCreate, AccountRead}
+
+// Scope limits a permission to the caller's own records or to any record.
+type Scope int
+
+const (
+	ScopeNone Scope = iota
+	ScopeOwn
+	ScopeAny
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("permission denied")
+)
+
+// Policy maps every role to the permissions it grants.
+type Policy map[string]map[Permission]Scope
+
+// DefaultPolicy lets admins manage everything, customers manage only their
+// own user and accounts, and support staff read anything.
+var DefaultPolicy = Policy{
+	RoleAdmin: {
+		UserRead:      ScopeAny,
+		UserVerify:    ScopeAny,
+		UserDelete:    ScopeAny,
+		AccountCreate: ScopeAny,
+		AccountRead:   ScopeAny,
+		APIKeyManage:  ScopeAny,
+	},
+	RoleCustomer: {
+		UserRead:      ScopeOwn,
+		UserVerify:    ScopeOwn,
+		AccountCreate: ScopeOwn,
+		AccountRead:   ScopeOwn,
+	},
+	RoleSupport: {
+		UserRead:    ScopeAny,
+		AccountRead: ScopeAny,
+	},
+}
+
+// Principal is the authenticated caller. Users get permissions through
+// Roles, API keys directly through Scopes, which apply to any record.
+type Principal struct {
+	Subject string
+	Roles   []string
+	Scopes  []Permission
+}
+
+type contextKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// PrincipalFrom returns the authenticated caller stored in ctx, either
+// directly or as JWT claims.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	if p, ok := ctx.Value(contextKey{}).(Principal); ok {
+		return p, true
+	}
+
+	claims, ok := auth.ClaimsFrom(ctx)
+	if !ok {
+		return Principal{}, false
+	}
+
+	return Principal{Subject: claims.Subject, Roles: claims.Roles}, true
+}
+
+// Scope returns the widest scope any of the principal's roles grants for perm.
+func (p Policy) Scope(principal Principal, perm Permission) Scope {
+	for _, granted := range principal.Scopes {
+		if granted == perm {
+			return ScopeAny
+		}
+	}
+
+	scope := ScopeNone
+	for _, role := range principal.Roles {
+		if s := p[role][perm]; s > scope {
+			scope = s
+		}
+	}
+	return scope
+}
+
+// Authorize checks that the caller in ctx holds perm on a record owned by
+// ownerID. An empty ownerID belongs to nobody, so only ScopeAny grants it.
+func (p Policy) Authorize(ctx context.Context, perm Permission, ownerID string) error {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	switch p.Scope(principal, perm) {
+	case ScopeAny:
+		return nil
+	case ScopeOwn:
+		if ownerID != "" && ownerID == principal.Subject {
+			return nil
+		}
+	}
+
+	return ErrForbidden
+}
+
+// Granted checks that the caller in ctx holds perm in any scope, for when
+// the owner of the record is not known yet.
+func (p Policy) Granted(ctx context.Context, perm Permission) error {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if p.Scope(principal, perm) == ScopeNone {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// Require is route middleware answering 403 when the caller's roles do not
+// grant perm in any scope. Ownership is checked later by the usecase.
+func (p Policy) Require(perm Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch err := p.Granted(r.Context(), perm); {
+			case errors.Is(err, ErrUnauthenticated):
+				auth.Unauthorized(w, err.Error())
+			case err != nil:
+				auth.Forbidden(w, err.Error())
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
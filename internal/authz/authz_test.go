@@ -0,0 +1,81 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/internal/auth"
+)
+
+func withPrincipal(subject string, roles ...string) context.Context {
+	return auth.WithClaims(context.Background(), &auth.Claims{
+		Roles:            roles,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: subject},
+	})
+}
+
+func TestDefaultPolicy_Authorize(t *testing.T) {
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		perm  Permission
+		owner string
+		err   error
+	}{
+		{"anonymous", context.Background(), UserRead, "u1", ErrUnauthenticated},
+
+		{"admin any user", withPrincipal("a1", RoleAdmin), UserRead, "u1", nil},
+		{"admin any account", withPrincipal("a1", RoleAdmin), AccountCreate, "u1", nil},
+
+		{"customer own user", withPrincipal("u1", RoleCustomer), UserRead, "u1", nil},
+		{"customer other user", withPrincipal("u1", RoleCustomer), UserRead, "u2", ErrForbidden},
+		{"customer own account", withPrincipal("u1", RoleCustomer), AccountCreate, "u1", nil},
+		{"customer other account", withPrincipal("u1", RoleCustomer), AccountRead, "u2", ErrForbidden},
+		{"customer without owner", withPrincipal("u1", RoleCustomer), AccountCreate, "", ErrForbidden},
+		{"customer with empty subject", withPrincipal("", RoleCustomer), AccountCreate, "", ErrForbidden},
+
+		{"support any user", withPrincipal("s1", RoleSupport), UserRead, "u1", nil},
+		{"support any account", withPrincipal("s1", RoleSupport), AccountRead, "u1", nil},
+		{"support write", withPrincipal("s1", RoleSupport), AccountCreate, "u1", ErrForbidden},
+
+		{"widest role wins", withPrincipal("u1", RoleCustomer, RoleSupport), UserRead, "u2", nil},
+		{"unknown role", withPrincipal("u1", "guest"), UserRead, "u1", ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DefaultPolicy.Authorize(tt.ctx, tt.perm, tt.owner)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDefaultPolicy_Granted(t *testing.T) {
+	assert.ErrorIs(t, DefaultPolicy.Granted(context.Background(), AccountRead), ErrUnauthenticated)
+	assert.NoError(t, DefaultPolicy.Granted(withPrincipal("u1", RoleCustomer), AccountRead))
+	assert.NoError(t, DefaultPolicy.Granted(withPrincipal("s1", RoleSupport), AccountRead))
+	assert.ErrorIs(t, DefaultPolicy.Granted(withPrincipal("s1", RoleSupport), AccountCreate), ErrForbidden)
+}
+
+func TestPolicy_Require(t *testing.T) {
+	handler := DefaultPolicy.Require(AccountCreate)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for ctx, code := range map[context.Context]int{
+		context.Background():              http.StatusUnauthorized,
+		withPrincipal("s1", RoleSupport):  http.StatusForbidden,
+		withPrincipal("u1", RoleCustomer): http.StatusOK,
+		withPrincipal("a1", RoleAdmin):    http.StatusOK,
+	} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("POST", "/account", nil).WithContext(ctx))
+		assert.Equal(t, code, rr.Code)
+	}
+}
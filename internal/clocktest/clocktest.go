@@ -0,0 +1,37 @@
+// Package clocktest provides a manually advanced clock for testing services
+// that depend on the current time.
+package clocktest
+
+import (
+	"sync"
+	"time"
+)
+
+// Start is the time every new Clock shows.
+var Start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// Clock only moves when advanced. It is safe for concurrent use.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func New() *Clock {
+	return &Clock{now: Start}
+}
+
+// Now returns the current time of the clock. It can be handed to services
+// in place of time.Now.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
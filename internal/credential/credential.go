@@ -0,0 +1,310 @@
+// Package credential manages password logins: hashing, verification with
+// lockout after repeated failures, and one-time password reset tokens.
+package credential
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vier21/tefa-ch3/config"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/mail"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	MinPasswordLength = 8
+	// MaxPasswordLength is the most bcrypt will hash.
+	MaxPasswordLength = 72
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrWeakPassword       = fmt.Errorf("password must be %d to %d bytes long", MinPasswordLength, MaxPasswordLength)
+)
+
+// LockedError is returned by Login while the credential is locked after too
+// many failed attempts.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed logins, retry after %s", e.RetryAfter)
+}
+
+// Notifier delivers password reset tokens to their owner.
+type Notifier interface {
+	PasswordReset(ctx context.Context, email, token string) error
+}
+
+// LogNotifier logs that a reset was requested instead of delivering the
+// token, which it never logs. It is meant for local development only.
+type LogNotifier struct{}
+
+func (LogNotifier) PasswordReset(ctx context.Context, email, token string) error {
+	logging.FromContext(ctx).Info("password reset requested", "email", email)
+	return nil
+}
+
+// MailNotifier mails reset tokens to the user.
+type MailNotifier struct {
+	Mailer mail.Mailer
+}
+
+func (n MailNotifier) PasswordReset(ctx context.Context, email, token string) error {
+	return n.Mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use the token below to choose a new password. It can only be used once.\n\n%s\n\n"+
+			"If you did not ask to reset your password you can ignore this mail.\n", token),
+	})
+}
+
+type Config struct {
+	// MaxAttempts failed logins in a row lock the credential for Lockout.
+	MaxAttempts int
+	Lockout     time.Duration
+	ResetTTL    time.Duration
+	BcryptCost  int
+}
+
+func FromConfig(cfg *config.Config) Config {
+	return Config{
+		MaxAttempts: cfg.PasswordMaxAttempts,
+		Lockout:     cfg.PasswordLockout,
+		ResetTTL:    cfg.PasswordResetTTL,
+		BcryptCost:  cfg.PasswordBcryptCost,
+	}
+}
+
+type Service struct {
+	repo     repository.CredentialRepositoryInterface
+	notifier Notifier
+	cfg      Config
+	now      func() time.Time
+
+	// dummyHash is compared against when the email is unknown so that
+	// lookups take as long as real password checks.
+	dummyHash []byte
+}
+
+type Option func(*Service)
+
+// WithClock makes the service read the current time from now instead of
+// time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func NewService(repo repository.CredentialRepositoryInterface, notifier Notifier, cfg Config, opts ...Option) (*Service, error) {
+	if cfg.MaxAttempts < 1 {
+		return nil, fmt.Errorf("credential: max attempts must be at least 1")
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = bcrypt.DefaultCost
+	}
+
+	dummy, err := bcrypt.GenerateFromPassword([]byte("not-a-real-password"), cfg.BcryptCost)
+	if err != nil {
+		return nil, fmt.Errorf("credential: %w", err)
+	}
+
+	s := &Service{
+		repo:      repo,
+		notifier:  notifier,
+		cfg:       cfg,
+		now:       time.Now,
+		dummyHash: dummy,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Validate reports whether password is acceptable as a new password.
+func Validate(password string) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// CheckAvailable returns repository.ErrCredentialExists if email already
+// has a password login.
+func (s *Service) CheckAvailable(ctx context.Context, email string) error {
+	_, err := s.repo.GetCredentialByEmail(ctx, email)
+	switch {
+	case err == nil:
+		return repository.ErrCredentialExists
+	case errors.Is(err, repository.ErrCredentialNotFound):
+		return nil
+	default:
+		return err
+	}
+}
+
+// SetPassword creates the password login for a newly registered user.
+func (s *Service) SetPassword(ctx context.Context, userID, email, password string) error {
+	hash, err := s.hash(password)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.InsertCredential(ctx, model.Credential{
+		UserID:       userID,
+		Email:        email,
+		PasswordHash: hash,
+		UpdatedAt:    s.timestamp(),
+	})
+}
+
+// Login checks email and password and returns the user ID. Every wrong
+// password counts towards the lockout, and a successful login resets it.
+func (s *Service) Login(ctx context.Context, email, password string) (string, error) {
+	cred, err := s.repo.GetCredentialByEmail(ctx, email)
+	if errors.Is(err, repository.ErrCredentialNotFound) {
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return "", ErrInvalidCredentials
+	}
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now()
+	if cred.LockedUntil != nil && now.Before(*cred.LockedUntil) {
+		return "", &LockedError{RetryAfter: cred.LockedUntil.Sub(now)}
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(cred.PasswordHash), []byte(password)); err != nil {
+		return "", s.recordFailure(ctx, cred.UserID, now)
+	}
+
+	if cred.FailedAttempts > 0 || cred.LockedUntil != nil {
+		if err := s.repo.ResetLoginFailures(ctx, cred.UserID); err != nil {
+			return "", err
+		}
+	}
+
+	return cred.UserID, nil
+}
+
+func (s *Service) recordFailure(ctx context.Context, userID string, now time.Time) error {
+	attempts, err := s.repo.RecordLoginFailure(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if attempts < s.cfg.MaxAttempts {
+		return ErrInvalidCredentials
+	}
+
+	if err := s.repo.LockCredential(ctx, userID, now.Add(s.cfg.Lockout).UTC().Truncate(time.Second)); err != nil {
+		return err
+	}
+	return &LockedError{RetryAfter: s.cfg.Lockout}
+}
+
+// ChangePassword replaces the password of a logged in user after checking
+// the current one.
+func (s *Service) ChangePassword(ctx context.Context, userID, current, password string) error {
+	cred, err := s.repo.GetCredentialByUserID(ctx, userID)
+	if errors.Is(err, repository.ErrCredentialNotFound) {
+		return ErrInvalidCredentials
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(cred.PasswordHash), []byte(current)); err != nil {
+		return ErrInvalidCredentials
+	}
+
+	hash, err := s.hash(password)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdatePassword(ctx, userID, hash, s.timestamp())
+}
+
+// RequestReset sends a one-time reset token to the owner of email. Unknown
+// emails are ignored so callers cannot probe for registered users.
+func (s *Service) RequestReset(ctx context.Context, email string) error {
+	cred, err := s.repo.GetCredentialByEmail(ctx, email)
+	if errors.Is(err, repository.ErrCredentialNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.InsertPasswordReset(ctx, model.PasswordReset{
+		TokenHash: hashToken(token),
+		UserID:    cred.UserID,
+		ExpiresAt: s.now().Add(s.cfg.ResetTTL).UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.PasswordReset(ctx, cred.Email, token)
+}
+
+// ResetPassword sets a new password with a token from RequestReset. This
+// also lifts any lockout. The token is used up only if the password was
+// changed.
+func (s *Service) ResetPassword(ctx context.Context, token, password string) error {
+	hash, err := s.hash(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.repo.ResetPassword(ctx, hashToken(token), hash, s.timestamp())
+	return err
+}
+
+func (s *Service) hash(password string) (string, error) {
+	if err := Validate(password); err != nil {
+		return "", err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.cfg.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (s *Service) timestamp() time.Time {
+	return s.now().UTC().Truncate(time.Second)
+}
+
+func generateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
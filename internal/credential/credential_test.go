@@ -0,0 +1,126 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/internal/clocktest"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/repository/memory"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeNotifier struct {
+	email, token string
+}
+
+func (n *fakeNotifier) PasswordReset(ctx context.Context, email, token string) error {
+	n.email, n.token = email, token
+	return nil
+}
+
+func newTestService(t *testing.T) (*Service, *fakeNotifier, *clocktest.Clock) {
+	t.Helper()
+
+	clock := clocktest.New()
+	notifier := &fakeNotifier{}
+	svc, err := NewService(memory.NewCredentialRepository(), notifier, Config{
+		MaxAttempts: 3,
+		Lockout:     15 * time.Minute,
+		ResetTTL:    time.Hour,
+		BcryptCost:  bcrypt.MinCost,
+	}, WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return svc, notifier, clock
+}
+
+func TestLogin(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	assert.NoError(t, svc.SetPassword(ctx, "budi", "budi@example.com", "s3cret-pass"))
+	assert.ErrorIs(t, svc.SetPassword(ctx, "sari", "sari@example.com", "short"), ErrWeakPassword)
+
+	userID, err := svc.Login(ctx, "budi@example.com", "s3cret-pass")
+	assert.NoError(t, err)
+	assert.Equal(t, "budi", userID)
+
+	_, err = svc.Login(ctx, "budi@example.com", "wrong-pass")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.Login(ctx, "nobody@example.com", "s3cret-pass")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestLogin_Lockout(t *testing.T) {
+	svc, _, clock := newTestService(t)
+	ctx := context.Background()
+	assert.NoError(t, svc.SetPassword(ctx, "budi", "budi@example.com", "s3cret-pass"))
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.Login(ctx, "budi@example.com", "wrong-pass")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	var locked *LockedError
+	_, err := svc.Login(ctx, "budi@example.com", "wrong-pass")
+	if assert.True(t, errors.As(err, &locked)) {
+		assert.Equal(t, 15*time.Minute, locked.RetryAfter)
+	}
+
+	clock.Advance(5 * time.Minute)
+	_, err = svc.Login(ctx, "budi@example.com", "s3cret-pass")
+	if assert.True(t, errors.As(err, &locked), "the right password is refused while locked") {
+		assert.Equal(t, 10*time.Minute, locked.RetryAfter)
+	}
+
+	clock.Advance(10 * time.Minute)
+	userID, err := svc.Login(ctx, "budi@example.com", "s3cret-pass")
+	assert.NoError(t, err)
+	assert.Equal(t, "budi", userID)
+}
+
+func TestChangePassword(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	ctx := context.Background()
+	assert.NoError(t, svc.SetPassword(ctx, "budi", "budi@example.com", "s3cret-pass"))
+
+	assert.ErrorIs(t, svc.ChangePassword(ctx, "budi", "wrong-pass", "n3w-password"), ErrInvalidCredentials)
+	assert.ErrorIs(t, svc.ChangePassword(ctx, "budi", "s3cret-pass", "short"), ErrWeakPassword)
+	assert.ErrorIs(t, svc.ChangePassword(ctx, "sari", "s3cret-pass", "n3w-password"), ErrInvalidCredentials)
+	assert.NoError(t, svc.ChangePassword(ctx, "budi", "s3cret-pass", "n3w-password"))
+
+	_, err := svc.Login(ctx, "budi@example.com", "s3cret-pass")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = svc.Login(ctx, "budi@example.com", "n3w-password")
+	assert.NoError(t, err)
+}
+
+func TestResetPassword(t *testing.T) {
+	svc, notifier, clock := newTestService(t)
+	ctx := context.Background()
+	assert.NoError(t, svc.SetPassword(ctx, "budi", "budi@example.com", "s3cret-pass"))
+
+	assert.NoError(t, svc.RequestReset(ctx, "nobody@example.com"))
+	assert.Empty(t, notifier.token, "unknown emails get no token")
+
+	assert.NoError(t, svc.RequestReset(ctx, "budi@example.com"))
+	assert.Equal(t, "budi@example.com", notifier.email)
+	assert.NotEmpty(t, notifier.token)
+
+	assert.ErrorIs(t, svc.ResetPassword(ctx, notifier.token, "short"), ErrWeakPassword)
+	assert.NoError(t, svc.ResetPassword(ctx, notifier.token, "n3w-password"))
+	assert.ErrorIs(t, svc.ResetPassword(ctx, notifier.token, "n3w-password"), repository.ErrResetTokenInvalid)
+
+	_, err := svc.Login(ctx, "budi@example.com", "n3w-password")
+	assert.NoError(t, err)
+
+	assert.NoError(t, svc.RequestReset(ctx, "budi@example.com"))
+	clock.Advance(2 * time.Hour)
+	assert.ErrorIs(t, svc.ResetPassword(ctx, notifier.token, "an0ther-password"), repository.ErrResetTokenInvalid)
+}
@@ -0,0 +1,207 @@
+//go:build integration
+
+// Package dbtest starts throwaway MySQL and Mongo servers for the
+// integration test suite. Run it with
+//
+//	go test -tags integration ./...
+//
+// The harness launches mysqld and mongod found on PATH (or MYSQLD_BIN and
+// MONGOD_BIN) with temporary data directories. Set TEST_MYSQL_DSN and
+// TEST_MONGODB_URI to use already running servers instead; a uniquely named
+// database is created on them and dropped afterwards.
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vier21/tefa-ch3/db"
+	"github.com/vier21/tefa-ch3/db/migration"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const startTimeout = 60 * time.Second
+
+type Env struct {
+	MySQL *sqlx.DB
+	Mongo *mongo.Database
+
+	closers []func()
+}
+
+// Fixtures are rows inserted as-is, keeping their IDs, into both stores.
+type Fixtures struct {
+	Users    []model.User
+	Accounts []model.Account
+}
+
+// Start launches both servers, applies every migration and points the db
+// package globals at them so repository constructors pick them up.
+func Start() (*Env, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+	defer cancel()
+
+	env := &Env{}
+	name := fmt.Sprintf("tefa_it_%d", time.Now().UnixNano())
+
+	mysqlDB, closeMysql, err := startMySQL(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("mysql: %w", err)
+	}
+	env.MySQL = mysqlDB
+	env.closers = append(env.closers, closeMysql)
+
+	client, closeMongo, err := startMongo(ctx)
+	if err != nil {
+		env.Close()
+		return nil, fmt.Errorf("mongo: %w", err)
+	}
+	env.Mongo = client.Database(name)
+	env.closers = append(env.closers, func() {
+		env.Mongo.Drop(context.Background())
+		closeMongo()
+	})
+
+	if err := env.migrate(ctx); err != nil {
+		env.Close()
+		return nil, err
+	}
+
+	db.DB = env.MySQL
+	db.MongoCLI = client
+	db.MongoDBName = name
+
+	return env, nil
+}
+
+// Main is a TestMain helper: it starts the servers, runs the tests and tears
+// everything down.
+func Main(m *testing.M, env **Env) {
+	e, err := Start()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dbtest:", err)
+		os.Exit(1)
+	}
+	*env = e
+
+	code := m.Run()
+	e.Close()
+	os.Exit(code)
+}
+
+func (e *Env) Close() {
+	for i := len(e.closers) - 1; i >= 0; i-- {
+		e.closers[i]()
+	}
+	e.closers = nil
+}
+
+func (e *Env) migrate(ctx context.Context) error {
+	mysqlRunner, err := migration.NewMysql(ctx, e.MySQL)
+	if err != nil {
+		return err
+	}
+	if _, err := mysqlRunner.Up(ctx, 0); err != nil {
+		return fmt.Errorf("mysql migrations: %w", err)
+	}
+
+	mongoRunner, err := migration.NewMongo(e.Mongo)
+	if err != nil {
+		return err
+	}
+	if _, err := mongoRunner.Up(ctx, 0); err != nil {
+		return fmt.Errorf("mongo migrations: %w", err)
+	}
+
+	return migration.EnsureMongoSchema(ctx, e.Mongo)
+}
+
+// Reset empties every table and collection except the migration bookkeeping
+// once the test finishes, and loads fixtures for it.
+func (e *Env) Reset(t *testing.T, fixtures Fixtures) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		if err := e.truncate(context.Background()); err != nil {
+			t.Errorf("dbtest: cleanup: %s", err)
+		}
+	})
+
+	if err := e.load(context.Background(), fixtures); err != nil {
+		t.Fatalf("dbtest: fixtures: %s", err)
+	}
+}
+
+func (e *Env) truncate(ctx context.Context) error {
+	var tables []string
+	if err := e.MySQL.SelectContext(ctx, &tables, "SHOW TABLES"); err != nil {
+		return err
+	}
+
+	conn, err := e.MySQL.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if table == "schema_migrations" {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, "TRUNCATE TABLE `"+table+"`"); err != nil {
+			return err
+		}
+	}
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1"); err != nil {
+		return err
+	}
+
+	names, err := e.Mongo.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name == "schema_migrations" {
+			continue
+		}
+		if _, err := e.Mongo.Collection(name).DeleteMany(ctx, bson.M{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Env) load(ctx context.Context, fixtures Fixtures) error {
+	for _, u := range fixtures.Users {
+		sqlstr := "INSERT INTO user (id, name, address, email, verified) VALUES (?, ?, ?, ?, ?)"
+		if _, err := e.MySQL.ExecContext(ctx, sqlstr, u.UserID, u.Name, u.Address, u.Email, u.Verified); err != nil {
+			return err
+		}
+		if _, err := e.Mongo.Collection(migration.UserCollection).InsertOne(ctx, u); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range fixtures.Accounts {
+		status := a.Status
+		if status == "" {
+			status = model.AccountActive
+		}
+		sqlstr := "INSERT INTO account (id, msisdn_customer, user_id, status) VALUES (?, ?, ?, ?)"
+		if _, err := e.MySQL.ExecContext(ctx, sqlstr, a.AccountID, a.MsisdnCustomer, a.UserID, status); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+//go:build integration
+
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// startMongo connects to TEST_MONGODB_URI or to a throwaway mongod.
+func startMongo(ctx context.Context) (*mongo.Client, func(), error) {
+	uri := os.Getenv("TEST_MONGODB_URI")
+	stop := func() {}
+
+	if uri == "" {
+		bin, err := lookBin("MONGOD_BIN", "mongod")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		dir, err := os.MkdirTemp("", "tefa-mongo-")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		port, err := freePort()
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, nil, err
+		}
+
+		cmd := exec.Command(bin,
+			"--dbpath", dir,
+			"--bind_ip", "127.0.0.1",
+			"--port", fmt.Sprint(port),
+			"--nounixsocket",
+			"--quiet",
+		)
+
+		if stop, err = startProcess(cmd, dir); err != nil {
+			return nil, nil, err
+		}
+		uri = fmt.Sprintf("mongodb://127.0.0.1:%d", port)
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	ping := func(ctx context.Context) error { return client.Ping(ctx, readpref.Primary()) }
+	if err := waitReady(ctx, ping); err != nil {
+		client.Disconnect(context.Background())
+		stop()
+		return nil, nil, err
+	}
+
+	return client, func() {
+		client.Disconnect(context.Background())
+		stop()
+	}, nil
+}
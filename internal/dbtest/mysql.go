@@ -0,0 +1,119 @@
+//go:build integration
+
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// startMySQL returns a connection to a fresh database called name, either on
+// the server from TEST_MYSQL_DSN or on a throwaway mysqld.
+func startMySQL(ctx context.Context, name string) (*sqlx.DB, func(), error) {
+	if dsn := os.Getenv("TEST_MYSQL_DSN"); dsn != "" {
+		return createMySQLDatabase(ctx, dsn, name, func() {})
+	}
+
+	bin, err := lookBin("MYSQLD_BIN", "mysqld")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "tefa-mysql-")
+	if err != nil {
+		return nil, nil, err
+	}
+	datadir := filepath.Join(dir, "data")
+
+	args := []string{"--no-defaults", "--datadir=" + datadir}
+	if os.Geteuid() == 0 {
+		args = append(args, "--user=root")
+	}
+
+	init := exec.CommandContext(ctx, bin, append(args, "--initialize-insecure")...)
+	if out, err := init.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, fmt.Errorf("mysqld --initialize-insecure: %w: %s", err, out)
+	}
+
+	port, err := freePort()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+
+	cmd := exec.Command(bin, append(args,
+		"--bind-address=127.0.0.1",
+		fmt.Sprintf("--port=%d", port),
+		"--socket="+filepath.Join(dir, "mysqld.sock"),
+		"--pid-file="+filepath.Join(dir, "mysqld.pid"),
+		"--log-error="+filepath.Join(dir, "error.log"),
+		"--mysqlx=OFF",
+	)...)
+
+	stop, err := startProcess(cmd, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return createMySQLDatabase(ctx, fmt.Sprintf("root@tcp(127.0.0.1:%d)/", port), name, stop)
+}
+
+func createMySQLDatabase(ctx context.Context, dsn, name string, stop func()) (*sqlx.DB, func(), error) {
+	admin, err := sqlx.Open("mysql", dsn)
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+
+	if err := waitReady(ctx, admin.PingContext); err != nil {
+		admin.Close()
+		stop()
+		return nil, nil, err
+	}
+
+	if _, err := admin.ExecContext(ctx, "CREATE DATABASE `"+name+"`"); err != nil {
+		admin.Close()
+		stop()
+		return nil, nil, err
+	}
+
+	dbDSN, err := withDatabase(dsn, name)
+	if err != nil {
+		admin.Close()
+		stop()
+		return nil, nil, err
+	}
+
+	conn, err := sqlx.Connect("mysql", dbDSN)
+	if err != nil {
+		admin.Close()
+		stop()
+		return nil, nil, err
+	}
+
+	return conn, func() {
+		conn.Close()
+		admin.ExecContext(context.Background(), "DROP DATABASE IF EXISTS `"+name+"`")
+		admin.Close()
+		stop()
+	}, nil
+}
+
+// withDatabase replaces the database part of dsn.
+func withDatabase(dsn, name string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+
+	cfg.DBName = name
+	cfg.ParseTime = true
+	return cfg.FormatDSN(), nil
+}
@@ -0,0 +1,69 @@
+//go:build integration
+
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// lookBin resolves a server binary from env or from the first name found on
+// PATH.
+func lookBin(env string, names ...string) (string, error) {
+	if bin := os.Getenv(env); bin != "" {
+		return bin, nil
+	}
+
+	for _, name := range names {
+		if bin, err := exec.LookPath(name); err == nil {
+			return bin, nil
+		}
+	}
+
+	return "", fmt.Errorf("none of %v found on PATH; install it, set %s or point the tests at a running server", names, env)
+}
+
+func freePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+// startProcess runs cmd in the background and returns a function that kills
+// it and removes dir.
+func startProcess(cmd *exec.Cmd, dir string) (func(), error) {
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+		os.RemoveAll(dir)
+	}, nil
+}
+
+// waitReady polls ping until it succeeds or ctx expires.
+func waitReady(ctx context.Context, ping func(ctx context.Context) error) error {
+	for {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("server not ready: %w", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
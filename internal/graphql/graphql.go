@@ -0,0 +1,86 @@
+// Package graphql serves users and their accounts as a GraphQL API resolved
+// through usecase.UserInterface, so the same authorization applies as on the
+// REST routes.
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"runtime/debug"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/vier21/tefa-ch3/config"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/usecase"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+type Config struct {
+	// MaxDepth bounds how deeply fields may be nested, 0 disables the check.
+	// Introspection fields count as well.
+	MaxDepth int
+	// MaxComplexity bounds how many fields of an operation may load data,
+	// 0 disables the check. See budget.
+	MaxComplexity int
+}
+
+func FromConfig(cfg *config.Config) Config {
+	return Config{
+		MaxDepth:      cfg.GraphQLMaxDepth,
+		MaxComplexity: cfg.GraphQLMaxComplexity,
+	}
+}
+
+// Request is the body of a GraphQL request over HTTP.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	// Extensions is accepted for clients that always send it and ignored.
+	Extensions map[string]interface{} `json:"extensions"`
+}
+
+type Schema struct {
+	cfg      Config
+	services usecase.UserInterface
+
+	exec *graphqlgo.Schema
+}
+
+func NewSchema(services usecase.UserInterface, cfg Config) (*Schema, error) {
+	exec, err := graphqlgo.ParseSchema(schemaSDL, &resolver{services: services},
+		graphqlgo.UseStringDescriptions(),
+		graphqlgo.Logger(panicLogger{}),
+		graphqlgo.MaxDepth(cfg.MaxDepth),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("graphql: %w", err)
+	}
+
+	return &Schema{
+		cfg:      cfg,
+		services: services,
+		exec:     exec,
+	}, nil
+}
+
+// Exec runs the request as the caller identified by ctx. Queries nested
+// deeper than MaxDepth are rejected before anything is resolved; fields
+// loading data past MaxComplexity fail as they are reached.
+func (s *Schema) Exec(ctx context.Context, req Request) *graphqlgo.Response {
+	ctx = withBudget(withLoaders(ctx, s.services), s.cfg.MaxComplexity)
+
+	res := s.exec.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	tagDepthErrors(res)
+	return res
+}
+
+// panicLogger logs panics of resolvers, which are answered with an error.
+type panicLogger struct{}
+
+func (panicLogger) LogPanic(ctx context.Context, value interface{}) {
+	logging.FromContext(ctx).Error("panic resolving graphql field", "panic", value, "stack", string(debug.Stack()))
+}
@@ -0,0 +1,286 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository/memory"
+	"github.com/vier21/tefa-ch3/internal/usecase"
+)
+
+// batchRecorder records the user IDs of every ListAccountsByUserIDs and
+// GetUsersByIDs call.
+type batchRecorder struct {
+	usecase.UserInterface
+
+	mu          sync.Mutex
+	batches     [][]string
+	userBatches [][]string
+}
+
+func (b *batchRecorder) ListAccountsByUserIDs(ctx context.Context, userIDs []string) (map[string][]model.Account, error) {
+	b.mu.Lock()
+	b.batches = append(b.batches, userIDs)
+	b.mu.Unlock()
+
+	return b.UserInterface.ListAccountsByUserIDs(ctx, userIDs)
+}
+
+func (b *batchRecorder) GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]model.User, error) {
+	b.mu.Lock()
+	b.userBatches = append(b.userBatches, userIDs)
+	b.mu.Unlock()
+
+	return b.UserInterface.GetUsersByIDs(ctx, userIDs)
+}
+
+type testEnv struct {
+	schema   *Schema
+	mysql    *memory.MysqlRepository
+	recorder *batchRecorder
+}
+
+func newTestEnv(t *testing.T, cfg Config) *testEnv {
+	t.Helper()
+
+	mysqlRepo := memory.NewMysqlRepository()
+	recorder := &batchRecorder{UserInterface: usecase.NewUserUsecase(mysqlRepo, memory.NewMongoRepository())}
+
+	schema, err := NewSchema(usecase.NewAuthorizedUsecase(recorder, authz.DefaultPolicy), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testEnv{schema: schema, mysql: mysqlRepo, recorder: recorder}
+}
+
+func (e *testEnv) insertUser(t *testing.T, name string, msisdns ...string) model.User {
+	t.Helper()
+
+	user, err := e.mysql.InsertUser(context.Background(), model.User{Name: name, Email: strings.ToLower(name) + "@example.com"})
+	require.NoError(t, err)
+	for _, msisdn := range msisdns {
+		_, err := e.mysql.InsertAccount(context.Background(), model.Account{MsisdnCustomer: msisdn, UserID: user.UserID, Status: model.AccountActive})
+		require.NoError(t, err)
+	}
+	return user
+}
+
+type response struct {
+	Data   map[string]json.RawMessage
+	Errors []struct {
+		Message    string
+		Path       []interface{}
+		Extensions struct{ Code string }
+	}
+}
+
+func as(subject string, roles ...string) context.Context {
+	return authz.WithPrincipal(context.Background(), authz.Principal{Subject: subject, Roles: roles})
+}
+
+func (e *testEnv) exec(t *testing.T, ctx context.Context, query string, variables map[string]interface{}) response {
+	t.Helper()
+
+	raw, err := json.Marshal(e.schema.Exec(ctx, Request{Query: query, Variables: variables}))
+	require.NoError(t, err)
+
+	var res response
+	require.NoError(t, json.Unmarshal(raw, &res))
+	return res
+}
+
+func TestQuery(t *testing.T) {
+	e := newTestEnv(t, Config{})
+	budi := e.insertUser(t, "Budi", "628111")
+
+	res := e.exec(t, as(budi.UserID, authz.RoleCustomer), `query ($id: ID!) {
+		user(id: $id) { id name email verified accounts { msisdnCustomer status user { name } } }
+	}`, map[string]interface{}{"id": budi.UserID})
+	assert.Empty(t, res.Errors)
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"id": %q, "name": "Budi", "email": "budi@example.com", "verified": false,
+		"accounts": [
+			{"msisdnCustomer": "628111", "status": "active", "user": {"name": "Budi"}}
+		]
+	}`, budi.UserID), string(res.Data["user"]))
+
+	accounts, err := e.mysql.ListAccountsByUserID(context.Background(), budi.UserID)
+	require.NoError(t, err)
+	res = e.exec(t, as(budi.UserID, authz.RoleCustomer), `query ($id: ID!) { account(id: $id) { id user { id } } }`,
+		map[string]interface{}{"id": accounts[0].AccountID})
+	assert.Empty(t, res.Errors)
+	assert.JSONEq(t, fmt.Sprintf(`{"id": %q, "user": {"id": %q}}`, accounts[0].AccountID, budi.UserID), string(res.Data["account"]))
+
+	res = e.exec(t, as("admin", authz.RoleAdmin), `{ user(id: "unknown") { id } account(id: "unknown") { id } }`, nil)
+	assert.Empty(t, res.Errors)
+	assert.JSONEq(t, `{"user": null, "account": null}`, mustJSON(t, res.Data))
+}
+
+func TestQuery_Errors(t *testing.T) {
+	e := newTestEnv(t, Config{})
+	budi := e.insertUser(t, "Budi")
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code string
+	}{
+		{"anonymous", context.Background(), "UNAUTHENTICATED"},
+		{"other user", as("someone-else", authz.RoleCustomer), "FORBIDDEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := e.exec(t, tt.ctx, `query ($id: ID!) { user(id: $id) { name } }`, map[string]interface{}{"id": budi.UserID})
+			require.Len(t, res.Errors, 1)
+			assert.Equal(t, tt.code, res.Errors[0].Extensions.Code)
+			assert.Equal(t, []interface{}{"user"}, res.Errors[0].Path)
+			assert.JSONEq(t, `null`, string(res.Data["user"]))
+		})
+	}
+}
+
+func TestMutation(t *testing.T) {
+	e := newTestEnv(t, Config{})
+
+	res := e.exec(t, context.Background(), `mutation ($input: RegisterUserInput!) { registerUser(input: $input) { id name } }`,
+		map[string]interface{}{"input": map[string]interface{}{"name": "Ani", "address": "Bandung", "email": "ani@example.com"}})
+	require.Empty(t, res.Errors)
+
+	var user struct{ ID, Name string }
+	require.NoError(t, json.Unmarshal(res.Data["registerUser"], &user))
+	assert.NotEmpty(t, user.ID)
+	assert.Equal(t, "Ani", user.Name)
+
+	register := `mutation ($input: RegisterAccountInput!) { registerAccount(input: $input) { msisdnCustomer user { id } } }`
+	input := map[string]interface{}{"input": map[string]interface{}{"userId": user.ID, "msisdnCustomer": "628999"}}
+
+	res = e.exec(t, context.Background(), register, input)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, "UNAUTHENTICATED", res.Errors[0].Extensions.Code)
+
+	res = e.exec(t, as(user.ID, authz.RoleCustomer), register, input)
+	require.Empty(t, res.Errors)
+	assert.JSONEq(t, fmt.Sprintf(`{"msisdnCustomer": "628999", "user": {"id": %q}}`, user.ID), string(res.Data["registerAccount"]))
+
+	res = e.exec(t, as(user.ID, authz.RoleCustomer), register, input)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, "CONFLICT", res.Errors[0].Extensions.Code)
+}
+
+func TestMutationLimit(t *testing.T) {
+	e := newTestEnv(t, Config{})
+
+	left := 1
+	ctx := WithMutationLimit(context.Background(), func(ctx context.Context) (time.Duration, error) {
+		if left == 0 {
+			return 30 * time.Second, nil
+		}
+		left--
+		return 0, nil
+	})
+
+	res := e.exec(t, ctx, `mutation {
+		a: registerUser(input: {name: "Ani", address: "Bandung", email: "ani@example.com"}) { id }
+		b: registerUser(input: {name: "Dewi", address: "Bandung", email: "dewi@example.com"}) { id }
+	}`, nil)
+	require.Len(t, res.Errors, 1, "every mutation field is charged")
+	assert.Equal(t, "RATE_LIMITED", res.Errors[0].Extensions.Code)
+	assert.Equal(t, []interface{}{"b"}, res.Errors[0].Path)
+	assert.Equal(t, 1, e.mysql.UserCount())
+}
+
+func TestAccountsAreBatched(t *testing.T) {
+	e := newTestEnv(t, Config{})
+	budi := e.insertUser(t, "Budi", "628111")
+	ani := e.insertUser(t, "Ani", "628222")
+
+	res := e.exec(t, as("admin", authz.RoleAdmin), `query ($budi: ID!, $ani: ID!) {
+		budi: user(id: $budi) { accounts { msisdnCustomer } }
+		ani: user(id: $ani) { accounts { msisdnCustomer } }
+		again: user(id: $budi) { accounts { msisdnCustomer } }
+	}`, map[string]interface{}{"budi": budi.UserID, "ani": ani.UserID})
+	require.Empty(t, res.Errors)
+	assert.JSONEq(t, `{"accounts": [{"msisdnCustomer": "628111"}]}`, string(res.Data["budi"]))
+	assert.JSONEq(t, `{"accounts": [{"msisdnCustomer": "628222"}]}`, string(res.Data["ani"]))
+	assert.JSONEq(t, string(res.Data["budi"]), string(res.Data["again"]))
+
+	require.Len(t, e.recorder.batches, 1, "accounts of all users are loaded at once")
+	assert.ElementsMatch(t, []string{budi.UserID, ani.UserID}, e.recorder.batches[0])
+}
+
+func TestAccountOwnersAreBatched(t *testing.T) {
+	e := newTestEnv(t, Config{})
+	budi := e.insertUser(t, "Budi", "628111")
+	ani := e.insertUser(t, "Ani", "628222")
+
+	res := e.exec(t, as("admin", authz.RoleAdmin), `query ($budi: ID!, $ani: ID!) {
+		budi: user(id: $budi) { accounts { user { name } } }
+		ani: user(id: $ani) { accounts { user { name } } }
+	}`, map[string]interface{}{"budi": budi.UserID, "ani": ani.UserID})
+	require.Empty(t, res.Errors)
+	assert.JSONEq(t, `{"accounts": [{"user": {"name": "Budi"}}]}`, string(res.Data["budi"]))
+	assert.JSONEq(t, `{"accounts": [{"user": {"name": "Ani"}}]}`, string(res.Data["ani"]))
+
+	require.Len(t, e.recorder.userBatches, 1, "owners of all accounts are loaded at once")
+	assert.ElementsMatch(t, []string{budi.UserID, ani.UserID}, e.recorder.userBatches[0])
+}
+
+func TestLimits(t *testing.T) {
+	e := newTestEnv(t, Config{MaxDepth: 4, MaxComplexity: 5})
+	budi := e.insertUser(t, "Budi", "628111")
+	ctx := as("admin", authz.RoleAdmin)
+	vars := map[string]interface{}{"id": budi.UserID}
+
+	// depth 4, loads user, accounts and the user of the account
+	res := e.exec(t, ctx, `query ($id: ID!) { user(id: $id) { name accounts { user { name } } } }`, vars)
+	assert.Empty(t, res.Errors)
+
+	batches := len(e.recorder.batches)
+
+	res = e.exec(t, ctx, `query ($id: ID!) { user(id: $id) { accounts { user { accounts { id } } } } }`, vars)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, "QUERY_TOO_DEEP", res.Errors[0].Extensions.Code)
+	assert.Nil(t, res.Data, "nothing is resolved")
+	assert.Len(t, e.recorder.batches, batches, "nothing is resolved")
+
+	res = e.exec(t, ctx, `{ __schema { types { fields { type { name } } } } }`, nil)
+	require.Len(t, res.Errors, 1, "introspection counts towards the depth")
+	assert.Equal(t, "QUERY_TOO_DEEP", res.Errors[0].Extensions.Code)
+
+	// fragments count wherever they are spread: 3 users and their accounts
+	// are 6 loads, one more than allowed
+	res = e.exec(t, ctx, `
+		query ($id: ID!) { a: user(id: $id) { ...accounts } b: user(id: $id) { ...accounts } c: user(id: $id) { ...accounts } }
+		fragment accounts on User { accounts { id } }
+	`, vars)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, "QUERY_TOO_COMPLEX", res.Errors[0].Extensions.Code)
+
+	// introspection loads nothing
+	res = e.exec(t, ctx, `{ __schema { types { name } } }`, nil)
+	assert.Empty(t, res.Errors)
+
+	res = e.exec(t, ctx, `{ user(id: `, nil)
+	assert.NotEmpty(t, res.Errors, "unparsable queries are rejected")
+	res = e.exec(t, ctx, `query a { __typename } query b { __typename }`, nil)
+	assert.NotEmpty(t, res.Errors, "the operation must be named")
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(raw)
+}
@@ -0,0 +1,87 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/vier21/tefa-ch3/internal/logging"
+)
+
+// maxDepthRule is the rule graphql-go reports fields nested deeper than
+// MaxDepth under.
+const maxDepthRule = "MaxDepthExceeded"
+
+type budgetKey struct{}
+
+// budget is the complexity allowance of one request. Every field that loads
+// data costs one and is charged before it loads; once the allowance is used
+// up such fields fail with QUERY_TOO_COMPLEX. graphql-go does not expose the
+// parsed query, so the cost is counted as the query runs instead of being
+// estimated up front.
+type budget struct {
+	max  int64
+	used atomic.Int64
+}
+
+func withBudget(ctx context.Context, max int) context.Context {
+	return context.WithValue(ctx, budgetKey{}, &budget{max: int64(max)})
+}
+
+// charge takes one from the budget of the request, if it has one.
+func charge(ctx context.Context) error {
+	b, _ := ctx.Value(budgetKey{}).(*budget)
+	if b == nil || b.max <= 0 {
+		return nil
+	}
+
+	if b.used.Add(1) > b.max {
+		return &queryError{code: "QUERY_TOO_COMPLEX", msg: fmt.Sprintf("query complexity exceeds the maximum of %d", b.max)}
+	}
+	return nil
+}
+
+// tagDepthErrors gives the depth errors of graphql-go the QUERY_TOO_DEEP
+// code, like the errors of resolvers carry one.
+func tagDepthErrors(res *graphqlgo.Response) {
+	for _, err := range res.Errors {
+		if err.Rule == maxDepthRule {
+			err.Extensions = map[string]interface{}{"code": "QUERY_TOO_DEEP"}
+		}
+	}
+}
+
+type mutationLimitKey struct{}
+
+// MutationLimit takes from the write budget of the caller and returns how
+// long to wait when it is spent, like ratelimit.Limiter.Take.
+type MutationLimit func(ctx context.Context) (time.Duration, error)
+
+// WithMutationLimit charges every mutation field of the request to take, so
+// that mutations batched into one operation cost as much as separate
+// requests.
+func WithMutationLimit(ctx context.Context, take MutationLimit) context.Context {
+	return context.WithValue(ctx, mutationLimitKey{}, take)
+}
+
+// chargeMutation fails with RATE_LIMITED once the write budget is spent.
+// Errors of the budget store let the mutation through.
+func chargeMutation(ctx context.Context) error {
+	take, _ := ctx.Value(mutationLimitKey{}).(MutationLimit)
+	if take == nil {
+		return nil
+	}
+
+	wait, err := take(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("rate limit store error", "error", err)
+		return nil
+	}
+	if wait > 0 {
+		return &queryError{code: "RATE_LIMITED", msg: fmt.Sprintf("rate limit exceeded, retry after %ds", int(math.Ceil(wait.Seconds())))}
+	}
+	return nil
+}
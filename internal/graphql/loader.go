@@ -0,0 +1,129 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/usecase"
+)
+
+const (
+	// batchWait is how long a loader collects keys before fetching them.
+	// Sibling fields are resolved concurrently, so they meet in one batch.
+	batchWait = 2 * time.Millisecond
+	// maxBatch fetches a batch right away once it holds that many keys.
+	maxBatch = 100
+)
+
+// loader batches the keys loaded by concurrent resolvers into one fetch and
+// remembers the results for the rest of the request. If a fetch fails, every
+// key of the batch fails with it.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	results map[K]*result[V]
+	pending *batch[K, V]
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type batch[K comparable, V any] struct {
+	keys    []K
+	results []*result[V]
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		results: map[K]*result[V]{},
+	}
+}
+
+// Load returns the value of key, fetched together with the keys loaded
+// around the same time.
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	res, ok := l.results[key]
+	if !ok {
+		res = &result[V]{done: make(chan struct{})}
+		l.results[key] = res
+		l.enqueue(ctx, key, res)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// enqueue adds key to the pending batch, starting a new one if there is
+// none. It must be called with l.mu held.
+func (l *loader[K, V]) enqueue(ctx context.Context, key K, res *result[V]) {
+	if l.pending == nil {
+		b := &batch[K, V]{}
+		l.pending = b
+		time.AfterFunc(batchWait, func() { l.dispatch(ctx, b) })
+	}
+
+	l.pending.keys = append(l.pending.keys, key)
+	l.pending.results = append(l.pending.results, res)
+
+	if len(l.pending.keys) >= maxBatch {
+		b := l.pending
+		l.pending = nil
+		go l.run(ctx, b)
+	}
+}
+
+// dispatch fetches b once its wait is over, unless it was full and fetched
+// already.
+func (l *loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	l.mu.Lock()
+	if l.pending != b {
+		l.mu.Unlock()
+		return
+	}
+	l.pending = nil
+	l.mu.Unlock()
+
+	l.run(ctx, b)
+}
+
+func (l *loader[K, V]) run(ctx context.Context, b *batch[K, V]) {
+	values, err := l.fetch(ctx, b.keys)
+	for i, res := range b.results {
+		res.value, res.err = values[b.keys[i]], err
+		close(res.done)
+	}
+}
+
+type loadersKey struct{}
+
+// loaders are created for every request, so nothing is cached across
+// requests or callers.
+type loaders struct {
+	accounts *loader[string, []model.Account]
+	users    *loader[string, model.User]
+}
+
+func withLoaders(ctx context.Context, services usecase.UserInterface) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		accounts: newLoader(services.ListAccountsByUserIDs),
+		users:    newLoader(services.GetUsersByIDs),
+	})
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
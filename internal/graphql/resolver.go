@@ -0,0 +1,201 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/credential"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/usecase"
+)
+
+// resolver is the root of the schema, shared by all requests.
+type resolver struct {
+	services usecase.UserInterface
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID graphqlgo.ID }) (*userResolver, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := r.services.GetUserByID(ctx, string(args.ID))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+
+	return &userResolver{services: r.services, user: user}, nil
+}
+
+func (r *resolver) Account(ctx context.Context, args struct{ ID graphqlgo.ID }) (*accountResolver, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+
+	account, err := r.services.GetAccount(ctx, string(args.ID))
+	if errors.Is(err, repository.ErrAccountNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+
+	return &accountResolver{services: r.services, account: account}, nil
+}
+
+type registerUserInput struct {
+	Name     string
+	Address  string
+	Email    string
+	Password *string
+}
+
+func (r *resolver) RegisterUser(ctx context.Context, args struct{ Input registerUserInput }) (*userResolver, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+	if err := chargeMutation(ctx); err != nil {
+		return nil, err
+	}
+
+	user := model.User{
+		Name:    args.Input.Name,
+		Address: args.Input.Address,
+		Email:   args.Input.Email,
+	}
+	if args.Input.Password != nil {
+		user.Password = *args.Input.Password
+	}
+
+	res, err := r.services.RegisterUser(ctx, user)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+
+	return &userResolver{services: r.services, user: res.UserMysql}, nil
+}
+
+type registerAccountInput struct {
+	UserID         graphqlgo.ID
+	MsisdnCustomer string
+}
+
+func (r *resolver) RegisterAccount(ctx context.Context, args struct{ Input registerAccountInput }) (*accountResolver, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+	if err := chargeMutation(ctx); err != nil {
+		return nil, err
+	}
+
+	account, err := r.services.RegisterAccount(ctx, model.Account{
+		UserID:         string(args.Input.UserID),
+		MsisdnCustomer: args.Input.MsisdnCustomer,
+	})
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+
+	return &accountResolver{services: r.services, account: account}, nil
+}
+
+type userResolver struct {
+	services usecase.UserInterface
+	user     model.User
+}
+
+func (u *userResolver) ID() graphqlgo.ID { return graphqlgo.ID(u.user.UserID) }
+func (u *userResolver) Name() string     { return u.user.Name }
+func (u *userResolver) Address() string  { return u.user.Address }
+func (u *userResolver) Email() string    { return u.user.Email }
+func (u *userResolver) Verified() bool   { return u.user.Verified }
+
+// Accounts are loaded in one batch for all the users of a query.
+func (u *userResolver) Accounts(ctx context.Context) ([]*accountResolver, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+
+	accounts, err := loadersFrom(ctx).accounts.Load(ctx, u.user.UserID)
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+
+	resolvers := make([]*accountResolver, 0, len(accounts))
+	for _, account := range accounts {
+		resolvers = append(resolvers, &accountResolver{services: u.services, account: account})
+	}
+	return resolvers, nil
+}
+
+type accountResolver struct {
+	services usecase.UserInterface
+	account  model.Account
+}
+
+func (a *accountResolver) ID() graphqlgo.ID       { return graphqlgo.ID(a.account.AccountID) }
+func (a *accountResolver) MsisdnCustomer() string { return a.account.MsisdnCustomer }
+func (a *accountResolver) Status() string         { return a.account.Status }
+
+// User is loaded in one batch for all the accounts of a query.
+func (a *accountResolver) User(ctx context.Context) (*userResolver, error) {
+	if err := charge(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := loadersFrom(ctx).users.Load(ctx, a.account.UserID)
+	if err == nil && user.UserID == "" {
+		err = repository.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, resolverError(ctx, err)
+	}
+
+	return &userResolver{services: a.services, user: user}, nil
+}
+
+// queryError carries the code of a failed field in the extensions of the
+// GraphQL error.
+type queryError struct {
+	code string
+	msg  string
+}
+
+func (e *queryError) Error() string { return e.msg }
+
+func (e *queryError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// resolverError maps domain errors to error codes. Unexpected errors are
+// logged and reported with a generic message.
+func resolverError(ctx context.Context, err error) error {
+	code := ""
+	switch {
+	case errors.Is(err, authz.ErrUnauthenticated):
+		code = "UNAUTHENTICATED"
+	case errors.Is(err, authz.ErrForbidden):
+		code = "FORBIDDEN"
+	case errors.Is(err, repository.ErrUserNotFound), errors.Is(err, repository.ErrAccountNotFound):
+		code = "NOT_FOUND"
+	case errors.Is(err, repository.ErrUserExists), errors.Is(err, repository.ErrCredentialExists), errors.Is(err, repository.ErrMsisdnTaken):
+		code = "CONFLICT"
+	case errors.Is(err, repository.ErrMsisdnLimit), errors.Is(err, credential.ErrWeakPassword):
+		code = "BAD_USER_INPUT"
+	case errors.Is(err, context.DeadlineExceeded):
+		code = "TIMEOUT"
+	}
+
+	if code == "" {
+		logging.FromContext(ctx).Error("graphql field failed", "error", err)
+		return &queryError{code: "INTERNAL_SERVER_ERROR", msg: "internal error"}
+	}
+	return &queryError{code: code, msg: err.Error()}
+}
@@ -0,0 +1,381 @@
+// Package grpcserver serves the userpb.UserService gRPC API on top of the same
+// usecase as the HTTP server.
+package grpcserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/vier21/tefa-ch3/internal/apikey"
+	"github.com/vier21/tefa-ch3/internal/auth"
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/credential"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/ratelimit"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/usecase"
+	"github.com/vier21/tefa-ch3/internal/userpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read from incoming calls.
+const (
+	AuthorizationKey = "authorization"
+	APIKeyKey        = "x-api-key"
+	RequestIDKey     = "x-request-id"
+	// RetryAfterKey is sent with ResourceExhausted when the rate limit is
+	// exceeded, in seconds like the HTTP header.
+	RetryAfterKey = "retry-after"
+)
+
+var requestIDRe = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// writeMethods are additionally charged to the write budget, like the HTTP
+// routes creating records.
+var writeMethods = map[string]bool{
+	userpb.UserService_RegisterUser_FullMethodName:    true,
+	userpb.UserService_RegisterAccount_FullMethodName: true,
+}
+
+type Server struct {
+	userpb.UnimplementedUserServiceServer
+
+	services usecase.UserInterface
+	tokens   *auth.TokenService
+	apiKeys  *apikey.Service
+	limiter  *ratelimit.Limiter
+	logger   *slog.Logger
+	tls      *tls.Config
+
+	grpc   *grpc.Server
+	health *health.Server
+}
+
+type Option func(*Server)
+
+// WithAuth accepts JWTs in the authorization metadata.
+func WithAuth(tokens *auth.TokenService) Option {
+	return func(s *Server) {
+		s.tokens = tokens
+	}
+}
+
+// WithAPIKeys accepts API keys in the x-api-key metadata.
+func WithAPIKeys(keys *apikey.Service) Option {
+	return func(s *Server) {
+		s.apiKeys = keys
+	}
+}
+
+// WithRateLimit charges every call to the budgets of its caller, with the
+// same classes as the HTTP routes.
+func WithRateLimit(limiter *ratelimit.Limiter) Option {
+	return func(s *Server) {
+		s.limiter = limiter
+	}
+}
+
+// WithTLS serves the calls over TLS with cfg, e.g. the ServerConfig of the
+// CertReloader of the HTTP server so both share certificates.
+func WithTLS(cfg *tls.Config) Option {
+	return func(s *Server) {
+		s.tls = cfg
+	}
+}
+
+// WithLogger sets the logger handed to every call through the context.
+// slog.Default is used otherwise.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+func NewServer(services usecase.UserInterface, opts ...Option) *Server {
+	s := &Server{
+		services: services,
+		logger:   slog.Default(),
+		health:   health.NewServer(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.withLogger, recoverer, s.authenticate, s.rateLimit),
+	}
+	if s.tls != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(s.tls)))
+	}
+	s.grpc = grpc.NewServer(serverOpts...)
+	userpb.RegisterUserServiceServer(s.grpc, s)
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+
+	return s
+}
+
+// Serve serves on ln until ctx is done and then stops gracefully, letting
+// in-flight calls finish.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info("grpc server started", "addr", ln.Addr().String(), "tls", s.tls != nil)
+		errCh <- s.grpc.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.health.Shutdown()
+	s.grpc.GracefulStop()
+	return <-errCh
+}
+
+// withLogger tags the call context and its logger with the request ID of the
+// caller or a new one.
+func (s *Server) withLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	id := firstMetadata(ctx, RequestIDKey)
+	if !requestIDRe.MatchString(id) {
+		id = uuid.NewString()
+	}
+
+	ctx = logging.WithRequestID(logging.WithLogger(ctx, s.logger.With("grpc_method", info.FullMethod)), id)
+	return handler(ctx, req)
+}
+
+// recoverer turns a panic in a handler into codes.Internal.
+func recoverer(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			logging.FromContext(ctx).Error("panic serving call", "panic", rec, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// authenticate identifies the caller by API key or JWT. Calls without
+// credentials are anonymous; the usecase decides whether they are allowed.
+func (s *Server) authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if key := firstMetadata(ctx, APIKeyKey); key != "" {
+		if s.apiKeys == nil {
+			return nil, status.Error(codes.Unauthenticated, "api keys are not accepted")
+		}
+
+		apiKey, err := s.apiKeys.Authenticate(ctx, key)
+		var limited *apikey.RateLimitError
+		switch {
+		case errors.As(err, &limited):
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		case errors.Is(err, apikey.ErrInvalidKey), errors.Is(err, apikey.ErrRevoked):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case err != nil:
+			return nil, toStatus(ctx, err)
+		}
+
+		return handler(authz.WithPrincipal(ctx, apikey.Principal(apiKey)), req)
+	}
+
+	if header := firstMetadata(ctx, AuthorizationKey); header != "" {
+		if s.tokens == nil {
+			return nil, status.Error(codes.Unauthenticated, "authentication is not configured")
+		}
+
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+		claims, err := s.tokens.Verify(strings.TrimSpace(token))
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return handler(auth.WithClaims(ctx, claims), req)
+	}
+
+	return handler(ctx, req)
+}
+
+// rateLimit charges the call to the default budget of the caller, and to the
+// write budget for methods creating records, and answers ResourceExhausted
+// once one is spent. Errors of the bucket store let the call through.
+func (s *Server) rateLimit(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if s.limiter == nil {
+		return handler(ctx, req)
+	}
+
+	classes := []ratelimit.Class{ratelimit.Default}
+	if writeMethods[info.FullMethod] {
+		classes = append(classes, ratelimit.Write)
+	}
+
+	client := clientKey(ctx)
+	for _, class := range classes {
+		wait, err := s.limiter.Take(ctx, class, client)
+		if err != nil {
+			logging.FromContext(ctx).Error("rate limit store error", "error", err)
+			continue
+		}
+		if wait > 0 {
+			grpc.SetHeader(ctx, metadata.Pairs(RetryAfterKey, strconv.Itoa(int(math.Ceil(wait.Seconds())))))
+			return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded")
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+// clientKey identifies the caller by its authenticated subject, or else by
+// the address it connects from, like the HTTP server does.
+func clientKey(ctx context.Context) string {
+	if p, ok := authz.PrincipalFrom(ctx); ok && p.Subject != "" {
+		return p.Subject
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		return "ip:" + host
+	}
+	return "ip:unknown"
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// toStatus maps domain errors to gRPC status codes. Unexpected errors are
+// logged and answered with a generic message.
+func toStatus(ctx context.Context, err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, authz.ErrUnauthenticated):
+		code = codes.Unauthenticated
+	case errors.Is(err, authz.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, repository.ErrUserNotFound), errors.Is(err, repository.ErrAccountNotFound):
+		code = codes.NotFound
+	case errors.Is(err, repository.ErrUserExists), errors.Is(err, repository.ErrCredentialExists), errors.Is(err, repository.ErrMsisdnTaken):
+		code = codes.AlreadyExists
+	case errors.Is(err, repository.ErrMsisdnLimit):
+		code = codes.FailedPrecondition
+	case errors.Is(err, credential.ErrWeakPassword):
+		code = codes.InvalidArgument
+	case errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	}
+
+	if code == codes.Internal {
+		logging.FromContext(ctx).Error("grpc call failed", "error", err)
+		return status.Error(code, "internal error")
+	}
+	return status.Error(code, err.Error())
+}
+
+func (s *Server) RegisterUser(ctx context.Context, req *userpb.RegisterUserRequest) (*userpb.RegisterUserResponse, error) {
+	res, err := s.services.RegisterUser(ctx, model.User{
+		Name:     req.GetName(),
+		Address:  req.GetAddress(),
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &userpb.RegisterUserResponse{
+		UserMysql: toUser(res.UserMysql),
+		UserMongo: toUser(res.UserMongo),
+	}, nil
+}
+
+func (s *Server) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.GetUserResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	user, err := s.services.GetUserByID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &userpb.GetUserResponse{User: toUser(user)}, nil
+}
+
+func (s *Server) RegisterAccount(ctx context.Context, req *userpb.RegisterAccountRequest) (*userpb.RegisterAccountResponse, error) {
+	if req.GetMsisdnCustomer() == "" || req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "msisdn_customer and user_id are required")
+	}
+
+	account, err := s.services.RegisterAccount(ctx, model.Account{
+		MsisdnCustomer: req.GetMsisdnCustomer(),
+		UserID:         req.GetUserId(),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &userpb.RegisterAccountResponse{Account: toAccount(account)}, nil
+}
+
+func (s *Server) GetUserByAccountID(ctx context.Context, req *userpb.GetUserByAccountIDRequest) (*userpb.GetUserByAccountIDResponse, error) {
+	if req.GetAccountId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "account_id is required")
+	}
+
+	user, err := s.services.GetUserByAccountID(ctx, req.GetAccountId())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &userpb.GetUserByAccountIDResponse{User: toUser(user)}, nil
+}
+
+func toUser(u model.User) *userpb.User {
+	return &userpb.User{
+		Id:       u.UserID,
+		Name:     u.Name,
+		Address:  u.Address,
+		Email:    u.Email,
+		Verified: u.Verified,
+	}
+}
+
+func toAccount(a model.Account) *userpb.Account {
+	return &userpb.Account{
+		Id:             a.AccountID,
+		MsisdnCustomer: a.MsisdnCustomer,
+		UserId:         a.UserID,
+		Status:         a.Status,
+	}
+}
@@ -0,0 +1,293 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/internal/apikey"
+	"github.com/vier21/tefa-ch3/internal/auth"
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/ratelimit"
+	"github.com/vier21/tefa-ch3/internal/repository/memory"
+	"github.com/vier21/tefa-ch3/internal/usecase"
+	"github.com/vier21/tefa-ch3/internal/userpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testEnv struct {
+	client userpb.UserServiceClient
+	health healthpb.HealthClient
+	tokens *auth.TokenService
+	keys   *apikey.Service
+}
+
+func newTestEnv(t *testing.T, opts ...Option) *testEnv {
+	t.Helper()
+
+	tokens, err := auth.NewTokenService(auth.Config{
+		Keys:     []auth.Key{{ID: "test", Secret: []byte("test-secret")}},
+		Issuer:   "tefa-ch3",
+		Audience: "tefa-ch3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := apikey.NewService(memory.NewAPIKeyRepository(), []string{string(authz.UserRead), string(authz.AccountRead)})
+
+	services := usecase.NewAuthorizedUsecase(
+		usecase.NewUserUsecase(memory.NewMysqlRepository(), memory.NewMongoRepository()),
+		authz.DefaultPolicy,
+	)
+	server := NewServer(services, append([]Option{WithAuth(tokens), WithAPIKeys(keys)}, opts...)...)
+
+	ln := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, ln) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testEnv{
+		client: userpb.NewUserServiceClient(conn),
+		health: healthpb.NewHealthClient(conn),
+		tokens: tokens,
+		keys:   keys,
+	}
+}
+
+// as returns a context calling as subject with roles.
+func (e *testEnv) as(t *testing.T, subject string, roles ...string) context.Context {
+	t.Helper()
+
+	token, _, err := e.tokens.Issue(subject, roles...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), AuthorizationKey, "Bearer "+token)
+}
+
+func TestServer(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.as(t, "test-admin", authz.RoleAdmin)
+
+	health, err := e.health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.GetStatus())
+
+	registered, err := e.client.RegisterUser(context.Background(), &userpb.RegisterUserRequest{
+		Name:     "Jane",
+		Address:  "Jakarta",
+		Email:    "jane@example.com",
+		Password: "correct horse battery",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	userID := registered.GetUserMysql().GetId()
+	assert.NotEmpty(t, userID)
+	assert.Equal(t, "jane@example.com", registered.GetUserMysql().GetEmail())
+
+	got, err := e.client.GetUser(admin, &userpb.GetUserRequest{Id: userID})
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane", got.GetUser().GetName())
+
+	account, err := e.client.RegisterAccount(admin, &userpb.RegisterAccountRequest{MsisdnCustomer: "628123456789", UserId: userID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, userID, account.GetAccount().GetUserId())
+
+	owner, err := e.client.GetUserByAccountID(e.as(t, userID, authz.RoleCustomer), &userpb.GetUserByAccountIDRequest{AccountId: account.GetAccount().GetId()})
+	assert.NoError(t, err)
+	assert.Equal(t, userID, owner.GetUser().GetId())
+
+	_, plain, err := e.keys.Create(context.Background(), "reader", []string{string(authz.UserRead)}, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	got, err = e.client.GetUser(metadata.AppendToOutgoingContext(context.Background(), APIKeyKey, plain), &userpb.GetUserRequest{Id: userID})
+	assert.NoError(t, err)
+	assert.Equal(t, userID, got.GetUser().GetId())
+}
+
+func TestServer_StatusCodes(t *testing.T) {
+	e := newTestEnv(t)
+	admin := e.as(t, "test-admin", authz.RoleAdmin)
+
+	registered, err := e.client.RegisterUser(context.Background(), &userpb.RegisterUserRequest{Name: "Jane", Email: "jane@example.com"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	userID := registered.GetUserMysql().GetId()
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"anonymous", func() error {
+			_, err := e.client.GetUser(context.Background(), &userpb.GetUserRequest{Id: userID})
+			return err
+		}, codes.Unauthenticated},
+		{"invalid token", func() error {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), AuthorizationKey, "Bearer invalid")
+			_, err := e.client.GetUser(ctx, &userpb.GetUserRequest{Id: userID})
+			return err
+		}, codes.Unauthenticated},
+		{"invalid api key", func() error {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), APIKeyKey, "invalid")
+			_, err := e.client.GetUser(ctx, &userpb.GetUserRequest{Id: userID})
+			return err
+		}, codes.Unauthenticated},
+		{"other user", func() error {
+			_, err := e.client.GetUser(e.as(t, "someone-else", authz.RoleCustomer), &userpb.GetUserRequest{Id: userID})
+			return err
+		}, codes.PermissionDenied},
+		{"missing id", func() error {
+			_, err := e.client.GetUser(admin, &userpb.GetUserRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"unknown user", func() error {
+			_, err := e.client.GetUser(admin, &userpb.GetUserRequest{Id: "unknown"})
+			return err
+		}, codes.NotFound},
+		{"unknown account", func() error {
+			_, err := e.client.GetUserByAccountID(admin, &userpb.GetUserByAccountIDRequest{AccountId: "unknown"})
+			return err
+		}, codes.NotFound},
+		{"account of unknown user", func() error {
+			_, err := e.client.RegisterAccount(admin, &userpb.RegisterAccountRequest{MsisdnCustomer: "628123456789", UserId: "unknown"})
+			return err
+		}, codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, status.Code(tt.call()))
+		})
+	}
+
+	_, err = e.client.RegisterAccount(admin, &userpb.RegisterAccountRequest{MsisdnCustomer: "628123456789", UserId: userID})
+	assert.NoError(t, err)
+	_, err = e.client.RegisterAccount(admin, &userpb.RegisterAccountRequest{MsisdnCustomer: "628123456789", UserId: userID})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	limiter := ratelimit.NewLimiter(memory.NewRateLimitRepository(), ratelimit.Config{Default: 3, Write: 1})
+	e := newTestEnv(t, WithRateLimit(limiter))
+
+	registered, err := e.client.RegisterUser(context.Background(), &userpb.RegisterUserRequest{Name: "Jane", Email: "jane@example.com"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var header metadata.MD
+	_, err = e.client.RegisterUser(context.Background(), &userpb.RegisterUserRequest{Name: "Sari", Email: "sari@example.com"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "writes have a separate, smaller budget")
+	assert.Equal(t, []string{"60"}, header.Get(RetryAfterKey))
+
+	_, err = e.client.GetUser(context.Background(), &userpb.GetUserRequest{Id: registered.GetUserMysql().GetId()})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "the last call of the default budget goes through")
+	_, err = e.client.GetUser(context.Background(), &userpb.GetUserRequest{Id: registered.GetUserMysql().GetId()})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "anonymous callers are limited by address")
+
+	user := e.as(t, registered.GetUserMysql().GetId(), authz.RoleCustomer)
+	_, err = e.client.GetUser(user, &userpb.GetUserRequest{Id: registered.GetUserMysql().GetId()})
+	assert.NoError(t, err, "subjects have their own budget")
+}
+
+// selfSigned returns a certificate for localhost and a pool trusting it.
+func selfSigned(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, pool
+}
+
+func TestServer_TLS(t *testing.T) {
+	cert, pool := selfSigned(t)
+	server := NewServer(nil, WithTLS(&tls.Config{Certificates: []tls.Certificate{cert}}))
+
+	ln := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, ln) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	dial := func(creds credentials.TransportCredentials) healthpb.HealthClient {
+		conn, err := grpc.NewClient("passthrough:///localhost",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+			grpc.WithTransportCredentials(creds),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return healthpb.NewHealthClient(conn)
+	}
+
+	health, err := dial(credentials.NewTLS(&tls.Config{RootCAs: pool, ServerName: "localhost"})).
+		Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.GetStatus())
+
+	ctx, cancelCall := context.WithTimeout(context.Background(), time.Second)
+	defer cancelCall()
+	_, err = dial(insecure.NewCredentials()).Check(ctx, &healthpb.HealthCheckRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err), "plaintext calls are refused")
+}
@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+// Reports only carry these generic messages; the underlying errors may name
+// hosts or credentials and are logged instead.
+const (
+	ErrMsgFailed   = "check failed"
+	ErrMsgTimedOut = "check timed out"
+)
+
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c checkFunc) Name() string                    { return c.name }
+func (c checkFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// CheckFunc turns fn into a Checker reported under name.
+func CheckFunc(name string, fn func(ctx context.Context) error) Checker {
+	return checkFunc{name: name, fn: fn}
+}
+
+func MySQL(db *sqlx.DB) Checker {
+	return CheckFunc("mysql", db.PingContext)
+}
+
+func Mongo(client *mongo.Client) Checker {
+	return CheckFunc("mongo", func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	})
+}
+
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+func (r Report) Up() bool {
+	return r.Status == StatusUp
+}
+
+// Run executes all checkers concurrently, each bounded by timeout, and
+// reports down if any of them fails. Failures are logged with their error.
+func Run(ctx context.Context, timeout time.Duration, checkers ...Checker) Report {
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]Result, len(checkers)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, c := range checkers {
+		wg.Add(1)
+		go func(c Checker) {
+			defer wg.Done()
+
+			cctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := c.Check(cctx)
+			res := Result{
+				Status:    StatusUp,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				logging.FromContext(ctx).Error("readiness check failed", "check", c.Name(), "error", err)
+				res.Status = StatusDown
+				res.Error = ErrMsgFailed
+				if errors.Is(cctx.Err(), context.DeadlineExceeded) {
+					res.Error = ErrMsgTimedOut
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.Name()] = res
+			if err != nil {
+				report.Status = StatusDown
+			}
+		}(c)
+	}
+
+	wg.Wait()
+	return report
+}
@@ -0,0 +1,52 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/internal/logging"
+)
+
+func TestRun_AllUp(t *testing.T) {
+	report := Run(context.Background(), time.Second,
+		CheckFunc("mysql", func(ctx context.Context) error { return nil }),
+		CheckFunc("mongo", func(ctx context.Context) error { return nil }),
+	)
+
+	assert.True(t, report.Up())
+	assert.Equal(t, StatusUp, report.Checks["mysql"].Status)
+	assert.Equal(t, StatusUp, report.Checks["mongo"].Status)
+}
+
+func TestRun_OneDown(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := logging.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, nil)))
+
+	report := Run(ctx, time.Second,
+		CheckFunc("mysql", func(ctx context.Context) error { return nil }),
+		CheckFunc("mongo", func(ctx context.Context) error { return errors.New("no reachable servers") }),
+	)
+
+	assert.False(t, report.Up())
+	assert.Equal(t, StatusUp, report.Checks["mysql"].Status)
+	assert.Equal(t, StatusDown, report.Checks["mongo"].Status)
+	assert.Equal(t, ErrMsgFailed, report.Checks["mongo"].Error, "the driver error is not exposed")
+	assert.Contains(t, logs.String(), "no reachable servers", "the driver error is logged")
+}
+
+func TestRun_Timeout(t *testing.T) {
+	report := Run(context.Background(), 10*time.Millisecond,
+		CheckFunc("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	)
+
+	assert.False(t, report.Up())
+	assert.Equal(t, ErrMsgTimedOut, report.Checks["slow"].Error)
+}
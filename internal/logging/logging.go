@@ -0,0 +1,95 @@
+// Package logging builds the structured logger of the service and carries it,
+// tagged with the request ID, through the context.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/vier21/tefa-ch3/config"
+)
+
+const redacted = "[REDACTED]"
+
+// New returns a logger writing JSON lines to w at level and above, with
+// personal data redacted.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: Redact,
+	}))
+}
+
+// ParseLevel reads "debug", "info", "warn" or "error", falling back to info.
+func ParseLevel(s string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+func FromConfig(cfg *config.Config, w io.Writer) *slog.Logger {
+	return New(w, ParseLevel(cfg.LogLevel))
+}
+
+// Redact masks email addresses and MSISDNs and hides secrets, based on the
+// attribute key. It is meant as slog.HandlerOptions.ReplaceAttr.
+func Redact(groups []string, a slog.Attr) slog.Attr {
+	switch strings.ToLower(a.Key) {
+	case "email":
+		a.Value = slog.StringValue(MaskEmail(a.Value.String()))
+	case "msisdn", "msisdn_customer":
+		a.Value = slog.StringValue(MaskMSISDN(a.Value.String()))
+	case "password", "secret", "authorization", "api_key":
+		a.Value = slog.StringValue(redacted)
+	}
+	return a
+}
+
+// MaskEmail keeps the first letter of the local part and the domain.
+func MaskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return redacted
+	}
+	return local[:1] + "***@" + domain
+}
+
+// MaskMSISDN keeps the country and operator prefix and the last 3 digits.
+func MaskMSISDN(msisdn string) string {
+	if len(msisdn) < 8 {
+		return redacted
+	}
+	return msisdn[:5] + strings.Repeat("*", len(msisdn)-8) + msisdn[len(msisdn)-3:]
+}
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger stored in ctx or slog.Default.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithRequestID stores id in ctx and adds it to every line of the logger in
+// ctx.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return WithLogger(ctx, FromContext(ctx).With("request_id", id))
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
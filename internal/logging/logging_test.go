@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var line map[string]interface{}
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestNew_Redacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, slog.LevelInfo)
+
+	logger.Info("registered",
+		"email", "budi@example.com",
+		slog.Group("account", "msisdn_customer", "6281234567890"),
+		"password", "hunter22",
+	)
+
+	lines := decodeLines(t, &buf)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, "b***@example.com", lines[0]["email"])
+	assert.Equal(t, map[string]interface{}{"msisdn_customer": "62812*****890"}, lines[0]["account"])
+	assert.Equal(t, redacted, lines[0]["password"])
+}
+
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, ParseLevel("warn"))
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	lines := decodeLines(t, &buf)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, "shown", lines[0]["msg"])
+	assert.Equal(t, slog.LevelInfo, ParseLevel("verbose"))
+}
+
+func TestWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), New(&buf, slog.LevelInfo))
+	ctx = WithRequestID(ctx, "req-1")
+
+	assert.Equal(t, "req-1", RequestID(ctx))
+	FromContext(ctx).Info("hello")
+
+	lines := decodeLines(t, &buf)
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+
+	assert.Empty(t, RequestID(context.Background()))
+	assert.Equal(t, slog.Default(), FromContext(context.Background()))
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, redacted, MaskEmail("not-an-email"))
+	assert.Equal(t, redacted, MaskMSISDN("12345"))
+	assert.Equal(t, "62812***000", MaskMSISDN("62812345000"))
+}
@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := NewFileMailer(dir, "no-reply@example.com")
+
+	err := mailer.Send(context.Background(), Message{To: "budi@example.com", Subject: "Hello", Body: "line one\nline two\n"})
+	assert.NoError(t, err)
+	err = mailer.Send(context.Background(), Message{To: "sari@example.com", Subject: "Hello", Body: "hi"})
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.NoError(t, err)
+	if !assert.Len(t, files, 2) {
+		return
+	}
+
+	raw, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+
+	headers, body, _ := strings.Cut(string(raw), "\r\n\r\n")
+	assert.Contains(t, headers, "From: no-reply@example.com\r\n")
+	assert.Contains(t, headers, "To: budi@example.com\r\n")
+	assert.Contains(t, headers, "Subject: Hello\r\n")
+	assert.Equal(t, "line one\r\nline two\r\n", body)
+}
+
+func TestFileMailer_HeaderInjection(t *testing.T) {
+	mailer := NewFileMailer(t.TempDir(), "no-reply@example.com")
+
+	err := mailer.Send(context.Background(), Message{To: "budi@example.com\r\nBcc: eve@example.com", Subject: "Hello"})
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+}
@@ -0,0 +1,198 @@
+// Package metrics collects the Prometheus metrics of the service: HTTP
+// traffic, repository calls, database pools and business events.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	repoDuration *prometheus.HistogramVec
+	repoErrors   *prometheus.CounterVec
+
+	mongoConnections      *prometheus.GaugeVec
+	mongoCheckedOut       *prometheus.GaugeVec
+	mongoCheckoutFailures *prometheus.CounterVec
+
+	UsersRegistered     prometheus.Counter
+	AccountsRegistered  prometheus.Counter
+	MsisdnLimitRejected prometheus.Counter
+}
+
+// New returns metrics registered on a fresh registry together with the Go
+// runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, chi route pattern and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method and chi route pattern.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_call_duration_seconds",
+			Help:    "Repository call latency by store and operation.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"store", "operation"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "repository_errors_total",
+			Help: "Failed repository calls by store and operation.",
+		}, []string{"store", "operation"}),
+
+		mongoConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mongo_pool_connections",
+			Help: "Open connections in the Mongo pool by server address.",
+		}, []string{"address"}),
+		mongoCheckedOut: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "mongo_pool_checked_out_connections",
+			Help: "Connections checked out of the Mongo pool by server address.",
+		}, []string{"address"}),
+		mongoCheckoutFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongo_pool_checkout_failures_total",
+			Help: "Failed connection check outs from the Mongo pool by server address.",
+		}, []string{"address"}),
+
+		UsersRegistered: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "users_registered_total",
+			Help: "Users registered.",
+		}),
+		AccountsRegistered: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "accounts_registered_total",
+			Help: "Accounts registered.",
+		}),
+		MsisdnLimitRejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "msisdn_limit_rejections_total",
+			Help: "Accounts rejected because the user reached the MSISDN limit.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration,
+		m.repoDuration, m.repoErrors,
+		m.mongoConnections, m.mongoCheckedOut, m.mongoCheckoutFailures,
+		m.UsersRegistered, m.AccountsRegistered, m.MsisdnLimitRejected,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// shutdownTimeout bounds how long Serve waits for scrapes in flight.
+const shutdownTimeout = 5 * time.Second
+
+// Serve serves the metrics on /metrics of ln until ctx is done. It is meant
+// for a listener apart from the public API, reachable by the scraper only.
+func (m *Metrics) Serve(ctx context.Context, ln net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: shutdownTimeout}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Middleware records every request under the pattern of the chi route that
+// served it, so path parameters do not create new series.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		m.httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		m.httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// RepositoryHook times repository calls and counts their errors. It is a
+// repository.Hook.
+func (m *Metrics) RepositoryHook(ctx context.Context, store, operation string) (context.Context, func(err error)) {
+	start := time.Now()
+
+	return ctx, func(err error) {
+		m.repoDuration.WithLabelValues(store, operation).Observe(time.Since(start).Seconds())
+		if err != nil {
+			m.repoErrors.WithLabelValues(store, operation).Inc()
+		}
+	}
+}
+
+// CollectDBStats exports the pool statistics of db under the name dbName.
+func (m *Metrics) CollectDBStats(db *sql.DB, dbName string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// MongoPoolMonitor returns a pool monitor for the Mongo client options that
+// tracks open and checked out connections.
+func (m *Metrics) MongoPoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				m.mongoConnections.WithLabelValues(e.Address).Inc()
+			case event.ConnectionClosed:
+				m.mongoConnections.WithLabelValues(e.Address).Dec()
+			case event.GetSucceeded:
+				m.mongoCheckedOut.WithLabelValues(e.Address).Inc()
+			case event.ConnectionReturned:
+				m.mongoCheckedOut.WithLabelValues(e.Address).Dec()
+			case event.GetFailed:
+				m.mongoCheckoutFailures.WithLabelValues(e.Address).Inc()
+			}
+		},
+	}
+}
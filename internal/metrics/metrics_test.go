@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := New()
+
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/ok", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/users/{id}", "404")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/ok", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "unmatched", "404")))
+	assert.Equal(t, 3, testutil.CollectAndCount(m.httpDuration))
+}
+
+func TestRepositoryHook(t *testing.T) {
+	m := New()
+
+	_, done := m.RepositoryHook(context.Background(), "mysql", "InsertUser")
+	done(nil)
+	_, done = m.RepositoryHook(context.Background(), "mysql", "InsertUser")
+	done(errors.New("duplicate"))
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.repoErrors.WithLabelValues("mysql", "InsertUser")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.repoDuration))
+}
+
+func TestMongoPoolMonitor(t *testing.T) {
+	m := New()
+	monitor := m.MongoPoolMonitor()
+
+	for _, typ := range []string{event.ConnectionCreated, event.ConnectionCreated, event.GetSucceeded, event.GetSucceeded, event.ConnectionReturned, event.ConnectionClosed, event.GetFailed} {
+		monitor.Event(&event.PoolEvent{Type: typ, Address: "localhost:27017"})
+	}
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.mongoConnections.WithLabelValues("localhost:27017")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.mongoCheckedOut.WithLabelValues("localhost:27017")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.mongoCheckoutFailures.WithLabelValues("localhost:27017")))
+}
+
+func TestServe(t *testing.T) {
+	m := New()
+	m.UsersRegistered.Inc()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- m.Serve(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/metrics")
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, string(body), "users_registered_total 1")
+		assert.Contains(t, string(body), "go_goroutines")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
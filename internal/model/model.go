@@ -1,14 +1,106 @@
 package model
 
+import (
+	"log/slog"
+	"time"
+)
+
 type User struct {
 	UserID  string `db:"id" json:"id,omitempty" bson:"_id,omitempty"`
 	Name    string `db:"name" json:"name" bson:"name"`
 	Address string `db:"address" json:"address" bson:"address"`
 	Email   string `db:"email" json:"email" bson:"email"`
+	// Verified is set once the user followed the link in the verification
+	// email.
+	Verified bool `db:"verified" json:"verified" bson:"verified"`
+
+	// Password is only accepted on registration and never stored on the
+	// user record.
+	Password string `db:"-" json:"password,omitempty" bson:"-"`
 }
 
+// LogValue leaves out the name, address and password. The email is logged
+// under a key the logging package redacts.
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", u.UserID),
+		slog.String("email", u.Email),
+		slog.Bool("verified", u.Verified),
+	)
+}
+
+const (
+	// AccountPending accounts wait for the owner to confirm the MSISDN with
+	// a one-time password.
+	AccountPending = "pending"
+	AccountActive  = "active"
+)
+
 type Account struct {
 	AccountID      string `db:"id" json:"id,omitempty" bson:"_id,omitempty"`
 	MsisdnCustomer string `db:"msisdn_customer" json:"msisdn_customer" bson:"msisdn_customer"`
 	UserID         string `db:"user_id" json:"user_id" bson:"user_id"`
+	Status         string `db:"status" json:"status,omitempty" bson:"status,omitempty"`
+}
+
+// LogValue logs the MSISDN under a key the logging package redacts.
+func (a Account) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", a.AccountID),
+		slog.String("msisdn", a.MsisdnCustomer),
+		slog.String("user_id", a.UserID),
+		slog.String("status", a.Status),
+	)
+}
+
+// AccountOTP is the one-time password confirming the MSISDN of a pending
+// account. Only the SHA-256 hash of the code is stored.
+type AccountOTP struct {
+	AccountID string    `db:"account_id"`
+	CodeHash  string    `db:"code_hash"`
+	Attempts  int       `db:"attempts"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// APIKey is a long-lived credential for service-to-service clients. Only the
+// SHA-256 hash of the key is stored; RateLimit is in requests per minute and
+// 0 means unlimited.
+type APIKey struct {
+	ID         string     `db:"id" json:"id"`
+	Name       string     `db:"name" json:"name"`
+	Prefix     string     `db:"prefix" json:"prefix"`
+	KeyHash    string     `db:"key_hash" json:"-"`
+	Scopes     []string   `db:"-" json:"scopes"`
+	RateLimit  int        `db:"rate_limit" json:"rate_limit"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+}
+
+// Credential is a user's password login. Email is copied from the user when
+// the password is set and is unique across credentials.
+type Credential struct {
+	UserID         string     `db:"user_id" json:"user_id"`
+	Email          string     `db:"email" json:"email"`
+	PasswordHash   string     `db:"password_hash" json:"-"`
+	FailedAttempts int        `db:"failed_attempts" json:"-"`
+	LockedUntil    *time.Time `db:"locked_until" json:"-"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// PasswordReset is a one-time password reset token. Only the SHA-256 hash of
+// the token is stored.
+type PasswordReset struct {
+	TokenHash string     `db:"token_hash"`
+	UserID    string     `db:"user_id"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+// RateLimitBucket is the token bucket of one client and rate limit class.
+type RateLimitBucket struct {
+	Key       string    `db:"bucket_key"`
+	Tokens    float64   `db:"tokens"`
+	UpdatedAt time.Time `db:"updated_at"`
 }
@@ -0,0 +1,249 @@
+// Package otp confirms that a customer owns the MSISDN of a new account with
+// a one-time password sent by SMS.
+package otp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/vier21/tefa-ch3/config"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/sms"
+)
+
+const purgeInterval = time.Minute
+
+var (
+	ErrInvalidCode     = errors.New("invalid verification code")
+	ErrExpired         = errors.New("verification code expired, register the account again")
+	ErrTooManyAttempts = errors.New("too many invalid verification codes, register the account again")
+)
+
+type Config struct {
+	// Length is the number of digits of a code.
+	Length int
+	TTL    time.Duration
+	// MaxAttempts wrong codes discard the pending account.
+	MaxAttempts int
+}
+
+func FromConfig(cfg *config.Config) Config {
+	return Config{
+		Length:      cfg.OTPLength,
+		TTL:         cfg.OTPTTL,
+		MaxAttempts: cfg.OTPMaxAttempts,
+	}
+}
+
+type Service struct {
+	accounts repository.MysqlRepositoryInterface
+	otps     repository.OTPRepositoryInterface
+	gateway  sms.Gateway
+	cfg      Config
+	now      func() time.Time
+}
+
+type Option func(*Service)
+
+// WithClock makes the service read the current time from now instead of
+// time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) {
+		s.now = now
+	}
+}
+
+func NewService(accounts repository.MysqlRepositoryInterface, otps repository.OTPRepositoryInterface, gateway sms.Gateway, cfg Config, opts ...Option) (*Service, error) {
+	if cfg.Length < 4 || cfg.Length > 10 {
+		return nil, fmt.Errorf("otp: length must be between 4 and 10 digits")
+	}
+	if cfg.MaxAttempts < 1 {
+		return nil, fmt.Errorf("otp: max attempts must be at least 1")
+	}
+	if cfg.TTL <= 0 {
+		return nil, fmt.Errorf("otp: ttl must be positive")
+	}
+
+	s := &Service{
+		accounts: accounts,
+		otps:     otps,
+		gateway:  gateway,
+		cfg:      cfg,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Start sends a code to the MSISDN of a pending account. The account is
+// discarded when the code cannot be sent.
+func (s *Service) Start(ctx context.Context, account model.Account) error {
+	code, err := s.generate()
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC().Truncate(time.Second)
+	err = s.otps.UpsertOTP(ctx, model.AccountOTP{
+		AccountID: account.AccountID,
+		CodeHash:  hash(account.AccountID, code),
+		ExpiresAt: now.Add(s.cfg.TTL),
+		CreatedAt: now,
+	})
+	if err == nil {
+		text := fmt.Sprintf("Your verification code is %s. It expires in %s.", code, s.cfg.TTL)
+		err = s.gateway.Send(ctx, account.MsisdnCustomer, text)
+	}
+
+	if err != nil {
+		if derr := s.discard(ctx, account.AccountID); derr != nil {
+			logging.FromContext(ctx).Error("error discarding pending account", "account_id", account.AccountID, "error", derr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Verify activates the account when code matches. Expired or missing codes
+// and too many wrong codes discard the pending account, and so does an
+// MSISDN activated by another account in the meantime.
+func (s *Service) Verify(ctx context.Context, accountID, code string) (model.Account, error) {
+	account, err := s.accounts.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return model.Account{}, err
+	}
+	if account.Status == model.AccountActive {
+		return account, nil
+	}
+
+	otp, err := s.otps.GetOTP(ctx, accountID)
+	if errors.Is(err, repository.ErrOTPNotFound) {
+		// Purge only finds pending accounts through their code, so one
+		// without a code would otherwise be kept for good.
+		return model.Account{}, s.fail(ctx, accountID, ErrExpired)
+	}
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	if !s.now().Before(otp.ExpiresAt) {
+		return model.Account{}, s.fail(ctx, accountID, ErrExpired)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash(accountID, code)), []byte(otp.CodeHash)) != 1 {
+		attempts, err := s.otps.RecordOTPFailure(ctx, accountID)
+		if err != nil {
+			return model.Account{}, err
+		}
+		if attempts >= s.cfg.MaxAttempts {
+			return model.Account{}, s.fail(ctx, accountID, ErrTooManyAttempts)
+		}
+		return model.Account{}, ErrInvalidCode
+	}
+
+	err = s.accounts.ActivateAccount(ctx, accountID)
+	if errors.Is(err, repository.ErrMsisdnTaken) {
+		return model.Account{}, s.fail(ctx, accountID, err)
+	}
+	if err != nil {
+		return model.Account{}, err
+	}
+	if err := s.otps.DeleteOTP(ctx, accountID); err != nil {
+		logging.FromContext(ctx).Error("error deleting otp", "account_id", accountID, "error", err)
+	}
+
+	account.Status = model.AccountActive
+	return account, nil
+}
+
+func (s *Service) fail(ctx context.Context, accountID string, reason error) error {
+	if err := s.discard(ctx, accountID); err != nil {
+		return err
+	}
+	return reason
+}
+
+func (s *Service) discard(ctx context.Context, accountID string) error {
+	if err := s.accounts.DeleteAccount(ctx, accountID); err != nil && !errors.Is(err, repository.ErrAccountNotFound) {
+		return err
+	}
+	return s.otps.DeleteOTP(ctx, accountID)
+}
+
+// Purge discards pending accounts whose code expired and returns how many
+// were removed.
+func (s *Service) Purge(ctx context.Context) (int, error) {
+	ids, err := s.otps.ListExpiredOTPs(ctx, s.now())
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int
+	for _, id := range ids {
+		account, err := s.accounts.GetAccountByID(ctx, id)
+		if err != nil && !errors.Is(err, repository.ErrAccountNotFound) {
+			return purged, err
+		}
+
+		// a leftover code of an account that was activated anyway
+		if err == nil && account.Status == model.AccountActive {
+			if err := s.otps.DeleteOTP(ctx, id); err != nil {
+				return purged, err
+			}
+			continue
+		}
+
+		if err := s.discard(ctx, id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// Run purges expired pending accounts every minute until ctx is cancelled.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.Purge(ctx); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("error purging pending accounts", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Service) generate() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(s.cfg.Length)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", s.cfg.Length, n), nil
+}
+
+// hash binds the code to the account so equal codes of different accounts
+// do not share a hash.
+func hash(accountID, code string) string {
+	sum := sha256.Sum256([]byte(accountID + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,180 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/internal/clocktest"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/repository/memory"
+	"github.com/vier21/tefa-ch3/internal/sms"
+)
+
+type testEnv struct {
+	svc      *Service
+	accounts *memory.MysqlRepository
+	gateway  *sms.Fake
+	clock    *clocktest.Clock
+	account  model.Account
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	clock := clocktest.New()
+	accounts := memory.NewMysqlRepository()
+	gateway := &sms.Fake{}
+	svc, err := NewService(accounts, memory.NewOTPRepository(), gateway, Config{Length: 6, TTL: 5 * time.Minute, MaxAttempts: 3}, WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	user, err := accounts.InsertUser(ctx, model.User{Name: "Budi"})
+	assert.NoError(t, err)
+	account, err := accounts.InsertAccount(ctx, model.Account{MsisdnCustomer: "6281200000001", UserID: user.UserID, Status: model.AccountPending})
+	assert.NoError(t, err)
+
+	return &testEnv{svc: svc, accounts: accounts, gateway: gateway, clock: clock, account: account}
+}
+
+var codePattern = regexp.MustCompile(`\d{6}`)
+
+func (e *testEnv) code(t *testing.T) string {
+	t.Helper()
+
+	msg, ok := e.gateway.Last(e.account.MsisdnCustomer)
+	if !ok {
+		t.Fatal("no code sent")
+	}
+	return codePattern.FindString(msg.Text)
+}
+
+func TestVerify(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	assert.NoError(t, env.svc.Start(ctx, env.account))
+	code := env.code(t)
+	assert.Len(t, code, 6)
+
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	_, err := env.svc.Verify(ctx, env.account.AccountID, wrong)
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	account, err := env.svc.Verify(ctx, env.account.AccountID, code)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AccountActive, account.Status)
+
+	stored, err := env.accounts.GetAccountByID(ctx, env.account.AccountID)
+	assert.NoError(t, err)
+	assert.Equal(t, model.AccountActive, stored.Status)
+
+	_, err = env.svc.Verify(ctx, env.account.AccountID, "whatever")
+	assert.NoError(t, err, "verifying an active account is a no-op")
+}
+
+func TestVerify_TooManyAttempts(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	assert.NoError(t, env.svc.Start(ctx, env.account))
+
+	for i := 0; i < 2; i++ {
+		_, err := env.svc.Verify(ctx, env.account.AccountID, "wrong")
+		assert.ErrorIs(t, err, ErrInvalidCode)
+	}
+	_, err := env.svc.Verify(ctx, env.account.AccountID, "wrong")
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+
+	_, err = env.accounts.GetAccountByID(ctx, env.account.AccountID)
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound, "the pending account is discarded")
+}
+
+func TestVerify_Expired(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	assert.NoError(t, env.svc.Start(ctx, env.account))
+	code := env.code(t)
+
+	env.clock.Advance(5 * time.Minute)
+	_, err := env.svc.Verify(ctx, env.account.AccountID, code)
+	assert.ErrorIs(t, err, ErrExpired)
+
+	_, err = env.accounts.GetAccountByID(ctx, env.account.AccountID)
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+}
+
+func TestVerify_NoCode(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	_, err := env.svc.Verify(ctx, env.account.AccountID, "123456")
+	assert.ErrorIs(t, err, ErrExpired)
+
+	_, err = env.accounts.GetAccountByID(ctx, env.account.AccountID)
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound, "a pending account without a code is discarded")
+}
+
+func TestVerify_MsisdnTaken(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	assert.NoError(t, env.svc.Start(ctx, env.account))
+	code := env.code(t)
+
+	owner, err := env.accounts.InsertUser(ctx, model.User{Name: "Sari"})
+	assert.NoError(t, err)
+	_, err = env.accounts.InsertAccount(ctx, model.Account{MsisdnCustomer: env.account.MsisdnCustomer, UserID: owner.UserID})
+	assert.NoError(t, err)
+
+	_, err = env.svc.Verify(ctx, env.account.AccountID, code)
+	assert.ErrorIs(t, err, repository.ErrMsisdnTaken)
+
+	_, err = env.accounts.GetAccountByID(ctx, env.account.AccountID)
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound, "the losing pending account is discarded")
+}
+
+type failingGateway struct{}
+
+func (failingGateway) Send(ctx context.Context, to, text string) error {
+	return errors.New("gateway down")
+}
+
+func TestStart_SendError(t *testing.T) {
+	env := newTestEnv(t)
+	env.svc.gateway = failingGateway{}
+
+	assert.Error(t, env.svc.Start(context.Background(), env.account))
+
+	_, err := env.accounts.GetAccountByID(context.Background(), env.account.AccountID)
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+}
+
+func TestPurge(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	assert.NoError(t, env.svc.Start(ctx, env.account))
+
+	purged, err := env.svc.Purge(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, purged)
+
+	env.clock.Advance(10 * time.Minute)
+	purged, err = env.svc.Purge(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = env.accounts.GetAccountByID(ctx, env.account.AccountID)
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+}
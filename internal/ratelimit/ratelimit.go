@@ -0,0 +1,159 @@
+// Package ratelimit throttles clients with token buckets, kept in process or
+// shared between replicas through the database.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vier21/tefa-ch3/config"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/repository/memory"
+)
+
+const purgeInterval = time.Minute
+
+// Class selects one of the budgets of a client.
+type Class string
+
+const (
+	// Default is charged for every request.
+	Default Class = "default"
+	// Write is additionally charged for endpoints that create records.
+	Write Class = "write"
+)
+
+type Config struct {
+	// Default and Write are the budgets in requests per minute, with a burst
+	// of the same size. 0 disables the budget.
+	Default int
+	Write   int
+	// Backend is "memory" to keep buckets in this process or "mysql" to
+	// share them between replicas.
+	Backend string
+}
+
+func FromConfig(cfg *config.Config) Config {
+	return Config{
+		Default: cfg.RateLimitDefault,
+		Write:   cfg.RateLimitWrite,
+		Backend: cfg.RateLimitBackend,
+	}
+}
+
+// NewStore returns the bucket store of backend.
+func NewStore(backend string) (repository.RateLimitRepositoryInterface, error) {
+	switch backend {
+	case "", "memory":
+		return memory.NewRateLimitRepository(), nil
+	case "mysql":
+		return repository.NewRateLimitRepository(), nil
+	}
+	return nil, fmt.Errorf("ratelimit: unknown backend %q", backend)
+}
+
+type Limiter struct {
+	store repository.RateLimitRepositoryInterface
+	cfg   Config
+	now   func() time.Time
+}
+
+type Option func(*Limiter)
+
+// WithClock makes the limiter read the current time from now instead of
+// time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(l *Limiter) {
+		l.now = now
+	}
+}
+
+func NewLimiter(store repository.RateLimitRepositoryInterface, cfg Config, opts ...Option) *Limiter {
+	l := &Limiter{
+		store: store,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Take consumes a token from the class budget of client and returns how long
+// to wait when none is left.
+func (l *Limiter) Take(ctx context.Context, class Class, client string) (time.Duration, error) {
+	perMinute := l.limit(class)
+	if perMinute <= 0 {
+		return 0, nil
+	}
+
+	var wait time.Duration
+	err := l.store.UpdateBucket(ctx, string(class)+":"+client, func(b model.RateLimitBucket) model.RateLimitBucket {
+		b, wait = take(b, perMinute, l.now())
+		return b
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return wait, nil
+}
+
+func (l *Limiter) limit(class Class) int {
+	if class == Write {
+		return l.cfg.Write
+	}
+	return l.cfg.Default
+}
+
+// Purge removes the buckets idle long enough to be full again, which is the
+// same as having none.
+func (l *Limiter) Purge(ctx context.Context) (int, error) {
+	return l.store.DeleteBucketsBefore(ctx, l.now().Add(-time.Minute))
+}
+
+// Run purges idle buckets every minute until ctx is done.
+func (l *Limiter) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := l.Purge(ctx); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("error purging rate limit buckets", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// take refills b at perMinute tokens per minute up to perMinute and consumes
+// one token. A bucket never updated starts full.
+func take(b model.RateLimitBucket, perMinute int, now time.Time) (model.RateLimitBucket, time.Duration) {
+	capacity := float64(perMinute)
+	rate := capacity / float64(time.Minute)
+
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = capacity
+	} else if elapsed := now.Sub(b.UpdatedAt); elapsed > 0 {
+		b.Tokens += float64(elapsed) * rate
+	}
+	if b.Tokens > capacity {
+		b.Tokens = capacity
+	}
+	b.UpdatedAt = now
+
+	if b.Tokens < 1 {
+		return b, time.Duration((1 - b.Tokens) / rate)
+	}
+
+	b.Tokens--
+	return b, 0
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/internal/clocktest"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/repository/memory"
+)
+
+func newTestLimiter(store repository.RateLimitRepositoryInterface, cfg Config) (*Limiter, *clocktest.Clock) {
+	clock := clocktest.New()
+	return NewLimiter(store, cfg, WithClock(clock.Now)), clock
+}
+
+func TestTake(t *testing.T) {
+	limiter, clock := newTestLimiter(memory.NewRateLimitRepository(), Config{Default: 3, Write: 1})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		wait, err := limiter.Take(ctx, Default, "client")
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+	}
+	wait, err := limiter.Take(ctx, Default, "client")
+	assert.NoError(t, err)
+	assert.Equal(t, 20*time.Second, wait, "3 per minute refill a token every 20s")
+
+	wait, _ = limiter.Take(ctx, Default, "other")
+	assert.Zero(t, wait, "clients have their own buckets")
+
+	wait, _ = limiter.Take(ctx, Write, "client")
+	assert.Zero(t, wait, "classes have their own buckets")
+	wait, _ = limiter.Take(ctx, Write, "client")
+	assert.Equal(t, time.Minute, wait)
+
+	clock.Advance(20 * time.Second)
+	wait, _ = limiter.Take(ctx, Default, "client")
+	assert.Zero(t, wait)
+	wait, _ = limiter.Take(ctx, Default, "client")
+	assert.Equal(t, 20*time.Second, wait)
+}
+
+func TestTake_Disabled(t *testing.T) {
+	limiter, _ := newTestLimiter(memory.NewRateLimitRepository(), Config{Default: 1})
+
+	for i := 0; i < 5; i++ {
+		wait, err := limiter.Take(context.Background(), Write, "client")
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	limiter, clock := newTestLimiter(memory.NewRateLimitRepository(), Config{Default: 2})
+	ctx := context.Background()
+
+	_, _ = limiter.Take(ctx, Default, "idle")
+	_, _ = limiter.Take(ctx, Default, "idle")
+	clock.Advance(30 * time.Second)
+	_, _ = limiter.Take(ctx, Default, "active")
+
+	clock.Advance(45 * time.Second)
+	n, err := limiter.Purge(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	wait, _ := limiter.Take(ctx, Default, "idle")
+	assert.Zero(t, wait, "purged buckets start full")
+}
+
+func TestNewStore(t *testing.T) {
+	store, err := NewStore("memory")
+	assert.NoError(t, err)
+	assert.IsType(t, &memory.RateLimitRepository{}, store)
+
+	_, err = NewStore("redis")
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/vier21/tefa-ch3/db"
+	"github.com/vier21/tefa-ch3/internal/model"
+)
+
+type APIKeyRepositoryInterface interface {
+	InsertAPIKey(ctx context.Context, key model.APIKey) (model.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string, at time.Time) error
+	TouchAPIKey(ctx context.Context, id string, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *sqlx.DB
+}
+
+func NewAPIKeyRepository() *apiKeyRepository {
+	return &apiKeyRepository{
+		db: db.DB,
+	}
+}
+
+// apiKeyRow is model.APIKey as stored, with scopes space separated.
+type apiKeyRow struct {
+	model.APIKey
+	Scopes string `db:"scopes"`
+}
+
+func (r apiKeyRow) toModel() model.APIKey {
+	key := r.APIKey
+	key.Scopes = strings.Fields(r.Scopes)
+	return key
+}
+
+const apiKeyColumns = "id, name, prefix, key_hash, scopes, rate_limit, created_at, last_used_at, revoked_at"
+
+func (m *apiKeyRepository) InsertAPIKey(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	key.ID = uuid.NewString()
+
+	sqlstr := "INSERT INTO api_key (id, name, prefix, key_hash, scopes, rate_limit, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	_, err := m.db.ExecContext(ctx, sqlstr, key.ID, key.Name, key.Prefix, key.KeyHash, strings.Join(key.Scopes, " "), key.RateLimit, key.CreatedAt)
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+func (m *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	var row apiKeyRow
+	err := m.db.GetContext(ctx, &row, "SELECT "+apiKeyColumns+" FROM api_key WHERE key_hash = ?", hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.APIKey{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	return row.toModel(), nil
+}
+
+func (m *apiKeyRepository) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	var rows []apiKeyRow
+	if err := m.db.SelectContext(ctx, &rows, "SELECT "+apiKeyColumns+" FROM api_key ORDER BY created_at"); err != nil {
+		return nil, err
+	}
+
+	keys := make([]model.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.toModel())
+	}
+
+	return keys, nil
+}
+
+func (m *apiKeyRepository) RevokeAPIKey(ctx context.Context, id string, at time.Time) error {
+	res, err := m.db.ExecContext(ctx, "UPDATE api_key SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", at, id)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		var exists bool
+		if err := m.db.GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM api_key WHERE id = ?)", id); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrAPIKeyNotFound
+		}
+	}
+
+	return nil
+}
+
+func (m *apiKeyRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	_, err := m.db.ExecContext(ctx, "UPDATE api_key SET last_used_at = ? WHERE id = ?", at, id)
+	return err
+}
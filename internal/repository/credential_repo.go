@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/vier21/tefa-ch3/db"
+	"github.com/vier21/tefa-ch3/internal/model"
+)
+
+type CredentialRepositoryInterface interface {
+	InsertCredential(ctx context.Context, cred model.Credential) error
+	GetCredentialByEmail(ctx context.Context, email string) (model.Credential, error)
+	GetCredentialByUserID(ctx context.Context, userID string) (model.Credential, error)
+	UpdatePassword(ctx context.Context, userID, hash string, at time.Time) error
+	RecordLoginFailure(ctx context.Context, userID string) (int, error)
+	LockCredential(ctx context.Context, userID string, until time.Time) error
+	ResetLoginFailures(ctx context.Context, userID string) error
+	InsertPasswordReset(ctx context.Context, reset model.PasswordReset) error
+	ResetPassword(ctx context.Context, tokenHash, hash string, at time.Time) (string, error)
+}
+
+type credentialRepository struct {
+	db *sqlx.DB
+}
+
+func NewCredentialRepository() *credentialRepository {
+	return &credentialRepository{
+		db: db.DB,
+	}
+}
+
+const credentialColumns = "user_id, email, password_hash, failed_attempts, locked_until, updated_at"
+
+func (m *credentialRepository) InsertCredential(ctx context.Context, cred model.Credential) error {
+	sqlstr := "INSERT INTO user_credential (user_id, email, password_hash, updated_at) VALUES (?, ?, ?, ?)"
+	_, err := m.db.ExecContext(ctx, sqlstr, cred.UserID, cred.Email, cred.PasswordHash, cred.UpdatedAt)
+	return mapCredentialError(err)
+}
+
+func (m *credentialRepository) GetCredentialByEmail(ctx context.Context, email string) (model.Credential, error) {
+	return m.getCredential(ctx, "email", email)
+}
+
+func (m *credentialRepository) GetCredentialByUserID(ctx context.Context, userID string) (model.Credential, error) {
+	return m.getCredential(ctx, "user_id", userID)
+}
+
+func (m *credentialRepository) getCredential(ctx context.Context, column, value string) (model.Credential, error) {
+	var cred model.Credential
+	err := m.db.GetContext(ctx, &cred, "SELECT "+credentialColumns+" FROM user_credential WHERE "+column+" = ?", value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Credential{}, ErrCredentialNotFound
+	}
+	if err != nil {
+		return model.Credential{}, err
+	}
+
+	return cred, nil
+}
+
+// UpdatePassword replaces the password hash and clears any failed attempts
+// and lockout.
+func (m *credentialRepository) UpdatePassword(ctx context.Context, userID, hash string, at time.Time) error {
+	res, err := m.db.ExecContext(ctx, updatePasswordSQL, hash, at, userID)
+	if err != nil {
+		return err
+	}
+
+	return requireCredential(ctx, m.db, res, userID)
+}
+
+const updatePasswordSQL = "UPDATE user_credential SET password_hash = ?, failed_attempts = 0, locked_until = NULL, updated_at = ? WHERE user_id = ?"
+
+// RecordLoginFailure increments the failed attempts and returns the new count.
+func (m *credentialRepository) RecordLoginFailure(ctx context.Context, userID string) (int, error) {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE user_credential SET failed_attempts = failed_attempts + 1 WHERE user_id = ?", userID); err != nil {
+		return 0, err
+	}
+
+	var attempts int
+	err = tx.GetContext(ctx, &attempts, "SELECT failed_attempts FROM user_credential WHERE user_id = ?", userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrCredentialNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return attempts, tx.Commit()
+}
+
+// LockCredential blocks logins until the given time and starts counting
+// failures afresh.
+func (m *credentialRepository) LockCredential(ctx context.Context, userID string, until time.Time) error {
+	res, err := m.db.ExecContext(ctx, "UPDATE user_credential SET failed_attempts = 0, locked_until = ? WHERE user_id = ?", until, userID)
+	if err != nil {
+		return err
+	}
+
+	return requireCredential(ctx, m.db, res, userID)
+}
+
+func (m *credentialRepository) ResetLoginFailures(ctx context.Context, userID string) error {
+	_, err := m.db.ExecContext(ctx, "UPDATE user_credential SET failed_attempts = 0, locked_until = NULL WHERE user_id = ?", userID)
+	return err
+}
+
+func (m *credentialRepository) InsertPasswordReset(ctx context.Context, reset model.PasswordReset) error {
+	sqlstr := "INSERT INTO password_reset (token_hash, user_id, expires_at) VALUES (?, ?, ?)"
+	_, err := m.db.ExecContext(ctx, sqlstr, reset.TokenHash, reset.UserID, reset.ExpiresAt)
+	return mapCredentialError(err)
+}
+
+// ResetPassword sets the password of the owner of an unused, unexpired
+// reset token and returns the user ID. The token is only marked as used once
+// the password has been updated, in the same transaction, so a failed update
+// leaves it usable.
+func (m *credentialRepository) ResetPassword(ctx context.Context, tokenHash, hash string, at time.Time) (string, error) {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	sqlstr := "SELECT user_id FROM password_reset WHERE token_hash = ? AND used_at IS NULL AND expires_at > ? FOR UPDATE"
+	err = tx.GetContext(ctx, &userID, sqlstr, tokenHash, at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrResetTokenInvalid
+	}
+	if err != nil {
+		return "", err
+	}
+
+	res, err := tx.ExecContext(ctx, updatePasswordSQL, hash, at, userID)
+	if err != nil {
+		return "", err
+	}
+	if err := requireCredential(ctx, tx, res, userID); err != nil {
+		return "", err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE password_reset SET used_at = ? WHERE token_hash = ?", at, tokenHash); err != nil {
+		return "", err
+	}
+
+	return userID, tx.Commit()
+}
+
+// requireCredential turns an update that matched no rows into
+// ErrCredentialNotFound. MySQL reports unchanged rows as unaffected, so the
+// row is looked up before giving up.
+func requireCredential(ctx context.Context, q sqlx.QueryerContext, res sql.Result, userID string) error {
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	var exists bool
+	if err := sqlx.GetContext(ctx, q, &exists, "SELECT EXISTS(SELECT 1 FROM user_credential WHERE user_id = ?)", userID); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCredentialNotFound
+	}
+
+	return nil
+}
+
+func mapCredentialError(err error) error {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return err
+	}
+
+	switch myErr.Number {
+	case 1062: // ER_DUP_ENTRY
+		return ErrCredentialExists
+	case 1452: // ER_NO_REFERENCED_ROW_2
+		return ErrUserNotFound
+	}
+	return err
+}
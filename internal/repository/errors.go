@@ -0,0 +1,21 @@
+package repository
+
+import "errors"
+
+// MaxAccountsPerUser is the number of MSISDNs a single user may register.
+const MaxAccountsPerUser = 3
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrUserExists      = errors.New("user already exists")
+	ErrAccountNotFound = errors.New("account not found")
+	ErrMsisdnLimit     = errors.New("MSISDN Limit Reached")
+	ErrMsisdnTaken     = errors.New("MSISDN already registered")
+	ErrAPIKeyNotFound  = errors.New("api key not found")
+
+	ErrCredentialNotFound = errors.New("credential not found")
+	ErrCredentialExists   = errors.New("credential already exists")
+	ErrResetTokenInvalid  = errors.New("password reset token is invalid or expired")
+
+	ErrOTPNotFound = errors.New("otp not found")
+)
@@ -0,0 +1,3 @@
+package repository
+
+//go:generate mockery --config ../../.mockery.yaml
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/vier21/tefa-ch3/internal/model"
+)
+
+const (
+	StoreMysql = "mysql"
+	StoreMongo = "mongo"
+)
+
+// Hook is called before every call of an instrumented repository. It returns
+// the context for the call and a function receiving the call's error.
+type Hook func(ctx context.Context, store, operation string) (context.Context, func(err error))
+
+func runHooks(ctx context.Context, hooks []Hook, store, operation string) (context.Context, func(err error)) {
+	done := make([]func(error), 0, len(hooks))
+	for _, hook := range hooks {
+		var fn func(error)
+		ctx, fn = hook(ctx, store, operation)
+		done = append(done, fn)
+	}
+
+	return ctx, func(err error) {
+		for i := len(done) - 1; i >= 0; i-- {
+			done[i](err)
+		}
+	}
+}
+
+type instrumentedMysql struct {
+	next  MysqlRepositoryInterface
+	hooks []Hook
+}
+
+// InstrumentMysql runs hooks around every call of next.
+func InstrumentMysql(next MysqlRepositoryInterface, hooks ...Hook) MysqlRepositoryInterface {
+	return &instrumentedMysql{next: next, hooks: hooks}
+}
+
+func (i *instrumentedMysql) InsertUser(ctx context.Context, user model.User) (model.User, error) {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "InsertUser")
+	user, err := i.next.InsertUser(ctx, user)
+	done(err)
+	return user, err
+}
+
+func (i *instrumentedMysql) GetUserByID(ctx context.Context, userID string) (model.User, error) {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "GetUserByID")
+	user, err := i.next.GetUserByID(ctx, userID)
+	done(err)
+	return user, err
+}
+
+func (i *instrumentedMysql) GetUsersByIDs(ctx context.Context, userIDs []string) ([]model.User, error) {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "GetUsersByIDs")
+	users, err := i.next.GetUsersByIDs(ctx, userIDs)
+	done(err)
+	return users, err
+}
+
+func (i *instrumentedMysql) InsertAccount(ctx context.Context, account model.Account) (model.Account, error) {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "InsertAccount")
+	account, err := i.next.InsertAccount(ctx, account)
+	done(err)
+	return account, err
+}
+
+func (i *instrumentedMysql) GetUserByAccountID(ctx context.Context, accountID string) (model.User, error) {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "GetUserByAccountID")
+	user, err := i.next.GetUserByAccountID(ctx, accountID)
+	done(err)
+	return user, err
+}
+
+func (i *instrumentedMysql) GetAccountByID(ctx context.Context, accountID string) (model.Account, error) {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "GetAccountByID")
+	account, err := i.next.GetAccountByID(ctx, accountID)
+	done(err)
+	return account, err
+}
+
+func (i *instrumentedMysql) ListAccountsByUserID(ctx context.Context, userID string) ([]model.Account, error) {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "ListAccountsByUserID")
+	accounts, err := i.next.ListAccountsByUserID(ctx, userID)
+	done(err)
+	return accounts, err
+}
+
+func (i *instrumentedMysql) ListAccountsByUserIDs(ctx context.Context, userIDs []string) ([]model.Account, error) {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "ListAccountsByUserIDs")
+	accounts, err := i.next.ListAccountsByUserIDs(ctx, userIDs)
+	done(err)
+	return accounts, err
+}
+
+func (i *instrumentedMysql) ActivateAccount(ctx context.Context, accountID string) error {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "ActivateAccount")
+	err := i.next.ActivateAccount(ctx, accountID)
+	done(err)
+	return err
+}
+
+func (i *instrumentedMysql) DeleteAccount(ctx context.Context, accountID string) error {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "DeleteAccount")
+	err := i.next.DeleteAccount(ctx, accountID)
+	done(err)
+	return err
+}
+
+func (i *instrumentedMysql) DeleteUser(ctx context.Context, userID string) error {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "DeleteUser")
+	err := i.next.DeleteUser(ctx, userID)
+	done(err)
+	return err
+}
+
+func (i *instrumentedMysql) MarkUserVerified(ctx context.Context, userID string) error {
+	ctx, done := runHooks(ctx, i.hooks, StoreMysql, "MarkUserVerified")
+	err := i.next.MarkUserVerified(ctx, userID)
+	done(err)
+	return err
+}
+
+type instrumentedMongo struct {
+	next  MongodbRepositoryInterface
+	hooks []Hook
+}
+
+// InstrumentMongo runs hooks around every call of next.
+func InstrumentMongo(next MongodbRepositoryInterface, hooks ...Hook) MongodbRepositoryInterface {
+	return &instrumentedMongo{next: next, hooks: hooks}
+}
+
+func (i *instrumentedMongo) InsertUser(ctx context.Context, user model.User) (model.User, error) {
+	ctx, done := runHooks(ctx, i.hooks, StoreMongo, "InsertUser")
+	user, err := i.next.InsertUser(ctx, user)
+	done(err)
+	return user, err
+}
+
+func (i *instrumentedMongo) GetUser(ctx context.Context, userid string) (model.User, error) {
+	ctx, done := runHooks(ctx, i.hooks, StoreMongo, "GetUser")
+	user, err := i.next.GetUser(ctx, userid)
+	done(err)
+	return user, err
+}
+
+func (i *instrumentedMongo) MarkUserVerified(ctx context.Context, userid string) error {
+	ctx, done := runHooks(ctx, i.hooks, StoreMongo, "MarkUserVerified")
+	err := i.next.MarkUserVerified(ctx, userid)
+	done(err)
+	return err
+}
+
+func (i *instrumentedMongo) DeleteUser(ctx context.Context, userid string) error {
+	ctx, done := runHooks(ctx, i.hooks, StoreMongo, "DeleteUser")
+	err := i.next.DeleteUser(ctx, userid)
+	done(err)
+	return err
+}
@@ -0,0 +1,48 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/repository/memory"
+)
+
+type ctxKey struct{}
+
+func TestInstrumentMysql(t *testing.T) {
+	var calls []string
+	var errs []error
+	hook := func(name string) repository.Hook {
+		return func(ctx context.Context, store, operation string) (context.Context, func(error)) {
+			calls = append(calls, name+" "+store+"."+operation)
+			return context.WithValue(ctx, ctxKey{}, name), func(err error) {
+				calls = append(calls, name+" done")
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	repo := repository.InstrumentMysql(memory.NewMysqlRepository(), hook("outer"), hook("inner"))
+
+	_, err := repo.GetUserByID(context.Background(), "missing")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	assert.Equal(t, []string{"outer mysql.GetUserByID", "inner mysql.GetUserByID", "inner done", "outer done"}, calls)
+	assert.Equal(t, []error{err, err}, errs)
+}
+
+func TestInstrumentMongo(t *testing.T) {
+	var seen string
+	repo := repository.InstrumentMongo(memory.NewMongoRepository(), func(ctx context.Context, store, operation string) (context.Context, func(error)) {
+		seen = store + "." + operation
+		return ctx, func(error) {}
+	})
+
+	user, err := repo.InsertUser(context.Background(), model.User{Name: "Budi"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, user.UserID)
+	assert.Equal(t, "mongo.InsertUser", seen)
+}
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+var _ repository.APIKeyRepositoryInterface = (*APIKeyRepository)(nil)
+
+type APIKeyRepository struct {
+	mu   sync.RWMutex
+	keys map[string]model.APIKey
+}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{
+		keys: map[string]model.APIKey{},
+	}
+}
+
+func (m *APIKeyRepository) InsertAPIKey(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return model.APIKey{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key.ID = uuid.NewString()
+	key.Scopes = append([]string(nil), key.Scopes...)
+	m.keys[key.ID] = key
+
+	return key, nil
+}
+
+func (m *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return model.APIKey{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, key := range m.keys {
+		if key.KeyHash == hash {
+			return key, nil
+		}
+	}
+
+	return model.APIKey{}, repository.ErrAPIKeyNotFound
+}
+
+func (m *APIKeyRepository) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]model.APIKey, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	return keys, nil
+}
+
+func (m *APIKeyRepository) RevokeAPIKey(ctx context.Context, id string, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key, ok := m.keys[id]
+	if !ok {
+		return repository.ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+		m.keys[id] = key
+	}
+
+	return nil
+}
+
+func (m *APIKeyRepository) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if key, ok := m.keys[id]; ok {
+		key.LastUsedAt = &at
+		m.keys[id] = key
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+var _ repository.CredentialRepositoryInterface = (*CredentialRepository)(nil)
+
+type CredentialRepository struct {
+	mu          sync.RWMutex
+	credentials map[string]model.Credential
+	resets      map[string]model.PasswordReset
+}
+
+func NewCredentialRepository() *CredentialRepository {
+	return &CredentialRepository{
+		credentials: map[string]model.Credential{},
+		resets:      map[string]model.PasswordReset{},
+	}
+}
+
+func (m *CredentialRepository) InsertCredential(ctx context.Context, cred model.Credential) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.credentials {
+		if c.UserID == cred.UserID || c.Email == cred.Email {
+			return repository.ErrCredentialExists
+		}
+	}
+
+	cred.FailedAttempts = 0
+	cred.LockedUntil = nil
+	m.credentials[cred.UserID] = cred
+
+	return nil
+}
+
+func (m *CredentialRepository) GetCredentialByEmail(ctx context.Context, email string) (model.Credential, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Credential{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, c := range m.credentials {
+		if c.Email == email {
+			return c, nil
+		}
+	}
+
+	return model.Credential{}, repository.ErrCredentialNotFound
+}
+
+func (m *CredentialRepository) GetCredentialByUserID(ctx context.Context, userID string) (model.Credential, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Credential{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.credentials[userID]
+	if !ok {
+		return model.Credential{}, repository.ErrCredentialNotFound
+	}
+
+	return c, nil
+}
+
+func (m *CredentialRepository) UpdatePassword(ctx context.Context, userID, hash string, at time.Time) error {
+	return m.update(ctx, userID, func(c *model.Credential) {
+		c.PasswordHash = hash
+		c.FailedAttempts = 0
+		c.LockedUntil = nil
+		c.UpdatedAt = at
+	})
+}
+
+func (m *CredentialRepository) RecordLoginFailure(ctx context.Context, userID string) (int, error) {
+	var attempts int
+	err := m.update(ctx, userID, func(c *model.Credential) {
+		c.FailedAttempts++
+		attempts = c.FailedAttempts
+	})
+
+	return attempts, err
+}
+
+func (m *CredentialRepository) LockCredential(ctx context.Context, userID string, until time.Time) error {
+	return m.update(ctx, userID, func(c *model.Credential) {
+		c.FailedAttempts = 0
+		c.LockedUntil = &until
+	})
+}
+
+func (m *CredentialRepository) ResetLoginFailures(ctx context.Context, userID string) error {
+	err := m.update(ctx, userID, func(c *model.Credential) {
+		c.FailedAttempts = 0
+		c.LockedUntil = nil
+	})
+	if err == repository.ErrCredentialNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func (m *CredentialRepository) update(ctx context.Context, userID string, fn func(c *model.Credential)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.credentials[userID]
+	if !ok {
+		return repository.ErrCredentialNotFound
+	}
+	fn(&c)
+	m.credentials[userID] = c
+
+	return nil
+}
+
+func (m *CredentialRepository) InsertPasswordReset(ctx context.Context, reset model.PasswordReset) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.resets[reset.TokenHash]; ok {
+		return repository.ErrCredentialExists
+	}
+	reset.UsedAt = nil
+	m.resets[reset.TokenHash] = reset
+
+	return nil
+}
+
+func (m *CredentialRepository) ResetPassword(ctx context.Context, tokenHash, hash string, at time.Time) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reset, ok := m.resets[tokenHash]
+	if !ok || reset.UsedAt != nil || !reset.ExpiresAt.After(at) {
+		return "", repository.ErrResetTokenInvalid
+	}
+
+	c, ok := m.credentials[reset.UserID]
+	if !ok {
+		return "", repository.ErrCredentialNotFound
+	}
+	c.PasswordHash = hash
+	c.FailedAttempts = 0
+	c.LockedUntil = nil
+	c.UpdatedAt = at
+	m.credentials[reset.UserID] = c
+
+	reset.UsedAt = &at
+	m.resets[tokenHash] = reset
+
+	return reset.UserID, nil
+}
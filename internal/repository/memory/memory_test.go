@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/repository/repotest"
+)
+
+func TestMysqlRepository_Contract(t *testing.T) {
+	repotest.RunMysqlContract(t, func(t *testing.T) repository.MysqlRepositoryInterface {
+		return NewMysqlRepository()
+	})
+}
+
+func TestMongoRepository_Contract(t *testing.T) {
+	repotest.RunMongoContract(t, func(t *testing.T) repository.MongodbRepositoryInterface {
+		return NewMongoRepository()
+	})
+}
+
+func TestAPIKeyRepository_Contract(t *testing.T) {
+	repotest.RunAPIKeyContract(t, func(t *testing.T) repository.APIKeyRepositoryInterface {
+		return NewAPIKeyRepository()
+	})
+}
+
+func TestCredentialRepository_Contract(t *testing.T) {
+	repotest.RunCredentialContract(t, func(t *testing.T) (repository.CredentialRepositoryInterface, repository.MysqlRepositoryInterface) {
+		return NewCredentialRepository(), NewMysqlRepository()
+	})
+}
+
+func TestOTPRepository_Contract(t *testing.T) {
+	repotest.RunOTPContract(t, func(t *testing.T) (repository.OTPRepositoryInterface, repository.MysqlRepositoryInterface) {
+		return NewOTPRepository(), NewMysqlRepository()
+	})
+}
+
+func TestRateLimitRepository_Contract(t *testing.T) {
+	repotest.RunRateLimitContract(t, func(t *testing.T) repository.RateLimitRepositoryInterface {
+		return NewRateLimitRepository()
+	})
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+var _ repository.MongodbRepositoryInterface = (*MongoRepository)(nil)
+
+// MongoRepository is an in-memory stand-in for the Mongo user collection.
+type MongoRepository struct {
+	mu    sync.RWMutex
+	users map[string]model.User
+}
+
+func NewMongoRepository() *MongoRepository {
+	return &MongoRepository{
+		users: map[string]model.User{},
+	}
+}
+
+// UserCount returns the number of stored users.
+func (m *MongoRepository) UserCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.users)
+}
+
+func (m *MongoRepository) InsertUser(ctx context.Context, user model.User) (model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return model.User{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if user.UserID == "" {
+		user.UserID = uuid.NewString()
+	}
+	if _, ok := m.users[user.UserID]; ok {
+		return model.User{}, repository.ErrUserExists
+	}
+	m.users[user.UserID] = user
+
+	return user, nil
+}
+
+func (m *MongoRepository) GetUser(ctx context.Context, userid string) (model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return model.User{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[userid]
+	if !ok {
+		return model.User{}, repository.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (m *MongoRepository) MarkUserVerified(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	user.Verified = true
+	m.users[userID] = user
+
+	return nil
+}
+
+func (m *MongoRepository) DeleteUser(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return repository.ErrUserNotFound
+	}
+	delete(m.users, userID)
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+var _ repository.MysqlRepositoryInterface = (*MysqlRepository)(nil)
+
+// MysqlRepository is an in-memory stand-in for the MySQL repository. It keeps
+// the same constraints as the schema: accounts must reference an existing
+// user, MSISDNs are unique among active accounts and a user holds at most
+// repository.MaxAccountsPerUser accounts.
+type MysqlRepository struct {
+	mu       sync.RWMutex
+	users    map[string]model.User
+	accounts map[string]model.Account
+}
+
+func NewMysqlRepository() *MysqlRepository {
+	return &MysqlRepository{
+		users:    map[string]model.User{},
+		accounts: map[string]model.Account{},
+	}
+}
+
+// UserCount returns the number of stored users.
+func (m *MysqlRepository) UserCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.users)
+}
+
+func (m *MysqlRepository) InsertUser(ctx context.Context, user model.User) (model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return model.User{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user.UserID = uuid.NewString()
+	m.users[user.UserID] = user
+
+	return user, nil
+}
+
+func (m *MysqlRepository) GetUserByID(ctx context.Context, userID string) (model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return model.User{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return model.User{}, repository.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (m *MysqlRepository) GetUsersByIDs(ctx context.Context, userIDs []string) ([]model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]bool, len(userIDs))
+	users := []model.User{}
+	for _, id := range userIDs {
+		if user, ok := m.users[id]; ok && !seen[id] {
+			seen[id] = true
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+
+	return users, nil
+}
+
+func (m *MysqlRepository) InsertAccount(ctx context.Context, account model.Account) (model.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Account{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var owned int
+	for _, acc := range m.accounts {
+		if acc.UserID == account.UserID {
+			owned++
+		}
+	}
+	if owned >= repository.MaxAccountsPerUser {
+		return model.Account{}, repository.ErrMsisdnLimit
+	}
+
+	if _, ok := m.users[account.UserID]; !ok {
+		return model.Account{}, repository.ErrUserNotFound
+	}
+
+	if m.msisdnActive(account.MsisdnCustomer, "") {
+		return model.Account{}, repository.ErrMsisdnTaken
+	}
+
+	if account.Status == "" {
+		account.Status = model.AccountActive
+	}
+	account.AccountID = uuid.NewString()
+	m.accounts[account.AccountID] = account
+
+	return account, nil
+}
+
+func (m *MysqlRepository) GetUserByAccountID(ctx context.Context, accountID string) (model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return model.User{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	account, ok := m.accounts[accountID]
+	if !ok || account.Status != model.AccountActive {
+		return model.User{}, repository.ErrAccountNotFound
+	}
+
+	user, ok := m.users[account.UserID]
+	if !ok {
+		return model.User{}, repository.ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (m *MysqlRepository) GetAccountByID(ctx context.Context, accountID string) (model.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Account{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	account, ok := m.accounts[accountID]
+	if !ok {
+		return model.Account{}, repository.ErrAccountNotFound
+	}
+
+	return account, nil
+}
+
+func (m *MysqlRepository) ListAccountsByUserID(ctx context.Context, userID string) ([]model.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	accounts := []model.Account{}
+	for _, account := range m.accounts {
+		if account.UserID == userID {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountID < accounts[j].AccountID })
+
+	return accounts, nil
+}
+
+func (m *MysqlRepository) ListAccountsByUserIDs(ctx context.Context, userIDs []string) ([]model.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		wanted[id] = true
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	accounts := []model.Account{}
+	for _, account := range m.accounts {
+		if wanted[account.UserID] {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].AccountID < accounts[j].AccountID })
+
+	return accounts, nil
+}
+
+func (m *MysqlRepository) ActivateAccount(ctx context.Context, accountID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	account, ok := m.accounts[accountID]
+	if !ok {
+		return repository.ErrAccountNotFound
+	}
+	if m.msisdnActive(account.MsisdnCustomer, accountID) {
+		return repository.ErrMsisdnTaken
+	}
+	account.Status = model.AccountActive
+	m.accounts[accountID] = account
+
+	return nil
+}
+
+// msisdnActive reports whether an active account other than exceptID holds
+// msisdn. Callers hold the lock.
+func (m *MysqlRepository) msisdnActive(msisdn, exceptID string) bool {
+	for id, acc := range m.accounts {
+		if id != exceptID && acc.MsisdnCustomer == msisdn && acc.Status == model.AccountActive {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MysqlRepository) DeleteAccount(ctx context.Context, accountID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accounts[accountID]; !ok {
+		return repository.ErrAccountNotFound
+	}
+	delete(m.accounts, accountID)
+
+	return nil
+}
+
+// DeleteUser also removes the user's accounts, like the foreign key does in
+// MySQL.
+func (m *MysqlRepository) DeleteUser(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return repository.ErrUserNotFound
+	}
+	delete(m.users, userID)
+	for id, acc := range m.accounts {
+		if acc.UserID == userID {
+			delete(m.accounts, id)
+		}
+	}
+
+	return nil
+}
+
+func (m *MysqlRepository) MarkUserVerified(ctx context.Context, userID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	user.Verified = true
+	m.users[userID] = user
+
+	return nil
+}
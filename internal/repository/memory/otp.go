@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+var _ repository.OTPRepositoryInterface = (*OTPRepository)(nil)
+
+// OTPRepository is an in-memory stand-in for the account_otp table. Unlike
+// the table it does not check that the account exists.
+type OTPRepository struct {
+	mu   sync.RWMutex
+	otps map[string]model.AccountOTP
+}
+
+func NewOTPRepository() *OTPRepository {
+	return &OTPRepository{
+		otps: map[string]model.AccountOTP{},
+	}
+}
+
+func (m *OTPRepository) UpsertOTP(ctx context.Context, otp model.AccountOTP) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	otp.Attempts = 0
+	m.otps[otp.AccountID] = otp
+
+	return nil
+}
+
+func (m *OTPRepository) GetOTP(ctx context.Context, accountID string) (model.AccountOTP, error) {
+	if err := ctx.Err(); err != nil {
+		return model.AccountOTP{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	otp, ok := m.otps[accountID]
+	if !ok {
+		return model.AccountOTP{}, repository.ErrOTPNotFound
+	}
+
+	return otp, nil
+}
+
+func (m *OTPRepository) RecordOTPFailure(ctx context.Context, accountID string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	otp, ok := m.otps[accountID]
+	if !ok {
+		return 0, repository.ErrOTPNotFound
+	}
+	otp.Attempts++
+	m.otps[accountID] = otp
+
+	return otp.Attempts, nil
+}
+
+func (m *OTPRepository) DeleteOTP(ctx context.Context, accountID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.otps, accountID)
+
+	return nil
+}
+
+func (m *OTPRepository) ListExpiredOTPs(ctx context.Context, before time.Time) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var expired []model.AccountOTP
+	for _, otp := range m.otps {
+		if !otp.ExpiresAt.After(before) {
+			expired = append(expired, otp)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ExpiresAt.Before(expired[j].ExpiresAt) })
+
+	ids := make([]string, 0, len(expired))
+	for _, otp := range expired {
+		ids = append(ids, otp.AccountID)
+	}
+
+	return ids, nil
+}
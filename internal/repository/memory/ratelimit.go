@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+var _ repository.RateLimitRepositoryInterface = (*RateLimitRepository)(nil)
+
+// RateLimitRepository is an in-memory stand-in for the rate_limit_bucket
+// table. It is also the store of the "memory" rate limit backend, which keeps
+// buckets in this process only.
+type RateLimitRepository struct {
+	mu      sync.Mutex
+	buckets map[string]model.RateLimitBucket
+}
+
+func NewRateLimitRepository() *RateLimitRepository {
+	return &RateLimitRepository{
+		buckets: map[string]model.RateLimitBucket{},
+	}
+}
+
+func (m *RateLimitRepository) UpdateBucket(ctx context.Context, key string, update func(model.RateLimitBucket) model.RateLimitBucket) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bucket, ok := m.buckets[key]
+	if !ok {
+		bucket = model.RateLimitBucket{Key: key}
+	}
+
+	bucket = update(bucket)
+	bucket.Key = key
+	m.buckets[key] = bucket
+
+	return nil
+}
+
+func (m *RateLimitRepository) DeleteBucketsBefore(ctx context.Context, before time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int
+	for key, bucket := range m.buckets {
+		if bucket.UpdatedAt.Before(before) {
+			delete(m.buckets, key)
+			n++
+		}
+	}
+
+	return n, nil
+}
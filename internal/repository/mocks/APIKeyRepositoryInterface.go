@@ -0,0 +1,307 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	model "github.com/vier21/tefa-ch3/internal/model"
+
+	time "time"
+)
+
+// APIKeyRepositoryInterface is an autogenerated mock type for the APIKeyRepositoryInterface type
+type APIKeyRepositoryInterface struct {
+	mock.Mock
+}
+
+type APIKeyRepositoryInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *APIKeyRepositoryInterface) EXPECT() *APIKeyRepositoryInterface_Expecter {
+	return &APIKeyRepositoryInterface_Expecter{mock: &_m.Mock}
+}
+
+// GetAPIKeyByHash provides a mock function with given fields: ctx, hash
+func (_m *APIKeyRepositoryInterface) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	ret := _m.Called(ctx, hash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKeyByHash")
+	}
+
+	var r0 model.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.APIKey, error)); ok {
+		return rf(ctx, hash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.APIKey); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		r0 = ret.Get(0).(model.APIKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// APIKeyRepositoryInterface_GetAPIKeyByHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAPIKeyByHash'
+type APIKeyRepositoryInterface_GetAPIKeyByHash_Call struct {
+	*mock.Call
+}
+
+// GetAPIKeyByHash is a helper method to define mock.On call
+//   - ctx context.Context
+//   - hash string
+func (_e *APIKeyRepositoryInterface_Expecter) GetAPIKeyByHash(ctx interface{}, hash interface{}) *APIKeyRepositoryInterface_GetAPIKeyByHash_Call {
+	return &APIKeyRepositoryInterface_GetAPIKeyByHash_Call{Call: _e.mock.On("GetAPIKeyByHash", ctx, hash)}
+}
+
+func (_c *APIKeyRepositoryInterface_GetAPIKeyByHash_Call) Run(run func(ctx context.Context, hash string)) *APIKeyRepositoryInterface_GetAPIKeyByHash_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *APIKeyRepositoryInterface_GetAPIKeyByHash_Call) Return(_a0 model.APIKey, _a1 error) *APIKeyRepositoryInterface_GetAPIKeyByHash_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *APIKeyRepositoryInterface_GetAPIKeyByHash_Call) RunAndReturn(run func(context.Context, string) (model.APIKey, error)) *APIKeyRepositoryInterface_GetAPIKeyByHash_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InsertAPIKey provides a mock function with given fields: ctx, key
+func (_m *APIKeyRepositoryInterface) InsertAPIKey(ctx context.Context, key model.APIKey) (model.APIKey, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertAPIKey")
+	}
+
+	var r0 model.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.APIKey) (model.APIKey, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.APIKey) model.APIKey); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Get(0).(model.APIKey)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.APIKey) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// APIKeyRepositoryInterface_InsertAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InsertAPIKey'
+type APIKeyRepositoryInterface_InsertAPIKey_Call struct {
+	*mock.Call
+}
+
+// InsertAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key model.APIKey
+func (_e *APIKeyRepositoryInterface_Expecter) InsertAPIKey(ctx interface{}, key interface{}) *APIKeyRepositoryInterface_InsertAPIKey_Call {
+	return &APIKeyRepositoryInterface_InsertAPIKey_Call{Call: _e.mock.On("InsertAPIKey", ctx, key)}
+}
+
+func (_c *APIKeyRepositoryInterface_InsertAPIKey_Call) Run(run func(ctx context.Context, key model.APIKey)) *APIKeyRepositoryInterface_InsertAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.APIKey))
+	})
+	return _c
+}
+
+func (_c *APIKeyRepositoryInterface_InsertAPIKey_Call) Return(_a0 model.APIKey, _a1 error) *APIKeyRepositoryInterface_InsertAPIKey_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *APIKeyRepositoryInterface_InsertAPIKey_Call) RunAndReturn(run func(context.Context, model.APIKey) (model.APIKey, error)) *APIKeyRepositoryInterface_InsertAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAPIKeys provides a mock function with given fields: ctx
+func (_m *APIKeyRepositoryInterface) ListAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 []model.APIKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]model.APIKey, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []model.APIKey); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.APIKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// APIKeyRepositoryInterface_ListAPIKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAPIKeys'
+type APIKeyRepositoryInterface_ListAPIKeys_Call struct {
+	*mock.Call
+}
+
+// ListAPIKeys is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *APIKeyRepositoryInterface_Expecter) ListAPIKeys(ctx interface{}) *APIKeyRepositoryInterface_ListAPIKeys_Call {
+	return &APIKeyRepositoryInterface_ListAPIKeys_Call{Call: _e.mock.On("ListAPIKeys", ctx)}
+}
+
+func (_c *APIKeyRepositoryInterface_ListAPIKeys_Call) Run(run func(ctx context.Context)) *APIKeyRepositoryInterface_ListAPIKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *APIKeyRepositoryInterface_ListAPIKeys_Call) Return(_a0 []model.APIKey, _a1 error) *APIKeyRepositoryInterface_ListAPIKeys_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *APIKeyRepositoryInterface_ListAPIKeys_Call) RunAndReturn(run func(context.Context) ([]model.APIKey, error)) *APIKeyRepositoryInterface_ListAPIKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeAPIKey provides a mock function with given fields: ctx, id, at
+func (_m *APIKeyRepositoryInterface) RevokeAPIKey(ctx context.Context, id string, at time.Time) error {
+	ret := _m.Called(ctx, id, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// APIKeyRepositoryInterface_RevokeAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeAPIKey'
+type APIKeyRepositoryInterface_RevokeAPIKey_Call struct {
+	*mock.Call
+}
+
+// RevokeAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+//   - at time.Time
+func (_e *APIKeyRepositoryInterface_Expecter) RevokeAPIKey(ctx interface{}, id interface{}, at interface{}) *APIKeyRepositoryInterface_RevokeAPIKey_Call {
+	return &APIKeyRepositoryInterface_RevokeAPIKey_Call{Call: _e.mock.On("RevokeAPIKey", ctx, id, at)}
+}
+
+func (_c *APIKeyRepositoryInterface_RevokeAPIKey_Call) Run(run func(ctx context.Context, id string, at time.Time)) *APIKeyRepositoryInterface_RevokeAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *APIKeyRepositoryInterface_RevokeAPIKey_Call) Return(_a0 error) *APIKeyRepositoryInterface_RevokeAPIKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *APIKeyRepositoryInterface_RevokeAPIKey_Call) RunAndReturn(run func(context.Context, string, time.Time) error) *APIKeyRepositoryInterface_RevokeAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TouchAPIKey provides a mock function with given fields: ctx, id, at
+func (_m *APIKeyRepositoryInterface) TouchAPIKey(ctx context.Context, id string, at time.Time) error {
+	ret := _m.Called(ctx, id, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TouchAPIKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// APIKeyRepositoryInterface_TouchAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TouchAPIKey'
+type APIKeyRepositoryInterface_TouchAPIKey_Call struct {
+	*mock.Call
+}
+
+// TouchAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+//   - at time.Time
+func (_e *APIKeyRepositoryInterface_Expecter) TouchAPIKey(ctx interface{}, id interface{}, at interface{}) *APIKeyRepositoryInterface_TouchAPIKey_Call {
+	return &APIKeyRepositoryInterface_TouchAPIKey_Call{Call: _e.mock.On("TouchAPIKey", ctx, id, at)}
+}
+
+func (_c *APIKeyRepositoryInterface_TouchAPIKey_Call) Run(run func(ctx context.Context, id string, at time.Time)) *APIKeyRepositoryInterface_TouchAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *APIKeyRepositoryInterface_TouchAPIKey_Call) Return(_a0 error) *APIKeyRepositoryInterface_TouchAPIKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *APIKeyRepositoryInterface_TouchAPIKey_Call) RunAndReturn(run func(context.Context, string, time.Time) error) *APIKeyRepositoryInterface_TouchAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewAPIKeyRepositoryInterface creates a new instance of APIKeyRepositoryInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAPIKeyRepositoryInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *APIKeyRepositoryInterface {
+	mock := &APIKeyRepositoryInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
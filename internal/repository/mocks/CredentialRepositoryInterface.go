@@ -0,0 +1,507 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	model "github.com/vier21/tefa-ch3/internal/model"
+
+	time "time"
+)
+
+// CredentialRepositoryInterface is an autogenerated mock type for the CredentialRepositoryInterface type
+type CredentialRepositoryInterface struct {
+	mock.Mock
+}
+
+type CredentialRepositoryInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *CredentialRepositoryInterface) EXPECT() *CredentialRepositoryInterface_Expecter {
+	return &CredentialRepositoryInterface_Expecter{mock: &_m.Mock}
+}
+
+// GetCredentialByEmail provides a mock function with given fields: ctx, email
+func (_m *CredentialRepositoryInterface) GetCredentialByEmail(ctx context.Context, email string) (model.Credential, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCredentialByEmail")
+	}
+
+	var r0 model.Credential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.Credential, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.Credential); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Get(0).(model.Credential)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CredentialRepositoryInterface_GetCredentialByEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCredentialByEmail'
+type CredentialRepositoryInterface_GetCredentialByEmail_Call struct {
+	*mock.Call
+}
+
+// GetCredentialByEmail is a helper method to define mock.On call
+//   - ctx context.Context
+//   - email string
+func (_e *CredentialRepositoryInterface_Expecter) GetCredentialByEmail(ctx interface{}, email interface{}) *CredentialRepositoryInterface_GetCredentialByEmail_Call {
+	return &CredentialRepositoryInterface_GetCredentialByEmail_Call{Call: _e.mock.On("GetCredentialByEmail", ctx, email)}
+}
+
+func (_c *CredentialRepositoryInterface_GetCredentialByEmail_Call) Run(run func(ctx context.Context, email string)) *CredentialRepositoryInterface_GetCredentialByEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_GetCredentialByEmail_Call) Return(_a0 model.Credential, _a1 error) *CredentialRepositoryInterface_GetCredentialByEmail_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_GetCredentialByEmail_Call) RunAndReturn(run func(context.Context, string) (model.Credential, error)) *CredentialRepositoryInterface_GetCredentialByEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCredentialByUserID provides a mock function with given fields: ctx, userID
+func (_m *CredentialRepositoryInterface) GetCredentialByUserID(ctx context.Context, userID string) (model.Credential, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCredentialByUserID")
+	}
+
+	var r0 model.Credential
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.Credential, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.Credential); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(model.Credential)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CredentialRepositoryInterface_GetCredentialByUserID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCredentialByUserID'
+type CredentialRepositoryInterface_GetCredentialByUserID_Call struct {
+	*mock.Call
+}
+
+// GetCredentialByUserID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *CredentialRepositoryInterface_Expecter) GetCredentialByUserID(ctx interface{}, userID interface{}) *CredentialRepositoryInterface_GetCredentialByUserID_Call {
+	return &CredentialRepositoryInterface_GetCredentialByUserID_Call{Call: _e.mock.On("GetCredentialByUserID", ctx, userID)}
+}
+
+func (_c *CredentialRepositoryInterface_GetCredentialByUserID_Call) Run(run func(ctx context.Context, userID string)) *CredentialRepositoryInterface_GetCredentialByUserID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_GetCredentialByUserID_Call) Return(_a0 model.Credential, _a1 error) *CredentialRepositoryInterface_GetCredentialByUserID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_GetCredentialByUserID_Call) RunAndReturn(run func(context.Context, string) (model.Credential, error)) *CredentialRepositoryInterface_GetCredentialByUserID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InsertCredential provides a mock function with given fields: ctx, cred
+func (_m *CredentialRepositoryInterface) InsertCredential(ctx context.Context, cred model.Credential) error {
+	ret := _m.Called(ctx, cred)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertCredential")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Credential) error); ok {
+		r0 = rf(ctx, cred)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CredentialRepositoryInterface_InsertCredential_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InsertCredential'
+type CredentialRepositoryInterface_InsertCredential_Call struct {
+	*mock.Call
+}
+
+// InsertCredential is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cred model.Credential
+func (_e *CredentialRepositoryInterface_Expecter) InsertCredential(ctx interface{}, cred interface{}) *CredentialRepositoryInterface_InsertCredential_Call {
+	return &CredentialRepositoryInterface_InsertCredential_Call{Call: _e.mock.On("InsertCredential", ctx, cred)}
+}
+
+func (_c *CredentialRepositoryInterface_InsertCredential_Call) Run(run func(ctx context.Context, cred model.Credential)) *CredentialRepositoryInterface_InsertCredential_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.Credential))
+	})
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_InsertCredential_Call) Return(_a0 error) *CredentialRepositoryInterface_InsertCredential_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_InsertCredential_Call) RunAndReturn(run func(context.Context, model.Credential) error) *CredentialRepositoryInterface_InsertCredential_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InsertPasswordReset provides a mock function with given fields: ctx, reset
+func (_m *CredentialRepositoryInterface) InsertPasswordReset(ctx context.Context, reset model.PasswordReset) error {
+	ret := _m.Called(ctx, reset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertPasswordReset")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.PasswordReset) error); ok {
+		r0 = rf(ctx, reset)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CredentialRepositoryInterface_InsertPasswordReset_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InsertPasswordReset'
+type CredentialRepositoryInterface_InsertPasswordReset_Call struct {
+	*mock.Call
+}
+
+// InsertPasswordReset is a helper method to define mock.On call
+//   - ctx context.Context
+//   - reset model.PasswordReset
+func (_e *CredentialRepositoryInterface_Expecter) InsertPasswordReset(ctx interface{}, reset interface{}) *CredentialRepositoryInterface_InsertPasswordReset_Call {
+	return &CredentialRepositoryInterface_InsertPasswordReset_Call{Call: _e.mock.On("InsertPasswordReset", ctx, reset)}
+}
+
+func (_c *CredentialRepositoryInterface_InsertPasswordReset_Call) Run(run func(ctx context.Context, reset model.PasswordReset)) *CredentialRepositoryInterface_InsertPasswordReset_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.PasswordReset))
+	})
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_InsertPasswordReset_Call) Return(_a0 error) *CredentialRepositoryInterface_InsertPasswordReset_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_InsertPasswordReset_Call) RunAndReturn(run func(context.Context, model.PasswordReset) error) *CredentialRepositoryInterface_InsertPasswordReset_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LockCredential provides a mock function with given fields: ctx, userID, until
+func (_m *CredentialRepositoryInterface) LockCredential(ctx context.Context, userID string, until time.Time) error {
+	ret := _m.Called(ctx, userID, until)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LockCredential")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, userID, until)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CredentialRepositoryInterface_LockCredential_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LockCredential'
+type CredentialRepositoryInterface_LockCredential_Call struct {
+	*mock.Call
+}
+
+// LockCredential is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - until time.Time
+func (_e *CredentialRepositoryInterface_Expecter) LockCredential(ctx interface{}, userID interface{}, until interface{}) *CredentialRepositoryInterface_LockCredential_Call {
+	return &CredentialRepositoryInterface_LockCredential_Call{Call: _e.mock.On("LockCredential", ctx, userID, until)}
+}
+
+func (_c *CredentialRepositoryInterface_LockCredential_Call) Run(run func(ctx context.Context, userID string, until time.Time)) *CredentialRepositoryInterface_LockCredential_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_LockCredential_Call) Return(_a0 error) *CredentialRepositoryInterface_LockCredential_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_LockCredential_Call) RunAndReturn(run func(context.Context, string, time.Time) error) *CredentialRepositoryInterface_LockCredential_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordLoginFailure provides a mock function with given fields: ctx, userID
+func (_m *CredentialRepositoryInterface) RecordLoginFailure(ctx context.Context, userID string) (int, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordLoginFailure")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CredentialRepositoryInterface_RecordLoginFailure_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordLoginFailure'
+type CredentialRepositoryInterface_RecordLoginFailure_Call struct {
+	*mock.Call
+}
+
+// RecordLoginFailure is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *CredentialRepositoryInterface_Expecter) RecordLoginFailure(ctx interface{}, userID interface{}) *CredentialRepositoryInterface_RecordLoginFailure_Call {
+	return &CredentialRepositoryInterface_RecordLoginFailure_Call{Call: _e.mock.On("RecordLoginFailure", ctx, userID)}
+}
+
+func (_c *CredentialRepositoryInterface_RecordLoginFailure_Call) Run(run func(ctx context.Context, userID string)) *CredentialRepositoryInterface_RecordLoginFailure_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_RecordLoginFailure_Call) Return(_a0 int, _a1 error) *CredentialRepositoryInterface_RecordLoginFailure_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_RecordLoginFailure_Call) RunAndReturn(run func(context.Context, string) (int, error)) *CredentialRepositoryInterface_RecordLoginFailure_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResetLoginFailures provides a mock function with given fields: ctx, userID
+func (_m *CredentialRepositoryInterface) ResetLoginFailures(ctx context.Context, userID string) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetLoginFailures")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CredentialRepositoryInterface_ResetLoginFailures_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetLoginFailures'
+type CredentialRepositoryInterface_ResetLoginFailures_Call struct {
+	*mock.Call
+}
+
+// ResetLoginFailures is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *CredentialRepositoryInterface_Expecter) ResetLoginFailures(ctx interface{}, userID interface{}) *CredentialRepositoryInterface_ResetLoginFailures_Call {
+	return &CredentialRepositoryInterface_ResetLoginFailures_Call{Call: _e.mock.On("ResetLoginFailures", ctx, userID)}
+}
+
+func (_c *CredentialRepositoryInterface_ResetLoginFailures_Call) Run(run func(ctx context.Context, userID string)) *CredentialRepositoryInterface_ResetLoginFailures_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_ResetLoginFailures_Call) Return(_a0 error) *CredentialRepositoryInterface_ResetLoginFailures_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_ResetLoginFailures_Call) RunAndReturn(run func(context.Context, string) error) *CredentialRepositoryInterface_ResetLoginFailures_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResetPassword provides a mock function with given fields: ctx, tokenHash, hash, at
+func (_m *CredentialRepositoryInterface) ResetPassword(ctx context.Context, tokenHash string, hash string, at time.Time) (string, error) {
+	ret := _m.Called(ctx, tokenHash, hash, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) (string, error)); ok {
+		return rf(ctx, tokenHash, hash, at)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) string); ok {
+		r0 = rf(ctx, tokenHash, hash, at)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
+		r1 = rf(ctx, tokenHash, hash, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CredentialRepositoryInterface_ResetPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResetPassword'
+type CredentialRepositoryInterface_ResetPassword_Call struct {
+	*mock.Call
+}
+
+// ResetPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tokenHash string
+//   - hash string
+//   - at time.Time
+func (_e *CredentialRepositoryInterface_Expecter) ResetPassword(ctx interface{}, tokenHash interface{}, hash interface{}, at interface{}) *CredentialRepositoryInterface_ResetPassword_Call {
+	return &CredentialRepositoryInterface_ResetPassword_Call{Call: _e.mock.On("ResetPassword", ctx, tokenHash, hash, at)}
+}
+
+func (_c *CredentialRepositoryInterface_ResetPassword_Call) Run(run func(ctx context.Context, tokenHash string, hash string, at time.Time)) *CredentialRepositoryInterface_ResetPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_ResetPassword_Call) Return(_a0 string, _a1 error) *CredentialRepositoryInterface_ResetPassword_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_ResetPassword_Call) RunAndReturn(run func(context.Context, string, string, time.Time) (string, error)) *CredentialRepositoryInterface_ResetPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdatePassword provides a mock function with given fields: ctx, userID, hash, at
+func (_m *CredentialRepositoryInterface) UpdatePassword(ctx context.Context, userID string, hash string, at time.Time) error {
+	ret := _m.Called(ctx, userID, hash, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) error); ok {
+		r0 = rf(ctx, userID, hash, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CredentialRepositoryInterface_UpdatePassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdatePassword'
+type CredentialRepositoryInterface_UpdatePassword_Call struct {
+	*mock.Call
+}
+
+// UpdatePassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+//   - hash string
+//   - at time.Time
+func (_e *CredentialRepositoryInterface_Expecter) UpdatePassword(ctx interface{}, userID interface{}, hash interface{}, at interface{}) *CredentialRepositoryInterface_UpdatePassword_Call {
+	return &CredentialRepositoryInterface_UpdatePassword_Call{Call: _e.mock.On("UpdatePassword", ctx, userID, hash, at)}
+}
+
+func (_c *CredentialRepositoryInterface_UpdatePassword_Call) Run(run func(ctx context.Context, userID string, hash string, at time.Time)) *CredentialRepositoryInterface_UpdatePassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_UpdatePassword_Call) Return(_a0 error) *CredentialRepositoryInterface_UpdatePassword_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CredentialRepositoryInterface_UpdatePassword_Call) RunAndReturn(run func(context.Context, string, string, time.Time) error) *CredentialRepositoryInterface_UpdatePassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewCredentialRepositoryInterface creates a new instance of CredentialRepositoryInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCredentialRepositoryInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CredentialRepositoryInterface {
+	mock := &CredentialRepositoryInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
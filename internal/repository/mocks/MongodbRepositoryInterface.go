@@ -0,0 +1,245 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	model "github.com/vier21/tefa-ch3/internal/model"
+)
+
+// MongodbRepositoryInterface is an autogenerated mock type for the MongodbRepositoryInterface type
+type MongodbRepositoryInterface struct {
+	mock.Mock
+}
+
+type MongodbRepositoryInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MongodbRepositoryInterface) EXPECT() *MongodbRepositoryInterface_Expecter {
+	return &MongodbRepositoryInterface_Expecter{mock: &_m.Mock}
+}
+
+// DeleteUser provides a mock function with given fields: ctx, userid
+func (_m *MongodbRepositoryInterface) DeleteUser(ctx context.Context, userid string) error {
+	ret := _m.Called(ctx, userid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userid)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MongodbRepositoryInterface_DeleteUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteUser'
+type MongodbRepositoryInterface_DeleteUser_Call struct {
+	*mock.Call
+}
+
+// DeleteUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userid string
+func (_e *MongodbRepositoryInterface_Expecter) DeleteUser(ctx interface{}, userid interface{}) *MongodbRepositoryInterface_DeleteUser_Call {
+	return &MongodbRepositoryInterface_DeleteUser_Call{Call: _e.mock.On("DeleteUser", ctx, userid)}
+}
+
+func (_c *MongodbRepositoryInterface_DeleteUser_Call) Run(run func(ctx context.Context, userid string)) *MongodbRepositoryInterface_DeleteUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MongodbRepositoryInterface_DeleteUser_Call) Return(_a0 error) *MongodbRepositoryInterface_DeleteUser_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MongodbRepositoryInterface_DeleteUser_Call) RunAndReturn(run func(context.Context, string) error) *MongodbRepositoryInterface_DeleteUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUser provides a mock function with given fields: ctx, userid
+func (_m *MongodbRepositoryInterface) GetUser(ctx context.Context, userid string) (model.User, error) {
+	ret := _m.Called(ctx, userid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUser")
+	}
+
+	var r0 model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.User, error)); ok {
+		return rf(ctx, userid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.User); ok {
+		r0 = rf(ctx, userid)
+	} else {
+		r0 = ret.Get(0).(model.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MongodbRepositoryInterface_GetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUser'
+type MongodbRepositoryInterface_GetUser_Call struct {
+	*mock.Call
+}
+
+// GetUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userid string
+func (_e *MongodbRepositoryInterface_Expecter) GetUser(ctx interface{}, userid interface{}) *MongodbRepositoryInterface_GetUser_Call {
+	return &MongodbRepositoryInterface_GetUser_Call{Call: _e.mock.On("GetUser", ctx, userid)}
+}
+
+func (_c *MongodbRepositoryInterface_GetUser_Call) Run(run func(ctx context.Context, userid string)) *MongodbRepositoryInterface_GetUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MongodbRepositoryInterface_GetUser_Call) Return(_a0 model.User, _a1 error) *MongodbRepositoryInterface_GetUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MongodbRepositoryInterface_GetUser_Call) RunAndReturn(run func(context.Context, string) (model.User, error)) *MongodbRepositoryInterface_GetUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InsertUser provides a mock function with given fields: ctx, user
+func (_m *MongodbRepositoryInterface) InsertUser(ctx context.Context, user model.User) (model.User, error) {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertUser")
+	}
+
+	var r0 model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.User) (model.User, error)); ok {
+		return rf(ctx, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.User) model.User); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Get(0).(model.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.User) error); ok {
+		r1 = rf(ctx, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MongodbRepositoryInterface_InsertUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InsertUser'
+type MongodbRepositoryInterface_InsertUser_Call struct {
+	*mock.Call
+}
+
+// InsertUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - user model.User
+func (_e *MongodbRepositoryInterface_Expecter) InsertUser(ctx interface{}, user interface{}) *MongodbRepositoryInterface_InsertUser_Call {
+	return &MongodbRepositoryInterface_InsertUser_Call{Call: _e.mock.On("InsertUser", ctx, user)}
+}
+
+func (_c *MongodbRepositoryInterface_InsertUser_Call) Run(run func(ctx context.Context, user model.User)) *MongodbRepositoryInterface_InsertUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.User))
+	})
+	return _c
+}
+
+func (_c *MongodbRepositoryInterface_InsertUser_Call) Return(_a0 model.User, _a1 error) *MongodbRepositoryInterface_InsertUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MongodbRepositoryInterface_InsertUser_Call) RunAndReturn(run func(context.Context, model.User) (model.User, error)) *MongodbRepositoryInterface_InsertUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkUserVerified provides a mock function with given fields: ctx, userid
+func (_m *MongodbRepositoryInterface) MarkUserVerified(ctx context.Context, userid string) error {
+	ret := _m.Called(ctx, userid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkUserVerified")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userid)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MongodbRepositoryInterface_MarkUserVerified_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkUserVerified'
+type MongodbRepositoryInterface_MarkUserVerified_Call struct {
+	*mock.Call
+}
+
+// MarkUserVerified is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userid string
+func (_e *MongodbRepositoryInterface_Expecter) MarkUserVerified(ctx interface{}, userid interface{}) *MongodbRepositoryInterface_MarkUserVerified_Call {
+	return &MongodbRepositoryInterface_MarkUserVerified_Call{Call: _e.mock.On("MarkUserVerified", ctx, userid)}
+}
+
+func (_c *MongodbRepositoryInterface_MarkUserVerified_Call) Run(run func(ctx context.Context, userid string)) *MongodbRepositoryInterface_MarkUserVerified_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MongodbRepositoryInterface_MarkUserVerified_Call) Return(_a0 error) *MongodbRepositoryInterface_MarkUserVerified_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MongodbRepositoryInterface_MarkUserVerified_Call) RunAndReturn(run func(context.Context, string) error) *MongodbRepositoryInterface_MarkUserVerified_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMongodbRepositoryInterface creates a new instance of MongodbRepositoryInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMongodbRepositoryInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MongodbRepositoryInterface {
+	mock := &MongodbRepositoryInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
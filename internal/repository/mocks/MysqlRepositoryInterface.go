@@ -0,0 +1,687 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	model "github.com/vier21/tefa-ch3/internal/model"
+)
+
+// MysqlRepositoryInterface is an autogenerated mock type for the MysqlRepositoryInterface type
+type MysqlRepositoryInterface struct {
+	mock.Mock
+}
+
+type MysqlRepositoryInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MysqlRepositoryInterface) EXPECT() *MysqlRepositoryInterface_Expecter {
+	return &MysqlRepositoryInterface_Expecter{mock: &_m.Mock}
+}
+
+// ActivateAccount provides a mock function with given fields: ctx, accountID
+func (_m *MysqlRepositoryInterface) ActivateAccount(ctx context.Context, accountID string) error {
+	ret := _m.Called(ctx, accountID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ActivateAccount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MysqlRepositoryInterface_ActivateAccount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ActivateAccount'
+type MysqlRepositoryInterface_ActivateAccount_Call struct {
+	*mock.Call
+}
+
+// ActivateAccount is a helper method to define mock.On call
+//   - ctx context.Context
+//   - accountID string
+func (_e *MysqlRepositoryInterface_Expecter) ActivateAccount(ctx interface{}, accountID interface{}) *MysqlRepositoryInterface_ActivateAccount_Call {
+	return &MysqlRepositoryInterface_ActivateAccount_Call{Call: _e.mock.On("ActivateAccount", ctx, accountID)}
+}
+
+func (_c *MysqlRepositoryInterface_ActivateAccount_Call) Run(run func(ctx context.Context, accountID string)) *MysqlRepositoryInterface_ActivateAccount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_ActivateAccount_Call) Return(_a0 error) *MysqlRepositoryInterface_ActivateAccount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_ActivateAccount_Call) RunAndReturn(run func(context.Context, string) error) *MysqlRepositoryInterface_ActivateAccount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteAccount provides a mock function with given fields: ctx, accountID
+func (_m *MysqlRepositoryInterface) DeleteAccount(ctx context.Context, accountID string) error {
+	ret := _m.Called(ctx, accountID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAccount")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MysqlRepositoryInterface_DeleteAccount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteAccount'
+type MysqlRepositoryInterface_DeleteAccount_Call struct {
+	*mock.Call
+}
+
+// DeleteAccount is a helper method to define mock.On call
+//   - ctx context.Context
+//   - accountID string
+func (_e *MysqlRepositoryInterface_Expecter) DeleteAccount(ctx interface{}, accountID interface{}) *MysqlRepositoryInterface_DeleteAccount_Call {
+	return &MysqlRepositoryInterface_DeleteAccount_Call{Call: _e.mock.On("DeleteAccount", ctx, accountID)}
+}
+
+func (_c *MysqlRepositoryInterface_DeleteAccount_Call) Run(run func(ctx context.Context, accountID string)) *MysqlRepositoryInterface_DeleteAccount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_DeleteAccount_Call) Return(_a0 error) *MysqlRepositoryInterface_DeleteAccount_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_DeleteAccount_Call) RunAndReturn(run func(context.Context, string) error) *MysqlRepositoryInterface_DeleteAccount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteUser provides a mock function with given fields: ctx, userID
+func (_m *MysqlRepositoryInterface) DeleteUser(ctx context.Context, userID string) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MysqlRepositoryInterface_DeleteUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteUser'
+type MysqlRepositoryInterface_DeleteUser_Call struct {
+	*mock.Call
+}
+
+// DeleteUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *MysqlRepositoryInterface_Expecter) DeleteUser(ctx interface{}, userID interface{}) *MysqlRepositoryInterface_DeleteUser_Call {
+	return &MysqlRepositoryInterface_DeleteUser_Call{Call: _e.mock.On("DeleteUser", ctx, userID)}
+}
+
+func (_c *MysqlRepositoryInterface_DeleteUser_Call) Run(run func(ctx context.Context, userID string)) *MysqlRepositoryInterface_DeleteUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_DeleteUser_Call) Return(_a0 error) *MysqlRepositoryInterface_DeleteUser_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_DeleteUser_Call) RunAndReturn(run func(context.Context, string) error) *MysqlRepositoryInterface_DeleteUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAccountByID provides a mock function with given fields: ctx, accountID
+func (_m *MysqlRepositoryInterface) GetAccountByID(ctx context.Context, accountID string) (model.Account, error) {
+	ret := _m.Called(ctx, accountID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAccountByID")
+	}
+
+	var r0 model.Account
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.Account, error)); ok {
+		return rf(ctx, accountID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.Account); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Get(0).(model.Account)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MysqlRepositoryInterface_GetAccountByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAccountByID'
+type MysqlRepositoryInterface_GetAccountByID_Call struct {
+	*mock.Call
+}
+
+// GetAccountByID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - accountID string
+func (_e *MysqlRepositoryInterface_Expecter) GetAccountByID(ctx interface{}, accountID interface{}) *MysqlRepositoryInterface_GetAccountByID_Call {
+	return &MysqlRepositoryInterface_GetAccountByID_Call{Call: _e.mock.On("GetAccountByID", ctx, accountID)}
+}
+
+func (_c *MysqlRepositoryInterface_GetAccountByID_Call) Run(run func(ctx context.Context, accountID string)) *MysqlRepositoryInterface_GetAccountByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_GetAccountByID_Call) Return(_a0 model.Account, _a1 error) *MysqlRepositoryInterface_GetAccountByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_GetAccountByID_Call) RunAndReturn(run func(context.Context, string) (model.Account, error)) *MysqlRepositoryInterface_GetAccountByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByAccountID provides a mock function with given fields: ctx, accountID
+func (_m *MysqlRepositoryInterface) GetUserByAccountID(ctx context.Context, accountID string) (model.User, error) {
+	ret := _m.Called(ctx, accountID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByAccountID")
+	}
+
+	var r0 model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.User, error)); ok {
+		return rf(ctx, accountID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.User); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Get(0).(model.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MysqlRepositoryInterface_GetUserByAccountID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByAccountID'
+type MysqlRepositoryInterface_GetUserByAccountID_Call struct {
+	*mock.Call
+}
+
+// GetUserByAccountID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - accountID string
+func (_e *MysqlRepositoryInterface_Expecter) GetUserByAccountID(ctx interface{}, accountID interface{}) *MysqlRepositoryInterface_GetUserByAccountID_Call {
+	return &MysqlRepositoryInterface_GetUserByAccountID_Call{Call: _e.mock.On("GetUserByAccountID", ctx, accountID)}
+}
+
+func (_c *MysqlRepositoryInterface_GetUserByAccountID_Call) Run(run func(ctx context.Context, accountID string)) *MysqlRepositoryInterface_GetUserByAccountID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_GetUserByAccountID_Call) Return(_a0 model.User, _a1 error) *MysqlRepositoryInterface_GetUserByAccountID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_GetUserByAccountID_Call) RunAndReturn(run func(context.Context, string) (model.User, error)) *MysqlRepositoryInterface_GetUserByAccountID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUserByID provides a mock function with given fields: ctx, userID
+func (_m *MysqlRepositoryInterface) GetUserByID(ctx context.Context, userID string) (model.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(model.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MysqlRepositoryInterface_GetUserByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUserByID'
+type MysqlRepositoryInterface_GetUserByID_Call struct {
+	*mock.Call
+}
+
+// GetUserByID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *MysqlRepositoryInterface_Expecter) GetUserByID(ctx interface{}, userID interface{}) *MysqlRepositoryInterface_GetUserByID_Call {
+	return &MysqlRepositoryInterface_GetUserByID_Call{Call: _e.mock.On("GetUserByID", ctx, userID)}
+}
+
+func (_c *MysqlRepositoryInterface_GetUserByID_Call) Run(run func(ctx context.Context, userID string)) *MysqlRepositoryInterface_GetUserByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_GetUserByID_Call) Return(_a0 model.User, _a1 error) *MysqlRepositoryInterface_GetUserByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_GetUserByID_Call) RunAndReturn(run func(context.Context, string) (model.User, error)) *MysqlRepositoryInterface_GetUserByID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUsersByIDs provides a mock function with given fields: ctx, userIDs
+func (_m *MysqlRepositoryInterface) GetUsersByIDs(ctx context.Context, userIDs []string) ([]model.User, error) {
+	ret := _m.Called(ctx, userIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUsersByIDs")
+	}
+
+	var r0 []model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]model.User, error)); ok {
+		return rf(ctx, userIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []model.User); ok {
+		r0 = rf(ctx, userIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, userIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MysqlRepositoryInterface_GetUsersByIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUsersByIDs'
+type MysqlRepositoryInterface_GetUsersByIDs_Call struct {
+	*mock.Call
+}
+
+// GetUsersByIDs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userIDs []string
+func (_e *MysqlRepositoryInterface_Expecter) GetUsersByIDs(ctx interface{}, userIDs interface{}) *MysqlRepositoryInterface_GetUsersByIDs_Call {
+	return &MysqlRepositoryInterface_GetUsersByIDs_Call{Call: _e.mock.On("GetUsersByIDs", ctx, userIDs)}
+}
+
+func (_c *MysqlRepositoryInterface_GetUsersByIDs_Call) Run(run func(ctx context.Context, userIDs []string)) *MysqlRepositoryInterface_GetUsersByIDs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_GetUsersByIDs_Call) Return(_a0 []model.User, _a1 error) *MysqlRepositoryInterface_GetUsersByIDs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_GetUsersByIDs_Call) RunAndReturn(run func(context.Context, []string) ([]model.User, error)) *MysqlRepositoryInterface_GetUsersByIDs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InsertAccount provides a mock function with given fields: ctx, account
+func (_m *MysqlRepositoryInterface) InsertAccount(ctx context.Context, account model.Account) (model.Account, error) {
+	ret := _m.Called(ctx, account)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertAccount")
+	}
+
+	var r0 model.Account
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.Account) (model.Account, error)); ok {
+		return rf(ctx, account)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.Account) model.Account); ok {
+		r0 = rf(ctx, account)
+	} else {
+		r0 = ret.Get(0).(model.Account)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.Account) error); ok {
+		r1 = rf(ctx, account)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MysqlRepositoryInterface_InsertAccount_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InsertAccount'
+type MysqlRepositoryInterface_InsertAccount_Call struct {
+	*mock.Call
+}
+
+// InsertAccount is a helper method to define mock.On call
+//   - ctx context.Context
+//   - account model.Account
+func (_e *MysqlRepositoryInterface_Expecter) InsertAccount(ctx interface{}, account interface{}) *MysqlRepositoryInterface_InsertAccount_Call {
+	return &MysqlRepositoryInterface_InsertAccount_Call{Call: _e.mock.On("InsertAccount", ctx, account)}
+}
+
+func (_c *MysqlRepositoryInterface_InsertAccount_Call) Run(run func(ctx context.Context, account model.Account)) *MysqlRepositoryInterface_InsertAccount_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.Account))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_InsertAccount_Call) Return(_a0 model.Account, _a1 error) *MysqlRepositoryInterface_InsertAccount_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_InsertAccount_Call) RunAndReturn(run func(context.Context, model.Account) (model.Account, error)) *MysqlRepositoryInterface_InsertAccount_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// InsertUser provides a mock function with given fields: ctx, user
+func (_m *MysqlRepositoryInterface) InsertUser(ctx context.Context, user model.User) (model.User, error) {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertUser")
+	}
+
+	var r0 model.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.User) (model.User, error)); ok {
+		return rf(ctx, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, model.User) model.User); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Get(0).(model.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, model.User) error); ok {
+		r1 = rf(ctx, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MysqlRepositoryInterface_InsertUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'InsertUser'
+type MysqlRepositoryInterface_InsertUser_Call struct {
+	*mock.Call
+}
+
+// InsertUser is a helper method to define mock.On call
+//   - ctx context.Context
+//   - user model.User
+func (_e *MysqlRepositoryInterface_Expecter) InsertUser(ctx interface{}, user interface{}) *MysqlRepositoryInterface_InsertUser_Call {
+	return &MysqlRepositoryInterface_InsertUser_Call{Call: _e.mock.On("InsertUser", ctx, user)}
+}
+
+func (_c *MysqlRepositoryInterface_InsertUser_Call) Run(run func(ctx context.Context, user model.User)) *MysqlRepositoryInterface_InsertUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.User))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_InsertUser_Call) Return(_a0 model.User, _a1 error) *MysqlRepositoryInterface_InsertUser_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_InsertUser_Call) RunAndReturn(run func(context.Context, model.User) (model.User, error)) *MysqlRepositoryInterface_InsertUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAccountsByUserID provides a mock function with given fields: ctx, userID
+func (_m *MysqlRepositoryInterface) ListAccountsByUserID(ctx context.Context, userID string) ([]model.Account, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAccountsByUserID")
+	}
+
+	var r0 []model.Account
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]model.Account, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.Account); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Account)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MysqlRepositoryInterface_ListAccountsByUserID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAccountsByUserID'
+type MysqlRepositoryInterface_ListAccountsByUserID_Call struct {
+	*mock.Call
+}
+
+// ListAccountsByUserID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *MysqlRepositoryInterface_Expecter) ListAccountsByUserID(ctx interface{}, userID interface{}) *MysqlRepositoryInterface_ListAccountsByUserID_Call {
+	return &MysqlRepositoryInterface_ListAccountsByUserID_Call{Call: _e.mock.On("ListAccountsByUserID", ctx, userID)}
+}
+
+func (_c *MysqlRepositoryInterface_ListAccountsByUserID_Call) Run(run func(ctx context.Context, userID string)) *MysqlRepositoryInterface_ListAccountsByUserID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_ListAccountsByUserID_Call) Return(_a0 []model.Account, _a1 error) *MysqlRepositoryInterface_ListAccountsByUserID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_ListAccountsByUserID_Call) RunAndReturn(run func(context.Context, string) ([]model.Account, error)) *MysqlRepositoryInterface_ListAccountsByUserID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAccountsByUserIDs provides a mock function with given fields: ctx, userIDs
+func (_m *MysqlRepositoryInterface) ListAccountsByUserIDs(ctx context.Context, userIDs []string) ([]model.Account, error) {
+	ret := _m.Called(ctx, userIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAccountsByUserIDs")
+	}
+
+	var r0 []model.Account
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]model.Account, error)); ok {
+		return rf(ctx, userIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []model.Account); ok {
+		r0 = rf(ctx, userIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Account)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, userIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MysqlRepositoryInterface_ListAccountsByUserIDs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAccountsByUserIDs'
+type MysqlRepositoryInterface_ListAccountsByUserIDs_Call struct {
+	*mock.Call
+}
+
+// ListAccountsByUserIDs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userIDs []string
+func (_e *MysqlRepositoryInterface_Expecter) ListAccountsByUserIDs(ctx interface{}, userIDs interface{}) *MysqlRepositoryInterface_ListAccountsByUserIDs_Call {
+	return &MysqlRepositoryInterface_ListAccountsByUserIDs_Call{Call: _e.mock.On("ListAccountsByUserIDs", ctx, userIDs)}
+}
+
+func (_c *MysqlRepositoryInterface_ListAccountsByUserIDs_Call) Run(run func(ctx context.Context, userIDs []string)) *MysqlRepositoryInterface_ListAccountsByUserIDs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].([]string))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_ListAccountsByUserIDs_Call) Return(_a0 []model.Account, _a1 error) *MysqlRepositoryInterface_ListAccountsByUserIDs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_ListAccountsByUserIDs_Call) RunAndReturn(run func(context.Context, []string) ([]model.Account, error)) *MysqlRepositoryInterface_ListAccountsByUserIDs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkUserVerified provides a mock function with given fields: ctx, userID
+func (_m *MysqlRepositoryInterface) MarkUserVerified(ctx context.Context, userID string) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkUserVerified")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MysqlRepositoryInterface_MarkUserVerified_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkUserVerified'
+type MysqlRepositoryInterface_MarkUserVerified_Call struct {
+	*mock.Call
+}
+
+// MarkUserVerified is a helper method to define mock.On call
+//   - ctx context.Context
+//   - userID string
+func (_e *MysqlRepositoryInterface_Expecter) MarkUserVerified(ctx interface{}, userID interface{}) *MysqlRepositoryInterface_MarkUserVerified_Call {
+	return &MysqlRepositoryInterface_MarkUserVerified_Call{Call: _e.mock.On("MarkUserVerified", ctx, userID)}
+}
+
+func (_c *MysqlRepositoryInterface_MarkUserVerified_Call) Run(run func(ctx context.Context, userID string)) *MysqlRepositoryInterface_MarkUserVerified_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_MarkUserVerified_Call) Return(_a0 error) *MysqlRepositoryInterface_MarkUserVerified_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MysqlRepositoryInterface_MarkUserVerified_Call) RunAndReturn(run func(context.Context, string) error) *MysqlRepositoryInterface_MarkUserVerified_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMysqlRepositoryInterface creates a new instance of MysqlRepositoryInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMysqlRepositoryInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MysqlRepositoryInterface {
+	mock := &MysqlRepositoryInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,306 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	model "github.com/vier21/tefa-ch3/internal/model"
+
+	time "time"
+)
+
+// OTPRepositoryInterface is an autogenerated mock type for the OTPRepositoryInterface type
+type OTPRepositoryInterface struct {
+	mock.Mock
+}
+
+type OTPRepositoryInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *OTPRepositoryInterface) EXPECT() *OTPRepositoryInterface_Expecter {
+	return &OTPRepositoryInterface_Expecter{mock: &_m.Mock}
+}
+
+// DeleteOTP provides a mock function with given fields: ctx, accountID
+func (_m *OTPRepositoryInterface) DeleteOTP(ctx context.Context, accountID string) error {
+	ret := _m.Called(ctx, accountID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteOTP")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// OTPRepositoryInterface_DeleteOTP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteOTP'
+type OTPRepositoryInterface_DeleteOTP_Call struct {
+	*mock.Call
+}
+
+// DeleteOTP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - accountID string
+func (_e *OTPRepositoryInterface_Expecter) DeleteOTP(ctx interface{}, accountID interface{}) *OTPRepositoryInterface_DeleteOTP_Call {
+	return &OTPRepositoryInterface_DeleteOTP_Call{Call: _e.mock.On("DeleteOTP", ctx, accountID)}
+}
+
+func (_c *OTPRepositoryInterface_DeleteOTP_Call) Run(run func(ctx context.Context, accountID string)) *OTPRepositoryInterface_DeleteOTP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *OTPRepositoryInterface_DeleteOTP_Call) Return(_a0 error) *OTPRepositoryInterface_DeleteOTP_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *OTPRepositoryInterface_DeleteOTP_Call) RunAndReturn(run func(context.Context, string) error) *OTPRepositoryInterface_DeleteOTP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetOTP provides a mock function with given fields: ctx, accountID
+func (_m *OTPRepositoryInterface) GetOTP(ctx context.Context, accountID string) (model.AccountOTP, error) {
+	ret := _m.Called(ctx, accountID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOTP")
+	}
+
+	var r0 model.AccountOTP
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (model.AccountOTP, error)); ok {
+		return rf(ctx, accountID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) model.AccountOTP); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Get(0).(model.AccountOTP)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OTPRepositoryInterface_GetOTP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOTP'
+type OTPRepositoryInterface_GetOTP_Call struct {
+	*mock.Call
+}
+
+// GetOTP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - accountID string
+func (_e *OTPRepositoryInterface_Expecter) GetOTP(ctx interface{}, accountID interface{}) *OTPRepositoryInterface_GetOTP_Call {
+	return &OTPRepositoryInterface_GetOTP_Call{Call: _e.mock.On("GetOTP", ctx, accountID)}
+}
+
+func (_c *OTPRepositoryInterface_GetOTP_Call) Run(run func(ctx context.Context, accountID string)) *OTPRepositoryInterface_GetOTP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *OTPRepositoryInterface_GetOTP_Call) Return(_a0 model.AccountOTP, _a1 error) *OTPRepositoryInterface_GetOTP_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *OTPRepositoryInterface_GetOTP_Call) RunAndReturn(run func(context.Context, string) (model.AccountOTP, error)) *OTPRepositoryInterface_GetOTP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListExpiredOTPs provides a mock function with given fields: ctx, before
+func (_m *OTPRepositoryInterface) ListExpiredOTPs(ctx context.Context, before time.Time) ([]string, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListExpiredOTPs")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]string, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []string); ok {
+		r0 = rf(ctx, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OTPRepositoryInterface_ListExpiredOTPs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListExpiredOTPs'
+type OTPRepositoryInterface_ListExpiredOTPs_Call struct {
+	*mock.Call
+}
+
+// ListExpiredOTPs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - before time.Time
+func (_e *OTPRepositoryInterface_Expecter) ListExpiredOTPs(ctx interface{}, before interface{}) *OTPRepositoryInterface_ListExpiredOTPs_Call {
+	return &OTPRepositoryInterface_ListExpiredOTPs_Call{Call: _e.mock.On("ListExpiredOTPs", ctx, before)}
+}
+
+func (_c *OTPRepositoryInterface_ListExpiredOTPs_Call) Run(run func(ctx context.Context, before time.Time)) *OTPRepositoryInterface_ListExpiredOTPs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *OTPRepositoryInterface_ListExpiredOTPs_Call) Return(_a0 []string, _a1 error) *OTPRepositoryInterface_ListExpiredOTPs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *OTPRepositoryInterface_ListExpiredOTPs_Call) RunAndReturn(run func(context.Context, time.Time) ([]string, error)) *OTPRepositoryInterface_ListExpiredOTPs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordOTPFailure provides a mock function with given fields: ctx, accountID
+func (_m *OTPRepositoryInterface) RecordOTPFailure(ctx context.Context, accountID string) (int, error) {
+	ret := _m.Called(ctx, accountID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordOTPFailure")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, accountID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, accountID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, accountID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// OTPRepositoryInterface_RecordOTPFailure_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordOTPFailure'
+type OTPRepositoryInterface_RecordOTPFailure_Call struct {
+	*mock.Call
+}
+
+// RecordOTPFailure is a helper method to define mock.On call
+//   - ctx context.Context
+//   - accountID string
+func (_e *OTPRepositoryInterface_Expecter) RecordOTPFailure(ctx interface{}, accountID interface{}) *OTPRepositoryInterface_RecordOTPFailure_Call {
+	return &OTPRepositoryInterface_RecordOTPFailure_Call{Call: _e.mock.On("RecordOTPFailure", ctx, accountID)}
+}
+
+func (_c *OTPRepositoryInterface_RecordOTPFailure_Call) Run(run func(ctx context.Context, accountID string)) *OTPRepositoryInterface_RecordOTPFailure_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *OTPRepositoryInterface_RecordOTPFailure_Call) Return(_a0 int, _a1 error) *OTPRepositoryInterface_RecordOTPFailure_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *OTPRepositoryInterface_RecordOTPFailure_Call) RunAndReturn(run func(context.Context, string) (int, error)) *OTPRepositoryInterface_RecordOTPFailure_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpsertOTP provides a mock function with given fields: ctx, otp
+func (_m *OTPRepositoryInterface) UpsertOTP(ctx context.Context, otp model.AccountOTP) error {
+	ret := _m.Called(ctx, otp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpsertOTP")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, model.AccountOTP) error); ok {
+		r0 = rf(ctx, otp)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// OTPRepositoryInterface_UpsertOTP_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpsertOTP'
+type OTPRepositoryInterface_UpsertOTP_Call struct {
+	*mock.Call
+}
+
+// UpsertOTP is a helper method to define mock.On call
+//   - ctx context.Context
+//   - otp model.AccountOTP
+func (_e *OTPRepositoryInterface_Expecter) UpsertOTP(ctx interface{}, otp interface{}) *OTPRepositoryInterface_UpsertOTP_Call {
+	return &OTPRepositoryInterface_UpsertOTP_Call{Call: _e.mock.On("UpsertOTP", ctx, otp)}
+}
+
+func (_c *OTPRepositoryInterface_UpsertOTP_Call) Run(run func(ctx context.Context, otp model.AccountOTP)) *OTPRepositoryInterface_UpsertOTP_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(model.AccountOTP))
+	})
+	return _c
+}
+
+func (_c *OTPRepositoryInterface_UpsertOTP_Call) Return(_a0 error) *OTPRepositoryInterface_UpsertOTP_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *OTPRepositoryInterface_UpsertOTP_Call) RunAndReturn(run func(context.Context, model.AccountOTP) error) *OTPRepositoryInterface_UpsertOTP_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewOTPRepositoryInterface creates a new instance of OTPRepositoryInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOTPRepositoryInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OTPRepositoryInterface {
+	mock := &OTPRepositoryInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,144 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	model "github.com/vier21/tefa-ch3/internal/model"
+
+	time "time"
+)
+
+// RateLimitRepositoryInterface is an autogenerated mock type for the RateLimitRepositoryInterface type
+type RateLimitRepositoryInterface struct {
+	mock.Mock
+}
+
+type RateLimitRepositoryInterface_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *RateLimitRepositoryInterface) EXPECT() *RateLimitRepositoryInterface_Expecter {
+	return &RateLimitRepositoryInterface_Expecter{mock: &_m.Mock}
+}
+
+// DeleteBucketsBefore provides a mock function with given fields: ctx, before
+func (_m *RateLimitRepositoryInterface) DeleteBucketsBefore(ctx context.Context, before time.Time) (int, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteBucketsBefore")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RateLimitRepositoryInterface_DeleteBucketsBefore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteBucketsBefore'
+type RateLimitRepositoryInterface_DeleteBucketsBefore_Call struct {
+	*mock.Call
+}
+
+// DeleteBucketsBefore is a helper method to define mock.On call
+//   - ctx context.Context
+//   - before time.Time
+func (_e *RateLimitRepositoryInterface_Expecter) DeleteBucketsBefore(ctx interface{}, before interface{}) *RateLimitRepositoryInterface_DeleteBucketsBefore_Call {
+	return &RateLimitRepositoryInterface_DeleteBucketsBefore_Call{Call: _e.mock.On("DeleteBucketsBefore", ctx, before)}
+}
+
+func (_c *RateLimitRepositoryInterface_DeleteBucketsBefore_Call) Run(run func(ctx context.Context, before time.Time)) *RateLimitRepositoryInterface_DeleteBucketsBefore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *RateLimitRepositoryInterface_DeleteBucketsBefore_Call) Return(_a0 int, _a1 error) *RateLimitRepositoryInterface_DeleteBucketsBefore_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RateLimitRepositoryInterface_DeleteBucketsBefore_Call) RunAndReturn(run func(context.Context, time.Time) (int, error)) *RateLimitRepositoryInterface_DeleteBucketsBefore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateBucket provides a mock function with given fields: ctx, key, update
+func (_m *RateLimitRepositoryInterface) UpdateBucket(ctx context.Context, key string, update func(model.RateLimitBucket) model.RateLimitBucket) error {
+	ret := _m.Called(ctx, key, update)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBucket")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, func(model.RateLimitBucket) model.RateLimitBucket) error); ok {
+		r0 = rf(ctx, key, update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RateLimitRepositoryInterface_UpdateBucket_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateBucket'
+type RateLimitRepositoryInterface_UpdateBucket_Call struct {
+	*mock.Call
+}
+
+// UpdateBucket is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+//   - update func(model.RateLimitBucket) model.RateLimitBucket
+func (_e *RateLimitRepositoryInterface_Expecter) UpdateBucket(ctx interface{}, key interface{}, update interface{}) *RateLimitRepositoryInterface_UpdateBucket_Call {
+	return &RateLimitRepositoryInterface_UpdateBucket_Call{Call: _e.mock.On("UpdateBucket", ctx, key, update)}
+}
+
+func (_c *RateLimitRepositoryInterface_UpdateBucket_Call) Run(run func(ctx context.Context, key string, update func(model.RateLimitBucket) model.RateLimitBucket)) *RateLimitRepositoryInterface_UpdateBucket_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(func(model.RateLimitBucket) model.RateLimitBucket))
+	})
+	return _c
+}
+
+func (_c *RateLimitRepositoryInterface_UpdateBucket_Call) Return(_a0 error) *RateLimitRepositoryInterface_UpdateBucket_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *RateLimitRepositoryInterface_UpdateBucket_Call) RunAndReturn(run func(context.Context, string, func(model.RateLimitBucket) model.RateLimitBucket) error) *RateLimitRepositoryInterface_UpdateBucket_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewRateLimitRepositoryInterface creates a new instance of RateLimitRepositoryInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRateLimitRepositoryInterface(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RateLimitRepositoryInterface {
+	mock := &RateLimitRepositoryInterface{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -2,11 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 
 	"github.com/google/uuid"
 	"github.com/vier21/tefa-ch3/db"
+	"github.com/vier21/tefa-ch3/internal/logging"
 	"github.com/vier21/tefa-ch3/internal/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -16,27 +17,36 @@ type MongodbRepositoryInterface interface {
 	InsertUser(ctx context.Context, user model.User) (model.User, error)
 
 	GetUser(ctx context.Context, userid string) (model.User, error)
+	MarkUserVerified(ctx context.Context, userid string) error
+	DeleteUser(ctx context.Context, userid string) error
 }
 
 type MongoRepository struct {
 	db         *mongo.Client
+	database   string
 	collection string
 }
 
 func NewMongoRepository() *MongoRepository {
 	return &MongoRepository{
 		db:         db.MongoCLI,
+		database:   db.MongoDBName,
 		collection: "user",
 	}
 }
 
 func (m *MongoRepository) InsertUser(ctx context.Context, user model.User) (model.User, error) {
-	coll := m.db.Database("user").Collection(m.collection)
-	id := uuid.NewString()
-	user.UserID = id
+	coll := m.db.Database(m.database).Collection(m.collection)
+	if user.UserID == "" {
+		user.UserID = uuid.NewString()
+	}
+	id := user.UserID
 
 	doc, err := coll.InsertOne(ctx, user)
 
+	if mongo.IsDuplicateKeyError(err) {
+		return model.User{}, ErrUserExists
+	}
 	if err != nil {
 		return model.User{}, err
 	}
@@ -50,24 +60,51 @@ func (m *MongoRepository) InsertUser(ctx context.Context, user model.User) (mode
 }
 
 func (m *MongoRepository) GetUser(ctx context.Context, userid string) (model.User, error) {
-	coll := m.db.Database("user").Collection(m.collection)
+	coll := m.db.Database(m.database).Collection(m.collection)
 	filter := bson.M{
 		"_id": userid,
 	}
 	doc := coll.FindOne(ctx, filter)
 
-	if doc.Err() != nil {
-		log.Println(doc.Err().Error())
-
+	if errors.Is(doc.Err(), mongo.ErrNoDocuments) {
+		return model.User{}, ErrUserNotFound
 	}
 
 	var user model.User
 
 	if err := doc.Decode(&user); err != nil {
-		log.Println(err.Error())
+		logging.FromContext(ctx).Error("error decoding user", "user_id", userid, "error", err)
 		return model.User{}, err
 	}
 
 	return user, nil
 
 }
+
+func (m *MongoRepository) MarkUserVerified(ctx context.Context, userid string) error {
+	coll := m.db.Database(m.database).Collection(m.collection)
+
+	res, err := coll.UpdateOne(ctx, bson.M{"_id": userid}, bson.M{"$set": bson.M{"verified": true}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (m *MongoRepository) DeleteUser(ctx context.Context, userid string) error {
+	coll := m.db.Database(m.database).Collection(m.collection)
+
+	res, err := coll.DeleteOne(ctx, bson.M{"_id": userid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/vier21/tefa-ch3/db"
@@ -13,8 +15,21 @@ import (
 type MysqlRepositoryInterface interface {
 	InsertUser(ctx context.Context, user model.User) (model.User, error)
 	GetUserByID(ctx context.Context, userID string) (model.User, error)
+	GetUsersByIDs(ctx context.Context, userIDs []string) ([]model.User, error)
 	InsertAccount(ctx context.Context, account model.Account) (model.Account, error)
 	GetUserByAccountID(ctx context.Context, accountID string) (model.User, error)
+	GetAccountByID(ctx context.Context, accountID string) (model.Account, error)
+	ListAccountsByUserID(ctx context.Context, userID string) ([]model.Account, error)
+	ListAccountsByUserIDs(ctx context.Context, userIDs []string) ([]model.Account, error)
+	// ActivateAccount fails with ErrMsisdnTaken when another account holding
+	// the same MSISDN was activated first. Only active accounts hold their
+	// MSISDN, so pending ones cannot block its owner.
+	ActivateAccount(ctx context.Context, accountID string) error
+	DeleteAccount(ctx context.Context, accountID string) error
+	// DeleteUser removes the user together with its accounts and
+	// credential.
+	DeleteUser(ctx context.Context, userID string) error
+	MarkUserVerified(ctx context.Context, userID string) error
 }
 
 type mySqlRepository struct {
@@ -29,20 +44,21 @@ func NewMysqlRepository() *mySqlRepository {
 
 func (m *mySqlRepository) InsertUser(ctx context.Context, user model.User) (model.User, error) {
 
-	sqlstr := "INSERT INTO user (id, name, address, email) values (?, ?, ?, ?)"
+	sqlstr := "INSERT INTO user (id, name, address, email, verified) values (?, ?, ?, ?, ?)"
 	user.UserID = uuid.NewString()
 
-	_, err := m.db.ExecContext(ctx, sqlstr, user.UserID, user.Name, user.Address, user.Email)
+	_, err := m.db.ExecContext(ctx, sqlstr, user.UserID, user.Name, user.Address, user.Email, user.Verified)
 
 	if err != nil {
 		return model.User{}, err
 	}
 
 	result := model.User{
-		UserID:  user.UserID,
-		Name:    user.Name,
-		Address: user.Name,
-		Email:   user.Email,
+		UserID:   user.UserID,
+		Name:     user.Name,
+		Address:  user.Address,
+		Email:    user.Email,
+		Verified: user.Verified,
 	}
 
 	return result, nil
@@ -50,17 +66,23 @@ func (m *mySqlRepository) InsertUser(ctx context.Context, user model.User) (mode
 
 func (m *mySqlRepository) GetUserByAccountID(ctx context.Context, accountID string) (model.User, error) {
 	// mengambil userID berdasarkan AccountID
-	accountSQL := "SELECT user_id FROM account WHERE id = ?"
+	accountSQL := "SELECT user_id FROM account WHERE id = ? AND status = 'active'"
 	var userID string
 	err := m.db.GetContext(ctx, &userID, accountSQL, accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, ErrAccountNotFound
+	}
 	if err != nil {
 		return model.User{}, err
 	}
 
 	// mencari informasi berdasarkan
-	userSQL := "SELECT id, name, address, email FROM user WHERE id = ?"
+	userSQL := "SELECT id, name, address, email, verified FROM user WHERE id = ?"
 	var user model.User
 	err = m.db.GetContext(ctx, &user, userSQL, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, ErrUserNotFound
+	}
 	if err != nil {
 		return model.User{}, err
 	}
@@ -70,9 +92,12 @@ func (m *mySqlRepository) GetUserByAccountID(ctx context.Context, accountID stri
 
 func (m *mySqlRepository) GetUserByID(ctx context.Context, userID string) (model.User, error) {
 	var user model.User
-	sqlstr := "SELECT id, name, address, email FROM user WHERE id = ?"
+	sqlstr := "SELECT id, name, address, email, verified FROM user WHERE id = ?"
 
 	err := m.db.GetContext(ctx, &user, sqlstr, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, ErrUserNotFound
+	}
 	if err != nil {
 		return model.User{}, err
 	}
@@ -80,41 +105,188 @@ func (m *mySqlRepository) GetUserByID(ctx context.Context, userID string) (model
 	return user, nil
 }
 
+// GetUsersByIDs returns the users found in one query, ordered by ID. Unknown
+// IDs are left out.
+func (m *mySqlRepository) GetUsersByIDs(ctx context.Context, userIDs []string) ([]model.User, error) {
+	users := []model.User{}
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+
+	sqlstr, args, err := sqlx.In("SELECT id, name, address, email, verified FROM user WHERE id IN (?) ORDER BY id", userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.db.SelectContext(ctx, &users, m.db.Rebind(sqlstr), args...); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
 func (m *mySqlRepository) InsertAccount(ctx context.Context, account model.Account) (model.Account, error) {
-	var accounts []model.Account
-	rows, err := m.db.QueryContext(ctx, "SELECT * FROM account WHERE user_id=?", account.UserID)
+	var owned int
+	err := m.db.GetContext(ctx, &owned, "SELECT COUNT(*) FROM account WHERE user_id = ?", account.UserID)
 	if err != nil {
 		return model.Account{}, err
 	}
 
-	defer rows.Close()
-
-	for rows.Next() {
-		var account model.Account
-		err := rows.Scan(&account.AccountID, &account.MsisdnCustomer, &account.UserID)
-		if err != nil {
-			return model.Account{}, err
-		}
-		accounts = append(accounts, account)
+	if owned >= MaxAccountsPerUser {
+		return model.Account{}, ErrMsisdnLimit
 	}
 
-	if err := rows.Err(); err != nil {
+	// the unique index only covers active accounts, so pending ones are
+	// checked here
+	var taken bool
+	err = m.db.GetContext(ctx, &taken, "SELECT EXISTS(SELECT 1 FROM account WHERE active_msisdn = ?)", account.MsisdnCustomer)
+	if err != nil {
 		return model.Account{}, err
 	}
+	if taken {
+		return model.Account{}, ErrMsisdnTaken
+	}
 
-	if len(accounts) >= 3 {
-		return model.Account{}, errors.New("MSISDN Limit Reached")
+	if account.Status == "" {
+		account.Status = model.AccountActive
 	}
 
-	sqlstr := "INSERT INTO account (id, msisdn_customer, user_id) VALUES (?, ?, ?)"
+	sqlstr := "INSERT INTO account (id, msisdn_customer, user_id, status) VALUES (?, ?, ?, ?)"
 	account.AccountID = uuid.NewString()
 
-	_, err = m.db.ExecContext(ctx, sqlstr, account.AccountID, account.MsisdnCustomer, account.UserID)
+	_, err = m.db.ExecContext(ctx, sqlstr, account.AccountID, account.MsisdnCustomer, account.UserID, account.Status)
 
+	if err != nil {
+		return model.Account{}, mapAccountError(err)
+	}
+
+	return account, nil
+
+}
+
+func (m *mySqlRepository) GetAccountByID(ctx context.Context, accountID string) (model.Account, error) {
+	var account model.Account
+	sqlstr := "SELECT id, msisdn_customer, user_id, status FROM account WHERE id = ?"
+
+	err := m.db.GetContext(ctx, &account, sqlstr, accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Account{}, ErrAccountNotFound
+	}
 	if err != nil {
 		return model.Account{}, err
 	}
 
 	return account, nil
+}
+
+// ListAccountsByUserID returns the accounts of the user in any status,
+// ordered by ID.
+func (m *mySqlRepository) ListAccountsByUserID(ctx context.Context, userID string) ([]model.Account, error) {
+	accounts := []model.Account{}
+	sqlstr := "SELECT id, msisdn_customer, user_id, status FROM account WHERE user_id = ? ORDER BY id"
+
+	if err := m.db.SelectContext(ctx, &accounts, sqlstr, userID); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// ListAccountsByUserIDs returns the accounts of all the users in one query,
+// ordered by ID.
+func (m *mySqlRepository) ListAccountsByUserIDs(ctx context.Context, userIDs []string) ([]model.Account, error) {
+	accounts := []model.Account{}
+	if len(userIDs) == 0 {
+		return accounts, nil
+	}
+
+	sqlstr, args, err := sqlx.In("SELECT id, msisdn_customer, user_id, status FROM account WHERE user_id IN (?) ORDER BY id", userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.db.SelectContext(ctx, &accounts, m.db.Rebind(sqlstr), args...); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func (m *mySqlRepository) ActivateAccount(ctx context.Context, accountID string) error {
+	res, err := m.db.ExecContext(ctx, "UPDATE account SET status = ? WHERE id = ?", model.AccountActive, accountID)
+	if err != nil {
+		return mapAccountError(err)
+	}
 
+	// an already active account is not counted as affected
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		if _, err := m.GetAccountByID(ctx, accountID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *mySqlRepository) DeleteAccount(ctx context.Context, accountID string) error {
+	res, err := m.db.ExecContext(ctx, "DELETE FROM account WHERE id = ?", accountID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrAccountNotFound
+	}
+
+	return nil
+}
+
+func (m *mySqlRepository) DeleteUser(ctx context.Context, userID string) error {
+	res, err := m.db.ExecContext(ctx, "DELETE FROM user WHERE id = ?", userID)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (m *mySqlRepository) MarkUserVerified(ctx context.Context, userID string) error {
+	res, err := m.db.ExecContext(ctx, "UPDATE user SET verified = TRUE WHERE id = ?", userID)
+	if err != nil {
+		return err
+	}
+
+	// an already verified user is not counted as affected
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		var exists bool
+		if err := m.db.GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM user WHERE id = ?)", userID); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrUserNotFound
+		}
+	}
+
+	return nil
+}
+
+// mapAccountError translates constraint violations from the account table
+// into repository errors.
+func mapAccountError(err error) error {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return err
+	}
+
+	switch myErr.Number {
+	case 1062: // ER_DUP_ENTRY
+		return ErrMsisdnTaken
+	case 1452: // ER_NO_REFERENCED_ROW_2
+		return ErrUserNotFound
+	}
+	return err
 }
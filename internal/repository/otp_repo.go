@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vier21/tefa-ch3/db"
+	"github.com/vier21/tefa-ch3/internal/model"
+)
+
+type OTPRepositoryInterface interface {
+	UpsertOTP(ctx context.Context, otp model.AccountOTP) error
+	GetOTP(ctx context.Context, accountID string) (model.AccountOTP, error)
+	RecordOTPFailure(ctx context.Context, accountID string) (int, error)
+	DeleteOTP(ctx context.Context, accountID string) error
+	ListExpiredOTPs(ctx context.Context, before time.Time) ([]string, error)
+}
+
+type otpRepository struct {
+	db *sqlx.DB
+}
+
+func NewOTPRepository() *otpRepository {
+	return &otpRepository{
+		db: db.DB,
+	}
+}
+
+// UpsertOTP stores otp, replacing the previous code of the account and its
+// failed attempts.
+func (m *otpRepository) UpsertOTP(ctx context.Context, otp model.AccountOTP) error {
+	sqlstr := `INSERT INTO account_otp (account_id, code_hash, attempts, expires_at, created_at) VALUES (?, ?, 0, ?, ?)
+		ON DUPLICATE KEY UPDATE code_hash = VALUES(code_hash), attempts = 0, expires_at = VALUES(expires_at), created_at = VALUES(created_at)`
+	_, err := m.db.ExecContext(ctx, sqlstr, otp.AccountID, otp.CodeHash, otp.ExpiresAt, otp.CreatedAt)
+	return mapAccountError(err)
+}
+
+func (m *otpRepository) GetOTP(ctx context.Context, accountID string) (model.AccountOTP, error) {
+	var otp model.AccountOTP
+	sqlstr := "SELECT account_id, code_hash, attempts, expires_at, created_at FROM account_otp WHERE account_id = ?"
+
+	err := m.db.GetContext(ctx, &otp, sqlstr, accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.AccountOTP{}, ErrOTPNotFound
+	}
+	if err != nil {
+		return model.AccountOTP{}, err
+	}
+
+	return otp, nil
+}
+
+// RecordOTPFailure increments the failed attempts and returns the new count.
+func (m *otpRepository) RecordOTPFailure(ctx context.Context, accountID string) (int, error) {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE account_otp SET attempts = attempts + 1 WHERE account_id = ?", accountID); err != nil {
+		return 0, err
+	}
+
+	var attempts int
+	err = tx.GetContext(ctx, &attempts, "SELECT attempts FROM account_otp WHERE account_id = ?", accountID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrOTPNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return attempts, tx.Commit()
+}
+
+func (m *otpRepository) DeleteOTP(ctx context.Context, accountID string) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM account_otp WHERE account_id = ?", accountID)
+	return err
+}
+
+// ListExpiredOTPs returns the accounts whose code expired before the given
+// time.
+func (m *otpRepository) ListExpiredOTPs(ctx context.Context, before time.Time) ([]string, error) {
+	var ids []string
+	if err := m.db.SelectContext(ctx, &ids, "SELECT account_id FROM account_otp WHERE expires_at <= ? ORDER BY expires_at", before); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
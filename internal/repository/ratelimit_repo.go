@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/vier21/tefa-ch3/db"
+	"github.com/vier21/tefa-ch3/internal/model"
+)
+
+type RateLimitRepositoryInterface interface {
+	// UpdateBucket passes the bucket stored under key, or one with only the
+	// key set when there is none, to update and stores the result. Updates
+	// of the same key do not interleave.
+	UpdateBucket(ctx context.Context, key string, update func(model.RateLimitBucket) model.RateLimitBucket) error
+	DeleteBucketsBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type rateLimitRepository struct {
+	db *sqlx.DB
+}
+
+func NewRateLimitRepository() *rateLimitRepository {
+	return &rateLimitRepository{
+		db: db.DB,
+	}
+}
+
+func (m *rateLimitRepository) UpdateBucket(ctx context.Context, key string, update func(model.RateLimitBucket) model.RateLimitBucket) error {
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	bucket := model.RateLimitBucket{Key: key}
+	err = tx.GetContext(ctx, &bucket, "SELECT bucket_key, tokens, updated_at FROM rate_limit_bucket WHERE bucket_key = ? FOR UPDATE", key)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	bucket = update(bucket)
+
+	sqlstr := `INSERT INTO rate_limit_bucket (bucket_key, tokens, updated_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE tokens = VALUES(tokens), updated_at = VALUES(updated_at)`
+	if _, err := tx.ExecContext(ctx, sqlstr, key, bucket.Tokens, bucket.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteBucketsBefore removes the buckets not updated since before and
+// returns how many were removed.
+func (m *rateLimitRepository) DeleteBucketsBefore(ctx context.Context, before time.Time) (int, error) {
+	res, err := m.db.ExecContext(ctx, "DELETE FROM rate_limit_bucket WHERE updated_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,129 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/db/migration"
+	"github.com/vier21/tefa-ch3/internal/dbtest"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/repository/repotest"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var env *dbtest.Env
+
+func TestMain(m *testing.M) {
+	dbtest.Main(m, &env)
+}
+
+var (
+	budi = model.User{UserID: "b3f1c1d2-0000-4000-8000-000000000001", Name: "Budi", Address: "Bandung", Email: "budi@example.com"}
+	sari = model.User{UserID: "b3f1c1d2-0000-4000-8000-000000000002", Name: "Sari", Address: "Jakarta", Email: "sari@example.com"}
+
+	budiAccounts = []model.Account{
+		{AccountID: "a0000000-0000-4000-8000-000000000001", MsisdnCustomer: "6281200000001", UserID: budi.UserID},
+		{AccountID: "a0000000-0000-4000-8000-000000000002", MsisdnCustomer: "6281200000002", UserID: budi.UserID},
+	}
+)
+
+func TestMysqlRepository_Contract(t *testing.T) {
+	repotest.RunMysqlContract(t, func(t *testing.T) repository.MysqlRepositoryInterface {
+		env.Reset(t, dbtest.Fixtures{})
+		return repository.NewMysqlRepository()
+	})
+}
+
+func TestMongoRepository_Contract(t *testing.T) {
+	repotest.RunMongoContract(t, func(t *testing.T) repository.MongodbRepositoryInterface {
+		env.Reset(t, dbtest.Fixtures{})
+		return repository.NewMongoRepository()
+	})
+}
+
+func TestAPIKeyRepository_Contract(t *testing.T) {
+	repotest.RunAPIKeyContract(t, func(t *testing.T) repository.APIKeyRepositoryInterface {
+		env.Reset(t, dbtest.Fixtures{})
+		return repository.NewAPIKeyRepository()
+	})
+}
+
+func TestCredentialRepository_Contract(t *testing.T) {
+	repotest.RunCredentialContract(t, func(t *testing.T) (repository.CredentialRepositoryInterface, repository.MysqlRepositoryInterface) {
+		env.Reset(t, dbtest.Fixtures{})
+		return repository.NewCredentialRepository(), repository.NewMysqlRepository()
+	})
+}
+
+func TestOTPRepository_Contract(t *testing.T) {
+	repotest.RunOTPContract(t, func(t *testing.T) (repository.OTPRepositoryInterface, repository.MysqlRepositoryInterface) {
+		env.Reset(t, dbtest.Fixtures{})
+		return repository.NewOTPRepository(), repository.NewMysqlRepository()
+	})
+}
+
+func TestRateLimitRepository_Contract(t *testing.T) {
+	repotest.RunRateLimitContract(t, func(t *testing.T) repository.RateLimitRepositoryInterface {
+		env.Reset(t, dbtest.Fixtures{})
+		return repository.NewRateLimitRepository()
+	})
+}
+
+func TestMysqlRepository_InsertAccount_Limit(t *testing.T) {
+	env.Reset(t, dbtest.Fixtures{Users: []model.User{budi}, Accounts: budiAccounts})
+	repo := repository.NewMysqlRepository()
+
+	third, err := repo.InsertAccount(context.Background(), model.Account{MsisdnCustomer: "6281200000003", UserID: budi.UserID})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, third.AccountID)
+
+	_, err = repo.InsertAccount(context.Background(), model.Account{MsisdnCustomer: "6281200000004", UserID: budi.UserID})
+	assert.ErrorIs(t, err, repository.ErrMsisdnLimit)
+}
+
+func TestMysqlRepository_InsertAccount_Duplicate(t *testing.T) {
+	env.Reset(t, dbtest.Fixtures{Users: []model.User{budi, sari}, Accounts: budiAccounts})
+	repo := repository.NewMysqlRepository()
+
+	_, err := repo.InsertAccount(context.Background(), model.Account{MsisdnCustomer: budiAccounts[0].MsisdnCustomer, UserID: sari.UserID})
+	assert.ErrorIs(t, err, repository.ErrMsisdnTaken)
+}
+
+func TestMysqlRepository_GetUserByAccountID(t *testing.T) {
+	env.Reset(t, dbtest.Fixtures{Users: []model.User{budi}, Accounts: budiAccounts})
+	repo := repository.NewMysqlRepository()
+
+	user, err := repo.GetUserByAccountID(context.Background(), budiAccounts[1].AccountID)
+	assert.NoError(t, err)
+	assert.Equal(t, budi, user)
+
+	_, err = repo.GetUserByAccountID(context.Background(), "a0000000-0000-4000-8000-00000000ffff")
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+}
+
+func TestMongoRepository_GetUser_Fixtures(t *testing.T) {
+	env.Reset(t, dbtest.Fixtures{Users: []model.User{budi}})
+	repo := repository.NewMongoRepository()
+
+	got, err := repo.GetUser(context.Background(), budi.UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, budi, got)
+
+	_, err = repo.GetUser(context.Background(), sari.UserID)
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
+func TestMongo_SchemaValidator(t *testing.T) {
+	env.Reset(t, dbtest.Fixtures{})
+
+	_, err := env.Mongo.Collection(migration.UserCollection).InsertOne(context.Background(), bson.M{"_id": "x", "name": "no email"})
+	assert.Error(t, err)
+
+	drift, err := migration.MongoIndexDrift(context.Background(), env.Mongo)
+	assert.NoError(t, err)
+	assert.Empty(t, drift)
+}
@@ -0,0 +1,747 @@
+// Package repotest holds the contract every implementation of the repository
+// interfaces must satisfy, whether it talks to a real database or keeps data
+// in memory.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+// RunMysqlContract runs the contract against repositories returned by
+// newRepo, which must start empty for every call.
+func RunMysqlContract(t *testing.T, newRepo func(t *testing.T) repository.MysqlRepositoryInterface) {
+	ctx := context.Background()
+
+	t.Run("InsertUser assigns an ID", func(t *testing.T) {
+		repo := newRepo(t)
+		in := newUser("Budi")
+
+		first, err := repo.InsertUser(ctx, in)
+		require.NoError(t, err)
+		second, err := repo.InsertUser(ctx, in)
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, first.UserID)
+		assert.NotEqual(t, first.UserID, second.UserID)
+		assert.Equal(t, in.Name, first.Name)
+		assert.Equal(t, in.Address, first.Address)
+		assert.Equal(t, in.Email, first.Email)
+	})
+
+	t.Run("GetUserByID returns the inserted user", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustInsertUser(t, repo, "Budi")
+
+		got, err := repo.GetUserByID(ctx, user.UserID)
+		require.NoError(t, err)
+		assert.Equal(t, user, got)
+	})
+
+	t.Run("GetUserByID unknown user", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetUserByID(ctx, "00000000-0000-4000-8000-000000000000")
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+
+	t.Run("InsertAccount assigns an ID", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustInsertUser(t, repo, "Budi")
+
+		account, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: "6281200000001", UserID: user.UserID})
+		require.NoError(t, err)
+		assert.NotEmpty(t, account.AccountID)
+		assert.Equal(t, "6281200000001", account.MsisdnCustomer)
+		assert.Equal(t, user.UserID, account.UserID)
+		assert.Equal(t, model.AccountActive, account.Status, "accounts are active unless stated otherwise")
+	})
+
+	t.Run("InsertAccount unknown user", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: "6281200000001", UserID: "00000000-0000-4000-8000-000000000000"})
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+
+	t.Run("InsertAccount MSISDN limit", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustInsertUser(t, repo, "Budi")
+		other := mustInsertUser(t, repo, "Sari")
+
+		for i := 0; i < repository.MaxAccountsPerUser; i++ {
+			_, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(i), UserID: user.UserID})
+			require.NoError(t, err)
+		}
+
+		_, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(99), UserID: user.UserID})
+		assert.ErrorIs(t, err, repository.ErrMsisdnLimit)
+
+		_, err = repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(98), UserID: other.UserID})
+		assert.NoError(t, err, "the limit applies per user")
+	})
+
+	t.Run("InsertAccount duplicate MSISDN", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustInsertUser(t, repo, "Budi")
+		other := mustInsertUser(t, repo, "Sari")
+
+		_, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: user.UserID})
+		require.NoError(t, err)
+
+		_, err = repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: other.UserID})
+		assert.ErrorIs(t, err, repository.ErrMsisdnTaken)
+	})
+
+	t.Run("pending accounts do not hold their MSISDN", func(t *testing.T) {
+		repo := newRepo(t)
+		squatter := mustInsertUser(t, repo, "Budi")
+		owner := mustInsertUser(t, repo, "Sari")
+		late := mustInsertUser(t, repo, "Ani")
+
+		squatted, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: squatter.UserID, Status: model.AccountPending})
+		require.NoError(t, err)
+		owned, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: owner.UserID, Status: model.AccountPending})
+		require.NoError(t, err, "a pending account does not block the MSISDN")
+
+		require.NoError(t, repo.ActivateAccount(ctx, owned.AccountID))
+		assert.ErrorIs(t, repo.ActivateAccount(ctx, squatted.AccountID), repository.ErrMsisdnTaken)
+
+		_, err = repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: late.UserID, Status: model.AccountPending})
+		assert.ErrorIs(t, err, repository.ErrMsisdnTaken, "an active account holds the MSISDN")
+	})
+
+	t.Run("GetUserByAccountID returns the owner", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustInsertUser(t, repo, "Budi")
+
+		account, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: user.UserID})
+		require.NoError(t, err)
+
+		got, err := repo.GetUserByAccountID(ctx, account.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, user, got)
+	})
+
+	t.Run("GetUserByAccountID unknown account", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetUserByAccountID(ctx, "00000000-0000-4000-8000-000000000000")
+		assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+	})
+
+	t.Run("pending accounts", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustInsertUser(t, repo, "Budi")
+
+		account, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: user.UserID, Status: model.AccountPending})
+		require.NoError(t, err)
+
+		got, err := repo.GetAccountByID(ctx, account.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, account, got)
+
+		_, err = repo.GetUserByAccountID(ctx, account.AccountID)
+		assert.ErrorIs(t, err, repository.ErrAccountNotFound, "pending accounts do not resolve to their owner")
+
+		require.NoError(t, repo.ActivateAccount(ctx, account.AccountID))
+		require.NoError(t, repo.ActivateAccount(ctx, account.AccountID), "activating twice is a no-op")
+
+		got, err = repo.GetAccountByID(ctx, account.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, model.AccountActive, got.Status)
+
+		owner, err := repo.GetUserByAccountID(ctx, account.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, user.UserID, owner.UserID)
+
+		assert.ErrorIs(t, repo.ActivateAccount(ctx, "00000000-0000-4000-8000-000000000000"), repository.ErrAccountNotFound)
+		_, err = repo.GetAccountByID(ctx, "00000000-0000-4000-8000-000000000000")
+		assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+	})
+
+	t.Run("ListAccountsByUserID", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustInsertUser(t, repo, "Budi")
+		other := mustInsertUser(t, repo, "Ani")
+
+		accounts, err := repo.ListAccountsByUserID(ctx, user.UserID)
+		require.NoError(t, err)
+		assert.Empty(t, accounts)
+		assert.NotNil(t, accounts, "no accounts is an empty list")
+
+		active, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: user.UserID})
+		require.NoError(t, err)
+		pending, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(2), UserID: user.UserID, Status: model.AccountPending})
+		require.NoError(t, err)
+		_, err = repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(3), UserID: other.UserID})
+		require.NoError(t, err)
+
+		accounts, err = repo.ListAccountsByUserID(ctx, user.UserID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []model.Account{active, pending}, accounts)
+		assert.True(t, accounts[0].AccountID < accounts[1].AccountID, "accounts are ordered by ID")
+	})
+
+	t.Run("GetUsersByIDs", func(t *testing.T) {
+		repo := newRepo(t)
+		budi := mustInsertUser(t, repo, "Budi")
+		ani := mustInsertUser(t, repo, "Ani")
+		mustInsertUser(t, repo, "Dewi")
+
+		users, err := repo.GetUsersByIDs(ctx, nil)
+		require.NoError(t, err)
+		assert.NotNil(t, users, "no users is an empty list")
+		assert.Empty(t, users)
+
+		users, err = repo.GetUsersByIDs(ctx, []string{budi.UserID, ani.UserID, "unknown"})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []model.User{budi, ani}, users)
+		assert.True(t, users[0].UserID < users[1].UserID, "users are ordered by ID")
+	})
+
+	t.Run("ListAccountsByUserIDs", func(t *testing.T) {
+		repo := newRepo(t)
+		budi := mustInsertUser(t, repo, "Budi")
+		ani := mustInsertUser(t, repo, "Ani")
+		other := mustInsertUser(t, repo, "Dewi")
+
+		accounts, err := repo.ListAccountsByUserIDs(ctx, nil)
+		require.NoError(t, err)
+		assert.NotNil(t, accounts, "no accounts is an empty list")
+		assert.Empty(t, accounts)
+
+		first, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: budi.UserID})
+		require.NoError(t, err)
+		second, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(2), UserID: ani.UserID, Status: model.AccountPending})
+		require.NoError(t, err)
+		_, err = repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(3), UserID: other.UserID})
+		require.NoError(t, err)
+
+		accounts, err = repo.ListAccountsByUserIDs(ctx, []string{budi.UserID, ani.UserID, "unknown"})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []model.Account{first, second}, accounts)
+		assert.True(t, accounts[0].AccountID < accounts[1].AccountID, "accounts are ordered by ID")
+	})
+
+	t.Run("DeleteAccount", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustInsertUser(t, repo, "Budi")
+
+		account, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: user.UserID, Status: model.AccountPending})
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteAccount(ctx, account.AccountID))
+		assert.ErrorIs(t, repo.DeleteAccount(ctx, account.AccountID), repository.ErrAccountNotFound)
+
+		_, err = repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: user.UserID})
+		assert.NoError(t, err, "the MSISDN is free again")
+	})
+
+	t.Run("MarkUserVerified", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustInsertUser(t, repo, "Budi")
+		assert.False(t, user.Verified)
+
+		require.NoError(t, repo.MarkUserVerified(ctx, user.UserID))
+		require.NoError(t, repo.MarkUserVerified(ctx, user.UserID), "verifying twice is a no-op")
+
+		got, err := repo.GetUserByID(ctx, user.UserID)
+		require.NoError(t, err)
+		assert.True(t, got.Verified)
+
+		assert.ErrorIs(t, repo.MarkUserVerified(ctx, "00000000-0000-4000-8000-000000000000"), repository.ErrUserNotFound)
+	})
+
+	t.Run("DeleteUser removes the user's accounts", func(t *testing.T) {
+		repo := newRepo(t)
+		user := mustInsertUser(t, repo, "Budi")
+
+		account, err := repo.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(1), UserID: user.UserID})
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteUser(ctx, user.UserID))
+		assert.ErrorIs(t, repo.DeleteUser(ctx, user.UserID), repository.ErrUserNotFound)
+
+		_, err = repo.GetUserByID(ctx, user.UserID)
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+		_, err = repo.GetAccountByID(ctx, account.AccountID)
+		assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+	})
+}
+
+// RunMongoContract runs the contract against repositories returned by
+// newRepo, which must start empty for every call.
+func RunMongoContract(t *testing.T, newRepo func(t *testing.T) repository.MongodbRepositoryInterface) {
+	ctx := context.Background()
+
+	t.Run("InsertUser assigns an ID", func(t *testing.T) {
+		repo := newRepo(t)
+		in := newUser("Budi")
+
+		first, err := repo.InsertUser(ctx, in)
+		require.NoError(t, err)
+		second, err := repo.InsertUser(ctx, in)
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, first.UserID)
+		assert.NotEqual(t, first.UserID, second.UserID)
+		assert.Equal(t, in.Name, first.Name)
+	})
+
+	t.Run("InsertUser keeps a provided ID", func(t *testing.T) {
+		repo := newRepo(t)
+		in := newUser("Budi")
+		in.UserID = "11111111-1111-4111-8111-111111111111"
+
+		user, err := repo.InsertUser(ctx, in)
+		require.NoError(t, err)
+		assert.Equal(t, in, user)
+
+		_, err = repo.InsertUser(ctx, in)
+		assert.ErrorIs(t, err, repository.ErrUserExists)
+	})
+
+	t.Run("GetUser returns the inserted user", func(t *testing.T) {
+		repo := newRepo(t)
+
+		user, err := repo.InsertUser(ctx, newUser("Budi"))
+		require.NoError(t, err)
+
+		got, err := repo.GetUser(ctx, user.UserID)
+		require.NoError(t, err)
+		assert.Equal(t, user, got)
+	})
+
+	t.Run("GetUser unknown user", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetUser(ctx, "00000000-0000-4000-8000-000000000000")
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+
+	t.Run("MarkUserVerified", func(t *testing.T) {
+		repo := newRepo(t)
+
+		user, err := repo.InsertUser(ctx, newUser("Budi"))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.MarkUserVerified(ctx, user.UserID))
+		require.NoError(t, repo.MarkUserVerified(ctx, user.UserID), "verifying twice is a no-op")
+
+		got, err := repo.GetUser(ctx, user.UserID)
+		require.NoError(t, err)
+		assert.True(t, got.Verified)
+
+		assert.ErrorIs(t, repo.MarkUserVerified(ctx, "00000000-0000-4000-8000-000000000000"), repository.ErrUserNotFound)
+	})
+
+	t.Run("DeleteUser", func(t *testing.T) {
+		repo := newRepo(t)
+
+		user, err := repo.InsertUser(ctx, newUser("Budi"))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteUser(ctx, user.UserID))
+		assert.ErrorIs(t, repo.DeleteUser(ctx, user.UserID), repository.ErrUserNotFound)
+
+		_, err = repo.GetUser(ctx, user.UserID)
+		assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	})
+}
+
+// RunAPIKeyContract runs the contract against repositories returned by
+// newRepo, which must start empty for every call.
+func RunAPIKeyContract(t *testing.T, newRepo func(t *testing.T) repository.APIKeyRepositoryInterface) {
+	ctx := context.Background()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	newKey := func(name, hash string) model.APIKey {
+		return model.APIKey{
+			Name:      name,
+			Prefix:    hash[:8],
+			KeyHash:   hash,
+			Scopes:    []string{"account:create", "user:read"},
+			RateLimit: 60,
+			CreatedAt: created,
+		}
+	}
+
+	t.Run("InsertAPIKey and GetAPIKeyByHash", func(t *testing.T) {
+		repo := newRepo(t)
+		in := newKey("partner", strings.Repeat("a", 64))
+
+		key, err := repo.InsertAPIKey(ctx, in)
+		require.NoError(t, err)
+		assert.NotEmpty(t, key.ID)
+
+		got, err := repo.GetAPIKeyByHash(ctx, in.KeyHash)
+		require.NoError(t, err)
+		assert.Equal(t, key.ID, got.ID)
+		assert.Equal(t, in.Scopes, got.Scopes)
+		assert.Equal(t, in.RateLimit, got.RateLimit)
+		assert.True(t, in.CreatedAt.Equal(got.CreatedAt))
+		assert.Nil(t, got.RevokedAt)
+
+		_, err = repo.GetAPIKeyByHash(ctx, strings.Repeat("b", 64))
+		assert.ErrorIs(t, err, repository.ErrAPIKeyNotFound)
+	})
+
+	t.Run("RevokeAPIKey", func(t *testing.T) {
+		repo := newRepo(t)
+		key, err := repo.InsertAPIKey(ctx, newKey("partner", strings.Repeat("c", 64)))
+		require.NoError(t, err)
+
+		revoked := created.Add(time.Hour)
+		require.NoError(t, repo.RevokeAPIKey(ctx, key.ID, revoked))
+		require.NoError(t, repo.RevokeAPIKey(ctx, key.ID, revoked.Add(time.Hour)), "revoking twice is a no-op")
+
+		got, err := repo.GetAPIKeyByHash(ctx, key.KeyHash)
+		require.NoError(t, err)
+		require.NotNil(t, got.RevokedAt)
+		assert.True(t, revoked.Equal(*got.RevokedAt))
+
+		assert.ErrorIs(t, repo.RevokeAPIKey(ctx, "00000000-0000-4000-8000-000000000000", revoked), repository.ErrAPIKeyNotFound)
+	})
+
+	t.Run("TouchAPIKey and ListAPIKeys", func(t *testing.T) {
+		repo := newRepo(t)
+		first, err := repo.InsertAPIKey(ctx, newKey("first", strings.Repeat("d", 64)))
+		require.NoError(t, err)
+		second := newKey("second", strings.Repeat("e", 64))
+		second.CreatedAt = created.Add(time.Minute)
+		_, err = repo.InsertAPIKey(ctx, second)
+		require.NoError(t, err)
+
+		used := created.Add(2 * time.Hour)
+		require.NoError(t, repo.TouchAPIKey(ctx, first.ID, used))
+
+		keys, err := repo.ListAPIKeys(ctx)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, "first", keys[0].Name)
+		assert.Equal(t, "second", keys[1].Name)
+		require.NotNil(t, keys[0].LastUsedAt)
+		assert.True(t, used.Equal(*keys[0].LastUsedAt))
+		assert.Nil(t, keys[1].LastUsedAt)
+	})
+}
+
+// RunCredentialContract runs the contract against credential repositories
+// returned by newRepo together with a user repository for the users they
+// belong to. Both must start empty for every call.
+func RunCredentialContract(t *testing.T, newRepo func(t *testing.T) (repository.CredentialRepositoryInterface, repository.MysqlRepositoryInterface)) {
+	ctx := context.Background()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	newCredential := func(user model.User) model.Credential {
+		return model.Credential{
+			UserID:       user.UserID,
+			Email:        user.Email,
+			PasswordHash: "$2a$10$" + strings.Repeat("a", 53),
+			UpdatedAt:    created,
+		}
+	}
+
+	t.Run("InsertCredential and GetCredential", func(t *testing.T) {
+		repo, users := newRepo(t)
+		budi := mustInsertUser(t, users, "budi")
+
+		require.NoError(t, repo.InsertCredential(ctx, newCredential(budi)))
+		assert.ErrorIs(t, repo.InsertCredential(ctx, newCredential(budi)), repository.ErrCredentialExists)
+
+		byEmail, err := repo.GetCredentialByEmail(ctx, budi.Email)
+		require.NoError(t, err)
+		assert.Equal(t, budi.UserID, byEmail.UserID)
+		assert.Zero(t, byEmail.FailedAttempts)
+		assert.Nil(t, byEmail.LockedUntil)
+		assert.True(t, created.Equal(byEmail.UpdatedAt))
+
+		byID, err := repo.GetCredentialByUserID(ctx, budi.UserID)
+		require.NoError(t, err)
+		assert.Equal(t, byEmail, byID)
+
+		_, err = repo.GetCredentialByEmail(ctx, "nobody@example.com")
+		assert.ErrorIs(t, err, repository.ErrCredentialNotFound)
+		_, err = repo.GetCredentialByUserID(ctx, "00000000-0000-4000-8000-000000000000")
+		assert.ErrorIs(t, err, repository.ErrCredentialNotFound)
+	})
+
+	t.Run("login failures and lockout", func(t *testing.T) {
+		repo, users := newRepo(t)
+		budi := mustInsertUser(t, users, "budi")
+		require.NoError(t, repo.InsertCredential(ctx, newCredential(budi)))
+
+		for want := 1; want <= 3; want++ {
+			attempts, err := repo.RecordLoginFailure(ctx, budi.UserID)
+			require.NoError(t, err)
+			assert.Equal(t, want, attempts)
+		}
+
+		until := created.Add(15 * time.Minute)
+		require.NoError(t, repo.LockCredential(ctx, budi.UserID, until))
+
+		cred, err := repo.GetCredentialByUserID(ctx, budi.UserID)
+		require.NoError(t, err)
+		assert.Zero(t, cred.FailedAttempts)
+		require.NotNil(t, cred.LockedUntil)
+		assert.True(t, until.Equal(*cred.LockedUntil))
+
+		_, err = repo.RecordLoginFailure(ctx, budi.UserID)
+		require.NoError(t, err)
+		require.NoError(t, repo.ResetLoginFailures(ctx, budi.UserID))
+
+		cred, err = repo.GetCredentialByUserID(ctx, budi.UserID)
+		require.NoError(t, err)
+		assert.Zero(t, cred.FailedAttempts)
+		assert.Nil(t, cred.LockedUntil)
+
+		_, err = repo.RecordLoginFailure(ctx, "00000000-0000-4000-8000-000000000000")
+		assert.ErrorIs(t, err, repository.ErrCredentialNotFound)
+	})
+
+	t.Run("UpdatePassword", func(t *testing.T) {
+		repo, users := newRepo(t)
+		budi := mustInsertUser(t, users, "budi")
+		require.NoError(t, repo.InsertCredential(ctx, newCredential(budi)))
+		require.NoError(t, repo.LockCredential(ctx, budi.UserID, created.Add(time.Hour)))
+
+		changed := created.Add(time.Minute)
+		hash := "$2a$10$" + strings.Repeat("b", 53)
+		require.NoError(t, repo.UpdatePassword(ctx, budi.UserID, hash, changed))
+
+		cred, err := repo.GetCredentialByUserID(ctx, budi.UserID)
+		require.NoError(t, err)
+		assert.Equal(t, hash, cred.PasswordHash)
+		assert.Nil(t, cred.LockedUntil, "a new password lifts the lockout")
+		assert.True(t, changed.Equal(cred.UpdatedAt))
+
+		assert.ErrorIs(t, repo.UpdatePassword(ctx, "00000000-0000-4000-8000-000000000000", hash, changed), repository.ErrCredentialNotFound)
+	})
+
+	t.Run("ResetPassword", func(t *testing.T) {
+		repo, users := newRepo(t)
+		budi := mustInsertUser(t, users, "budi")
+
+		reset := model.PasswordReset{TokenHash: strings.Repeat("a", 64), UserID: budi.UserID, ExpiresAt: created.Add(time.Hour)}
+		require.NoError(t, repo.InsertPasswordReset(ctx, reset))
+		expired := model.PasswordReset{TokenHash: strings.Repeat("b", 64), UserID: budi.UserID, ExpiresAt: created}
+		require.NoError(t, repo.InsertPasswordReset(ctx, expired))
+
+		changed := created.Add(time.Minute)
+		hash := "$2a$10$" + strings.Repeat("b", 53)
+
+		_, err := repo.ResetPassword(ctx, reset.TokenHash, hash, changed)
+		assert.ErrorIs(t, err, repository.ErrCredentialNotFound)
+
+		require.NoError(t, repo.InsertCredential(ctx, newCredential(budi)))
+		require.NoError(t, repo.LockCredential(ctx, budi.UserID, created.Add(time.Hour)))
+
+		userID, err := repo.ResetPassword(ctx, reset.TokenHash, hash, changed)
+		require.NoError(t, err, "a failed reset does not use up the token")
+		assert.Equal(t, budi.UserID, userID)
+
+		cred, err := repo.GetCredentialByUserID(ctx, budi.UserID)
+		require.NoError(t, err)
+		assert.Equal(t, hash, cred.PasswordHash)
+		assert.Nil(t, cred.LockedUntil, "a new password lifts the lockout")
+		assert.True(t, changed.Equal(cred.UpdatedAt))
+
+		_, err = repo.ResetPassword(ctx, reset.TokenHash, hash, changed)
+		assert.ErrorIs(t, err, repository.ErrResetTokenInvalid, "tokens are single use")
+		_, err = repo.ResetPassword(ctx, expired.TokenHash, hash, changed)
+		assert.ErrorIs(t, err, repository.ErrResetTokenInvalid)
+		_, err = repo.ResetPassword(ctx, strings.Repeat("c", 64), hash, created)
+		assert.ErrorIs(t, err, repository.ErrResetTokenInvalid)
+	})
+}
+
+// RunOTPContract runs the contract against OTP repositories returned by
+// newRepo together with a repository for the accounts they belong to. Both
+// must start empty for every call.
+func RunOTPContract(t *testing.T, newRepo func(t *testing.T) (repository.OTPRepositoryInterface, repository.MysqlRepositoryInterface)) {
+	ctx := context.Background()
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	newAccount := func(t *testing.T, accounts repository.MysqlRepositoryInterface, i int) model.Account {
+		t.Helper()
+
+		user := mustInsertUser(t, accounts, fmt.Sprintf("user%d", i))
+		account, err := accounts.InsertAccount(ctx, model.Account{MsisdnCustomer: msisdn(i), UserID: user.UserID, Status: model.AccountPending})
+		require.NoError(t, err)
+		return account
+	}
+
+	t.Run("UpsertOTP and GetOTP", func(t *testing.T) {
+		repo, accounts := newRepo(t)
+		account := newAccount(t, accounts, 1)
+
+		otp := model.AccountOTP{AccountID: account.AccountID, CodeHash: strings.Repeat("a", 64), ExpiresAt: created.Add(5 * time.Minute), CreatedAt: created}
+		require.NoError(t, repo.UpsertOTP(ctx, otp))
+
+		got, err := repo.GetOTP(ctx, account.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, otp.CodeHash, got.CodeHash)
+		assert.Zero(t, got.Attempts)
+		assert.True(t, otp.ExpiresAt.Equal(got.ExpiresAt))
+
+		_, err = repo.RecordOTPFailure(ctx, account.AccountID)
+		require.NoError(t, err)
+
+		otp.CodeHash = strings.Repeat("b", 64)
+		require.NoError(t, repo.UpsertOTP(ctx, otp))
+		got, err = repo.GetOTP(ctx, account.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, otp.CodeHash, got.CodeHash)
+		assert.Zero(t, got.Attempts, "a new code resets the attempts")
+
+		require.NoError(t, repo.DeleteOTP(ctx, account.AccountID))
+		_, err = repo.GetOTP(ctx, account.AccountID)
+		assert.ErrorIs(t, err, repository.ErrOTPNotFound)
+	})
+
+	t.Run("RecordOTPFailure", func(t *testing.T) {
+		repo, accounts := newRepo(t)
+		account := newAccount(t, accounts, 1)
+		require.NoError(t, repo.UpsertOTP(ctx, model.AccountOTP{AccountID: account.AccountID, CodeHash: strings.Repeat("a", 64), ExpiresAt: created, CreatedAt: created}))
+
+		for want := 1; want <= 3; want++ {
+			attempts, err := repo.RecordOTPFailure(ctx, account.AccountID)
+			require.NoError(t, err)
+			assert.Equal(t, want, attempts)
+		}
+
+		_, err := repo.RecordOTPFailure(ctx, "00000000-0000-4000-8000-000000000000")
+		assert.ErrorIs(t, err, repository.ErrOTPNotFound)
+	})
+
+	t.Run("ListExpiredOTPs", func(t *testing.T) {
+		repo, accounts := newRepo(t)
+		first := newAccount(t, accounts, 1)
+		second := newAccount(t, accounts, 2)
+		live := newAccount(t, accounts, 3)
+
+		for i, account := range []model.Account{second, first, live} {
+			expires := created.Add(time.Duration(i) * time.Minute)
+			if account.AccountID == first.AccountID {
+				expires = created.Add(-time.Minute)
+			}
+			if account.AccountID == live.AccountID {
+				expires = created.Add(time.Hour)
+			}
+			require.NoError(t, repo.UpsertOTP(ctx, model.AccountOTP{AccountID: account.AccountID, CodeHash: strings.Repeat("a", 64), ExpiresAt: expires, CreatedAt: created}))
+		}
+
+		ids, err := repo.ListExpiredOTPs(ctx, created)
+		require.NoError(t, err)
+		assert.Equal(t, []string{first.AccountID, second.AccountID}, ids)
+	})
+}
+
+func RunRateLimitContract(t *testing.T, newRepo func(t *testing.T) repository.RateLimitRepositoryInterface) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("UpdateBucket", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var seen []model.RateLimitBucket
+		update := func(b model.RateLimitBucket) model.RateLimitBucket {
+			seen = append(seen, b)
+			return model.RateLimitBucket{Key: b.Key, Tokens: b.Tokens + 1.5, UpdatedAt: now}
+		}
+
+		require.NoError(t, repo.UpdateBucket(ctx, "default:client", update))
+		require.NoError(t, repo.UpdateBucket(ctx, "default:client", update))
+		require.NoError(t, repo.UpdateBucket(ctx, "write:client", update))
+
+		require.Len(t, seen, 3)
+		assert.Equal(t, "default:client", seen[0].Key)
+		assert.Zero(t, seen[0].Tokens)
+		assert.True(t, seen[0].UpdatedAt.IsZero(), "a new bucket has no update time")
+		assert.Equal(t, 1.5, seen[1].Tokens)
+		assert.True(t, now.Equal(seen[1].UpdatedAt))
+		assert.Zero(t, seen[2].Tokens, "buckets are kept per key")
+	})
+
+	t.Run("concurrent UpdateBucket", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, repo.UpdateBucket(ctx, "key", func(b model.RateLimitBucket) model.RateLimitBucket {
+					b.Tokens++
+					b.UpdatedAt = now
+					return b
+				}))
+			}()
+		}
+		wg.Wait()
+
+		var tokens float64
+		require.NoError(t, repo.UpdateBucket(ctx, "key", func(b model.RateLimitBucket) model.RateLimitBucket {
+			tokens = b.Tokens
+			return b
+		}))
+		assert.Equal(t, float64(10), tokens)
+	})
+
+	t.Run("DeleteBucketsBefore", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for i, key := range []string{"old", "older", "fresh"} {
+			updated := now.Add(-time.Duration(i+1) * time.Minute)
+			if key == "fresh" {
+				updated = now
+			}
+			require.NoError(t, repo.UpdateBucket(ctx, key, func(b model.RateLimitBucket) model.RateLimitBucket {
+				return model.RateLimitBucket{Key: key, Tokens: 1, UpdatedAt: updated}
+			}))
+		}
+
+		n, err := repo.DeleteBucketsBefore(ctx, now)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		require.NoError(t, repo.UpdateBucket(ctx, "fresh", func(b model.RateLimitBucket) model.RateLimitBucket {
+			assert.Equal(t, float64(1), b.Tokens, "fresh buckets are kept")
+			return b
+		}))
+		require.NoError(t, repo.UpdateBucket(ctx, "old", func(b model.RateLimitBucket) model.RateLimitBucket {
+			assert.Zero(t, b.Tokens)
+			return b
+		}))
+	})
+}
+
+func newUser(name string) model.User {
+	return model.User{
+		Name:    name,
+		Address: "Jl. Asia Afrika No. 1",
+		Email:   fmt.Sprintf("%s@example.com", name),
+	}
+}
+
+func mustInsertUser(t *testing.T, repo repository.MysqlRepositoryInterface, name string) model.User {
+	t.Helper()
+
+	user, err := repo.InsertUser(context.Background(), newUser(name))
+	require.NoError(t, err)
+	return user
+}
+
+func msisdn(i int) string {
+	return fmt.Sprintf("62812%08d", i)
+}
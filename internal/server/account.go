@@ -0,0 +1,82 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vier21/tefa-ch3/internal/otp"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/usecase"
+)
+
+type verifyAccountRequest struct {
+	Code string `json:"code"`
+}
+
+// VerifyAccountHandler activates a pending account with the code sent to
+// its MSISDN.
+func (a *ApiServer) VerifyAccountHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var req verifyAccountRequest
+	if err := a.decodeJSON(w, r, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	account, err := a.Services.VerifyAccount(r.Context(), chi.URLParam(r, "id"), req.Code)
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repository.ErrAccountNotFound):
+			code = http.StatusNotFound
+		case errors.Is(err, otp.ErrInvalidCode), errors.Is(err, usecase.ErrAccountVerificationDisabled):
+			code = http.StatusBadRequest
+		case errors.Is(err, otp.ErrExpired):
+			code = http.StatusGone
+		case errors.Is(err, otp.ErrTooManyAttempts):
+			code = http.StatusTooManyRequests
+		case errors.Is(err, repository.ErrMsisdnTaken):
+			code = http.StatusConflict
+		}
+		writeServiceError(w, err, code)
+		return
+	}
+
+	writeSuccess(w, account)
+}
+
+// GetAccountHandler returns an account in any status.
+func (a *ApiServer) GetAccountHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	account, err := a.Services.GetAccount(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrAccountNotFound) {
+			code = http.StatusNotFound
+		}
+		writeServiceError(w, err, code)
+		return
+	}
+
+	writeSuccess(w, account)
+}
+
+// ListAccountsHandler returns the accounts of a user.
+func (a *ApiServer) ListAccountsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	accounts, err := a.Services.ListAccounts(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrUserNotFound) {
+			code = http.StatusNotFound
+		}
+		writeServiceError(w, err, code)
+		return
+	}
+
+	writeSuccess(w, accounts)
+}
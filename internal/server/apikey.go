@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vier21/tefa-ch3/internal/apikey"
+	"github.com/vier21/tefa-ch3/internal/auth"
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+type createAPIKeyRequest struct {
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	RateLimit int      `json:"rate_limit"`
+}
+
+type createAPIKeyResponse struct {
+	APIKey model.APIKey `json:"api_key"`
+	Key    string       `json:"key"`
+}
+
+func (a *ApiServer) authenticateAPIKey(w http.ResponseWriter, r *http.Request, key string, next http.Handler) {
+	if a.APIKeys == nil {
+		auth.Unauthorized(w, "api keys are not accepted")
+		return
+	}
+
+	apiKey, err := a.APIKeys.Authenticate(r.Context(), key)
+
+	var limited *apikey.RateLimitError
+	switch {
+	case errors.As(err, &limited):
+		writeTooManyRequests(w, limited.RetryAfter, err.Error())
+		return
+	case errors.Is(err, apikey.ErrInvalidKey), errors.Is(err, apikey.ErrRevoked):
+		auth.Unauthorized(w, err.Error())
+		return
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ctx := authz.WithPrincipal(r.Context(), apikey.Principal(apiKey))
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func writeTooManyRequests(w http.ResponseWriter, retryAfter time.Duration, msg string) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	writeJSONError(w, http.StatusTooManyRequests, msg)
+}
+
+// writeJSONError answers with code and an auth.ErrorResponse body, the same
+// shape as the 401 and 403 answers.
+func writeJSONError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	json.NewEncoder(w).Encode(auth.ErrorResponse{
+		Status: fmt.Sprintf("%s (%d)", http.StatusText(code), code),
+		Error:  msg,
+	})
+}
+
+func (a *ApiServer) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var req createAPIKeyRequest
+	if err := a.decodeJSON(w, r, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	key, plain, err := a.APIKeys.Create(r.Context(), req.Name, req.Scopes, req.RateLimit)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, apikey.ErrInvalidScope) || errors.Is(err, apikey.ErrInvalidName) {
+			code = http.StatusBadRequest
+		}
+		writeJSONError(w, code, err.Error())
+		return
+	}
+
+	writeSuccess(w, createAPIKeyResponse{APIKey: key, Key: plain})
+}
+
+func (a *ApiServer) ListAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	keys, err := a.APIKeys.List(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSuccess(w, keys)
+}
+
+func (a *ApiServer) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+	if err := a.APIKeys.Revoke(r.Context(), id); err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			code = http.StatusNotFound
+		}
+		writeJSONError(w, code, err.Error())
+		return
+	}
+
+	writeSuccess(w, map[string]string{"id": id})
+}
+
+func writeSuccess(w http.ResponseWriter, data interface{}) {
+	res := Response{
+		Status: fmt.Sprintf("Success (%s)", strconv.Itoa(http.StatusOK)),
+		Data:   data,
+	}
+
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		http.Error(w, ErrFetchResp, http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/vier21/tefa-ch3/internal/auth"
+)
+
+// defaultMaxBodyBytes bounds request bodies unless WithHTTP sets another
+// limit.
+const defaultMaxBodyBytes = 1 << 20
+
+// BodyError is a request body decodeJSON refused. Field names the offending
+// JSON field when the error is about a single one.
+type BodyError struct {
+	Code  int
+	Field string
+	Msg   string
+}
+
+func (e *BodyError) Error() string {
+	return ErrReqBodyNotValid + ": " + e.Msg
+}
+
+type bodyErrorResponse struct {
+	auth.ErrorResponse
+	Field string `json:"field,omitempty"`
+}
+
+// decodeJSON decodes exactly one JSON value from the body into v. Bodies
+// larger than the configured limit, unknown fields and trailing data are
+// refused with a *BodyError.
+func (a *ApiServer) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, a.maxBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return toBodyError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return toBodyError(err)
+		}
+		return badRequest("", "unexpected data after the JSON value")
+	}
+	return nil
+}
+
+func toBodyError(err error) *BodyError {
+	var (
+		syntax   *json.SyntaxError
+		typeErr  *json.UnmarshalTypeError
+		tooLarge *http.MaxBytesError
+	)
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return badRequest("", "body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return badRequest("", "malformed JSON")
+	case errors.As(err, &syntax):
+		return badRequest("", fmt.Sprintf("malformed JSON at offset %d", syntax.Offset))
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return badRequest("", fmt.Sprintf("body must be %s", jsonType(typeErr.Type.Kind())))
+		}
+		return badRequest(typeErr.Field, fmt.Sprintf("field %q must be %s", typeErr.Field, jsonType(typeErr.Type.Kind())))
+	case errors.As(err, &tooLarge):
+		return &BodyError{Code: http.StatusRequestEntityTooLarge, Msg: fmt.Sprintf("body exceeds %d bytes", tooLarge.Limit)}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return badRequest(field, fmt.Sprintf("unknown field %q", field))
+	default:
+		return badRequest("", err.Error())
+	}
+}
+
+// jsonType names a Go kind the way API clients know it.
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	default:
+		return "a number"
+	}
+}
+
+func badRequest(field, msg string) *BodyError {
+	return &BodyError{Code: http.StatusBadRequest, Field: field, Msg: msg}
+}
+
+// rejectClientID refuses IDs sent by clients for records the server assigns
+// the ID of.
+func rejectClientID(id string) error {
+	if id == "" {
+		return nil
+	}
+	return badRequest("id", `field "id" is assigned by the server`)
+}
+
+// writeBodyError answers a *BodyError with its code and the offending field.
+func writeBodyError(w http.ResponseWriter, err error) {
+	var bodyErr *BodyError
+	if !errors.As(err, &bodyErr) {
+		bodyErr = badRequest("", err.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(bodyErr.Code)
+
+	json.NewEncoder(w).Encode(bodyErrorResponse{
+		ErrorResponse: auth.ErrorResponse{
+			Status: fmt.Sprintf("%s (%d)", http.StatusText(bodyErr.Code), bodyErr.Code),
+			Error:  bodyErr.Error(),
+		},
+		Field: bodyErr.Field,
+	})
+}
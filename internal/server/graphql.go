@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/vier21/tefa-ch3/internal/graphql"
+	"github.com/vier21/tefa-ch3/internal/ratelimit"
+)
+
+// WithGraphQL serves schema on POST /graphql. Callers authenticate like on
+// the REST routes; anonymous callers may only register themselves.
+func WithGraphQL(schema *graphql.Schema) Option {
+	return func(a *ApiServer) {
+		a.GraphQL = schema
+	}
+}
+
+// GraphQLHandler answers with 200 and the result of the operation, errors
+// included, once the body is a valid GraphQL request. Every mutation field is
+// charged to the write budget of the caller, as if it were sent on its own.
+func (a *ApiServer) GraphQLHandler(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	if err := a.decodeJSON(w, r, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if req.Query == "" {
+		writeBodyError(w, badRequest("query", `field "query" is required`))
+		return
+	}
+
+	ctx := r.Context()
+	if a.Limiter != nil {
+		client := clientKey(r)
+		ctx = graphql.WithMutationLimit(ctx, func(ctx context.Context) (time.Duration, error) {
+			return a.Limiter.Take(ctx, ratelimit.Write, client)
+		})
+	}
+
+	res := a.GraphQL.Exec(ctx, req)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/vier21/tefa-ch3/internal/health"
+)
+
+const readinessTimeout = 2 * time.Second
+
+// HealthzHandler reports that the process is alive. It never touches the
+// databases so a slow dependency does not get the pod restarted.
+func (a *ApiServer) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, health.Report{Status: health.StatusUp})
+}
+
+// ReadyzHandler pings every readiness dependency and answers 503 when any of
+// them is down, so the load balancer stops routing traffic here.
+func (a *ApiServer) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	if a.Draining() {
+		writeHealth(w, health.Report{Status: health.StatusDraining})
+		return
+	}
+
+	writeHealth(w, health.Run(r.Context(), readinessTimeout, a.Checks...))
+}
+
+func writeHealth(w http.ResponseWriter, report health.Report) {
+	w.Header().Add("Content-Type", "application/json")
+	w.Header().Add("Cache-Control", "no-store")
+
+	if !report.Up() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, ErrFetchResp, http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/vier21/tefa-ch3/internal/logging"
+)
+
+// RequestIDHeader carries the request ID. A valid ID sent by the client is
+// kept so calls can be correlated across services.
+const RequestIDHeader = "X-Request-ID"
+
+var requestIDRe = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// requestID tags the request context and its logger with a request ID and
+// echoes it in the response.
+func (a *ApiServer) requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !requestIDRe.MatchString(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		ctx := logging.WithRequestID(logging.WithLogger(r.Context(), a.Logger), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/vier21/tefa-ch3/internal/auth"
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/credential"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type loginResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// writeLoginError answers credential failures with 401, lockouts with 429
+// and weak passwords with 400, all as JSON.
+func writeLoginError(w http.ResponseWriter, err error) {
+	var locked *credential.LockedError
+	switch {
+	case errors.As(err, &locked):
+		writeTooManyRequests(w, locked.RetryAfter, err.Error())
+	case errors.Is(err, credential.ErrInvalidCredentials), errors.Is(err, repository.ErrResetTokenInvalid):
+		auth.Unauthorized(w, err.Error())
+	case errors.Is(err, credential.ErrWeakPassword):
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// LoginHandler exchanges an email and password for an access token. Users
+// logging in with a password always act as customers.
+func (a *ApiServer) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var req loginRequest
+	if err := a.decodeJSON(w, r, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	userID, err := a.Credentials.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	token, expires, err := a.Tokens.Issue(userID, authz.RoleCustomer)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSuccess(w, loginResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(expires).Seconds()),
+	})
+}
+
+func (a *ApiServer) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var req changePasswordRequest
+	if err := a.decodeJSON(w, r, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	principal, ok := authz.PrincipalFrom(r.Context())
+	if !ok {
+		auth.Unauthorized(w, authz.ErrUnauthenticated.Error())
+		return
+	}
+
+	if err := a.Credentials.ChangePassword(r.Context(), principal.Subject, req.CurrentPassword, req.NewPassword); err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	writeSuccess(w, nil)
+}
+
+// ForgotPasswordHandler always succeeds so that it cannot be used to find
+// out which emails are registered.
+func (a *ApiServer) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var req forgotPasswordRequest
+	if err := a.decodeJSON(w, r, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	if err := a.Credentials.RequestReset(r.Context(), req.Email); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSuccess(w, nil)
+}
+
+func (a *ApiServer) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var req resetPasswordRequest
+	if err := a.decodeJSON(w, r, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+
+	if err := a.Credentials.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	writeSuccess(w, nil)
+}
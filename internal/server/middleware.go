@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+	"github.com/vier21/tefa-ch3/config"
+	"github.com/vier21/tefa-ch3/internal/logging"
+)
+
+// MiddlewareConfig configures the optional part of the middleware stack.
+// Request IDs and panic recovery are always on; the zero value adds nothing
+// else.
+type MiddlewareConfig struct {
+	// RequestTimeout bounds the context of every request, 0 means no limit.
+	RequestTimeout time.Duration
+	// RouteTimeouts overrides RequestTimeout per route, keyed by method and
+	// route pattern, e.g. "POST /user".
+	RouteTimeouts map[string]time.Duration
+
+	// CORS is disabled while AllowedOrigins is empty.
+	CORS CORSConfig
+
+	// TrustProxyHeaders takes the client IP from X-Forwarded-For and
+	// X-Real-IP. Only enable it behind a proxy that sets them.
+	TrustProxyHeaders bool
+
+	// GzipLevel compresses responses at the given level, 0 disables it.
+	GzipLevel int
+
+	// AccessLog logs one line per request.
+	AccessLog bool
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+func MiddlewareFromConfig(cfg *config.Config) MiddlewareConfig {
+	return MiddlewareConfig{
+		RequestTimeout: cfg.RequestTimeout,
+		RouteTimeouts:  cfg.RouteTimeouts,
+		CORS: CORSConfig{
+			AllowedOrigins:   cfg.CORSAllowedOrigins,
+			AllowedMethods:   cfg.CORSAllowedMethods,
+			AllowedHeaders:   cfg.CORSAllowedHeaders,
+			AllowCredentials: cfg.CORSAllowCredentials,
+			MaxAge:           cfg.CORSMaxAge,
+		},
+		TrustProxyHeaders: cfg.TrustProxyHeaders,
+		GzipLevel:         cfg.GzipLevel,
+		AccessLog:         cfg.AccessLog,
+	}
+}
+
+// WithMiddleware configures timeouts, CORS, client IPs, compression and
+// access logging.
+func WithMiddleware(cfg MiddlewareConfig) Option {
+	return func(a *ApiServer) {
+		a.middleware = cfg
+	}
+}
+
+// useMiddleware installs the middleware stack. Logging and metrics sit
+// outside the recoverer so they see the 500 of a panicking handler.
+func (a *ApiServer) useMiddleware(r chi.Router, observe ...func(http.Handler) http.Handler) {
+	cfg := a.middleware
+
+	r.Use(a.requestID)
+	if cfg.TrustProxyHeaders {
+		r.Use(middleware.RealIP)
+	}
+	r.Use(observe...)
+	if cfg.AccessLog {
+		r.Use(accessLog)
+	}
+	r.Use(recoverer)
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		r.Use(cors.Handler(cors.Options{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			ExposedHeaders:   []string{RequestIDHeader, "Retry-After"},
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           int(cfg.CORS.MaxAge.Seconds()),
+		}))
+	}
+	if cfg.RequestTimeout > 0 || len(cfg.RouteTimeouts) > 0 {
+		r.Use(a.timeout)
+	}
+	if cfg.GzipLevel > 0 {
+		r.Use(middleware.Compress(cfg.GzipLevel))
+	}
+}
+
+// checkRouteTimeouts warns about RouteTimeouts keys that match no route,
+// which are most likely typos.
+func (a *ApiServer) checkRouteTimeouts() {
+	known := map[string]bool{}
+	chi.Walk(a.Router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		known[method+" "+route] = true
+		return nil
+	})
+
+	for key := range a.middleware.RouteTimeouts {
+		if !known[key] {
+			a.Logger.Warn("route timeout matches no route", "route", key)
+		}
+	}
+}
+
+// recoverer turns a panic in a handler into a JSON 500 and logs it with the
+// stack trace. If the handler had already started its response, the status
+// is out and only the log records the panic.
+func recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// the server aborts the response quietly for this one
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			logging.FromContext(r.Context()).Error("panic serving request",
+				"panic", rec,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"stack", string(debug.Stack()),
+			)
+			if ww.Status() == 0 {
+				writeJSONError(w, http.StatusInternalServerError, "internal server error")
+			}
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// timeout bounds the request context by the timeout of its route. Handlers
+// are expected to give up once the context is done; if one returns without
+// writing anything the client gets a 504.
+func (a *ApiServer) timeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := a.routeTimeout(r)
+		if d <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if ww.Status() == 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			writeJSONError(w, http.StatusGatewayTimeout, "request timed out")
+		}
+	})
+}
+
+func (a *ApiServer) routeTimeout(r *http.Request) time.Duration {
+	if len(a.middleware.RouteTimeouts) > 0 {
+		rctx := chi.NewRouteContext()
+		if a.Router.Match(rctx, r.Method, r.URL.Path) {
+			if d, ok := a.middleware.RouteTimeouts[r.Method+" "+rctx.RoutePattern()]; ok {
+				return d
+			}
+		}
+	}
+	return a.middleware.RequestTimeout
+}
+
+// accessLog logs every request once it has been served.
+func accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := ""
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+
+		logging.FromContext(r.Context()).Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", route,
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"duration", time.Since(start).String(),
+			"remote_addr", r.RemoteAddr,
+			"user_agent", r.UserAgent(),
+		)
+	})
+}
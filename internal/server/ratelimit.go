@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/ratelimit"
+)
+
+// rateLimit charges every request to the given budgets of its client and
+// answers 429 once one is exhausted. Errors of the bucket store let the
+// request through.
+func (a *ApiServer) rateLimit(classes ...ratelimit.Class) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if a.Limiter == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := clientKey(r)
+
+			for _, class := range classes {
+				wait, err := a.Limiter.Take(r.Context(), class, client)
+				if err != nil {
+					logging.FromContext(r.Context()).Error("rate limit store error", "error", err)
+					continue
+				}
+				if wait > 0 {
+					writeTooManyRequests(w, wait, "rate limit exceeded")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey identifies the caller by its authenticated subject, which is
+// "apikey:<id>" for API keys, or else by its IP address.
+func clientKey(r *http.Request) string {
+	if p, ok := authz.PrincipalFrom(r.Context()); ok && p.Subject != "" {
+		return p.Subject
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/ratelimit"
+	"github.com/vier21/tefa-ch3/internal/tracing"
+	"github.com/vier21/tefa-ch3/internal/verification"
+)
+
+// APIVersion is a route tree served under "/<Name>". Several versions are
+// served side by side, e.g. v1 while clients move to v2.
+type APIVersion struct {
+	Name   string
+	Routes func(a *ApiServer, r chi.Router)
+
+	// Deprecated, when set, announces on every response of the version that
+	// it is deprecated since then. Sunset is the date it will be removed.
+	Deprecated time.Time
+	Sunset     time.Time
+}
+
+// V1 is the first resource oriented API.
+var V1 = APIVersion{Name: "v1", Routes: (*ApiServer).routesV1}
+
+// legacyDeprecated is when the unversioned routes were superseded by v1.
+var legacyDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// WithAPIVersion serves another API version next to v1. A version with the
+// name of a registered one replaces it, e.g. to deprecate v1.
+func WithAPIVersion(v APIVersion) Option {
+	return func(a *ApiServer) {
+		for i := range a.versions {
+			if a.versions[i].Name == v.Name {
+				a.versions[i] = v
+				return
+			}
+		}
+		a.versions = append(a.versions, v)
+	}
+}
+
+// Routes registers every endpoint on the router. NewServer calls it, so the
+// router returned by Handler is the one served in production.
+func (a *ApiServer) Routes() {
+	r := a.NewRouter()
+	observe := []func(http.Handler) http.Handler{tracing.Middleware}
+	if a.Metrics != nil {
+		observe = append(observe, a.Metrics.Middleware)
+	}
+	a.useMiddleware(r, observe...)
+
+	r.Get("/healthz", a.HealthzHandler)
+	r.Get("/readyz", a.ReadyzHandler)
+	r.Get("/openapi.json", a.OpenAPIHandler)
+	r.Get("/docs", a.DocsHandler)
+
+	if a.GraphQL != nil {
+		r.With(a.authenticateOptional, a.rateLimit(ratelimit.Default)).Post("/graphql", a.GraphQLHandler)
+	}
+
+	// the link is mailed to users, so it stays unversioned
+	if a.Verifier != nil {
+		r.With(a.rateLimit(ratelimit.Default)).Get(verification.Path, a.VerifyUserHandler)
+	}
+
+	for _, v := range a.versions {
+		v := v
+		r.Route("/"+v.Name, func(r chi.Router) {
+			if !v.Deprecated.IsZero() {
+				r.Use(deprecated(v.Deprecated, v.Sunset, ""))
+			}
+			v.Routes(a, r)
+		})
+	}
+
+	a.legacyRoutes(r)
+}
+
+func (a *ApiServer) routesV1(r chi.Router) {
+	r.With(a.authenticateOptional, a.rateLimit(ratelimit.Default, ratelimit.Write)).Post("/users", a.RegisterUserHandler)
+
+	if a.Credentials != nil && a.Tokens != nil {
+		r.Route("/auth", a.authRoutes)
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(a.authenticate)
+		r.Use(a.rateLimit(ratelimit.Default))
+
+		r.With(a.Policy.Require(authz.UserRead)).Get("/users/{id}", a.GetUserMysqlHandler)
+		r.With(a.Policy.Require(authz.AccountRead)).Get("/users/{id}/accounts", a.ListAccountsHandler)
+		r.With(a.rateLimit(ratelimit.Write), a.Policy.Require(authz.AccountCreate)).Post("/accounts", a.RegisterAccountHandler)
+		r.With(a.Policy.Require(authz.AccountRead)).Get("/accounts/{id}", a.GetAccountHandler)
+		r.With(a.Policy.Require(authz.AccountRead)).Get("/accounts/{id}/user", a.GetUserByAccountIDHandler)
+		r.With(a.Policy.Require(authz.AccountCreate)).Post("/accounts/{id}/verify", a.VerifyAccountHandler)
+
+		if a.APIKeys != nil {
+			r.Route("/apikeys", a.apiKeyRoutes)
+		}
+	})
+}
+
+func (a *ApiServer) authRoutes(r chi.Router) {
+	r.Use(a.rateLimit(ratelimit.Default))
+
+	r.Post("/login", a.LoginHandler)
+	r.Post("/password/forgot", a.ForgotPasswordHandler)
+	r.Post("/password/reset", a.ResetPasswordHandler)
+	r.With(a.authenticate).Post("/password", a.ChangePasswordHandler)
+}
+
+func (a *ApiServer) apiKeyRoutes(r chi.Router) {
+	r.Use(a.Policy.Require(authz.APIKeyManage))
+
+	r.Post("/", a.CreateAPIKeyHandler)
+	r.Get("/", a.ListAPIKeysHandler)
+	r.Delete("/{id}", a.RevokeAPIKeyHandler)
+}
+
+// legacyRoutes keeps the routes from before v1 working. Every response
+// points to its v1 successor.
+func (a *ApiServer) legacyRoutes(r chi.Router) {
+	moved := func(successor string) func(http.Handler) http.Handler {
+		return deprecated(legacyDeprecated, time.Time{}, successor)
+	}
+
+	r.With(moved("/v1/users"), a.authenticateOptional, a.rateLimit(ratelimit.Default, ratelimit.Write)).Post("/user", a.RegisterUserHandler)
+
+	if a.Credentials != nil && a.Tokens != nil {
+		r.With(moved("/v1/*")).Route("/auth", a.authRoutes)
+	}
+
+	r.Group(func(r chi.Router) {
+		r.Use(a.authenticate)
+		r.Use(a.rateLimit(ratelimit.Default))
+
+		r.With(moved("/v1/users/{id}"), a.Policy.Require(authz.UserRead)).Get("/{id}/user/mysql", a.GetUserMysqlHandler)
+		r.With(moved("/v1/users/{id}"), a.Policy.Require(authz.UserRead)).Get("/{id}/user/mongo", a.GetUserMongoHandler)
+		r.With(moved("/v1/accounts"), a.rateLimit(ratelimit.Write), a.Policy.Require(authz.AccountCreate)).Post("/account", a.RegisterAccountHandler)
+		r.With(moved("/v1/accounts/{id}/verify"), a.Policy.Require(authz.AccountCreate)).Post("/account/{id}/verify", a.VerifyAccountHandler)
+		r.With(moved("/v1/accounts/{id}/user"), a.Policy.Require(authz.AccountRead)).Get("/{id}/account", a.GetUserByAccountIDHandler)
+
+		if a.APIKeys != nil {
+			r.With(moved("/v1/*")).Route("/apikeys", a.apiKeyRoutes)
+		}
+	})
+}
+
+var successorParamRe = regexp.MustCompile(`\{(\w+)\}`)
+
+// deprecated sets the Deprecation header of RFC 9745, the Sunset header of
+// RFC 8594 if sunset is set and a successor-version link if successor is
+// set. URL parameters in successor are filled in from the request; a
+// trailing "/*" stands for the whole request path.
+func deprecated(since, sunset time.Time, successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(since.Unix(), 10))
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			if successor != "" {
+				w.Header().Set("Link", "<"+successorPath(r, successor)+`>; rel="successor-version"`)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func successorPath(r *http.Request, successor string) string {
+	if prefix, ok := strings.CutSuffix(successor, "/*"); ok {
+		return prefix + r.URL.Path
+	}
+
+	return successorParamRe.ReplaceAllStringFunc(successor, func(param string) string {
+		return chi.URLParam(r, param[1:len(param)-1])
+	})
+}
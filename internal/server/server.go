@@ -2,24 +2,145 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/vier21/tefa-ch3/internal/apikey"
+	"github.com/vier21/tefa-ch3/internal/auth"
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/credential"
+	"github.com/vier21/tefa-ch3/internal/graphql"
+	"github.com/vier21/tefa-ch3/internal/health"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/metrics"
 	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/ratelimit"
+	"github.com/vier21/tefa-ch3/internal/repository"
 	"github.com/vier21/tefa-ch3/internal/usecase"
+	"github.com/vier21/tefa-ch3/internal/verification"
 )
 
 type ApiServer struct {
-	Services usecase.UserInterface
-	Router   *chi.Mux
-	Server   *http.Server
+	Services    usecase.UserInterface
+	Router      *chi.Mux
+	Server      *http.Server
+	Checks      []health.Checker
+	Tokens      *auth.TokenService
+	Policy      authz.Policy
+	APIKeys     *apikey.Service
+	Credentials *credential.Service
+	Verifier    *verification.Service
+	Limiter     *ratelimit.Limiter
+	Logger      *slog.Logger
+	Metrics     *metrics.Metrics
+	Certs       *CertReloader
+	GraphQL     *graphql.Schema
+
+	middleware      MiddlewareConfig
+	disableHTTP2    bool
+	maxBodyBytes    int64
+	versions        []APIVersion
+	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+	shutdownHooks   []func(ctx context.Context) error
+
+	draining      atomic.Bool
+	workers       sync.WaitGroup
+	workerCtx     context.Context
+	cancelWorkers context.CancelFunc
+}
+
+type Option func(*ApiServer)
+
+// WithReadinessChecks registers the dependencies probed by /readyz.
+func WithReadinessChecks(checks ...health.Checker) Option {
+	return func(a *ApiServer) {
+		a.Checks = append(a.Checks, checks...)
+	}
+}
+
+// WithAuth sets the token service that verifies bearer tokens on every
+// endpoint except health checks and user registration.
+func WithAuth(tokens *auth.TokenService) Option {
+	return func(a *ApiServer) {
+		a.Tokens = tokens
+	}
+}
+
+// WithAPIKeys enables X-API-Key authentication and the /apikeys management
+// endpoints.
+func WithAPIKeys(keys *apikey.Service) Option {
+	return func(a *ApiServer) {
+		a.APIKeys = keys
+	}
+}
+
+// WithCredentials enables password login and the /auth endpoints. Tokens are
+// issued by the service set with WithAuth.
+func WithCredentials(credentials *credential.Service) Option {
+	return func(a *ApiServer) {
+		a.Credentials = credentials
+	}
+}
+
+// WithVerification enables the email verification link endpoint.
+func WithVerification(verifier *verification.Service) Option {
+	return func(a *ApiServer) {
+		a.Verifier = verifier
+	}
+}
+
+// WithRateLimit throttles every endpoint except health checks per client.
+func WithRateLimit(limiter *ratelimit.Limiter) Option {
+	return func(a *ApiServer) {
+		a.Limiter = limiter
+	}
+}
+
+// WithLogger sets the logger of the server, handed to every request and
+// background worker through the context. slog.Default is used otherwise.
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *ApiServer) {
+		a.Logger = logger
+	}
+}
+
+// WithMetrics records every request. The metrics are not served on the API
+// port; see metrics.Metrics.Serve.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(a *ApiServer) {
+		a.Metrics = m
+	}
+}
+
+// WithPolicy replaces authz.DefaultPolicy for the per-route permission
+// checks.
+func WithPolicy(policy authz.Policy) Option {
+	return func(a *ApiServer) {
+		a.Policy = policy
+	}
+}
+
+// WithAddr sets the address the server listens on, ":3001" by default.
+func WithAddr(addr string) Option {
+	return func(a *ApiServer) {
+		if addr != "" {
+			a.Server.Addr = addr
+		}
+	}
 }
 
 type Response struct {
@@ -33,61 +154,159 @@ var (
 	ErrReqBodyNotValid = "request body not valid"
 )
 
-func NewServer(usersvc usecase.UserInterface) *ApiServer {
+func NewServer(usersvc usecase.UserInterface, opts ...Option) *ApiServer {
 	mux := chi.NewRouter()
-
-	return &ApiServer{
-		Services: usersvc,
-		Router:   mux,
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+
+	a := &ApiServer{
+		Services:        usersvc,
+		Router:          mux,
+		Policy:          authz.DefaultPolicy,
+		Logger:          slog.Default(),
+		shutdownTimeout: 30 * time.Second,
+		maxBodyBytes:    defaultMaxBodyBytes,
+		versions:        []APIVersion{V1},
+		workerCtx:       workerCtx,
+		cancelWorkers:   cancelWorkers,
 		Server: &http.Server{
-			Addr:         ":3001",
-			Handler:      mux,
-			IdleTimeout:  120 * time.Second,
-			WriteTimeout: 1 * time.Second,
-			ReadTimeout:  1 * time.Second,
+			Addr:              ":3001",
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
 		},
 	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.Certs != nil {
+		a.Server.TLSConfig = a.Certs.ServerConfig(!a.disableHTTP2)
+	}
+	if a.disableHTTP2 {
+		a.Server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	a.workerCtx = logging.WithLogger(a.workerCtx, a.Logger)
+
+	a.Routes()
+	a.checkRouteTimeouts()
+
+	return a
 }
 
 func (a *ApiServer) NewRouter() *chi.Mux {
 	return a.Router
 }
 
-func (a *ApiServer) Run() {
-	r := a.NewRouter()
+// authenticate accepts either an API key in the X-API-Key header or a JWT
+// bearer token.
+func (a *ApiServer) authenticate(next http.Handler) http.Handler {
+	jwt := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.Unauthorized(w, "authentication is not configured")
+	})
+	if a.Tokens != nil {
+		jwt = a.Tokens.Middleware(next).ServeHTTP
+	}
 
-	r.Post("/user", a.RegisterUserHandler)
-	r.Get("/{id}/user/mysql", a.GetUserMysqlHandler)
-	r.Get("/{id}/user/mongo", a.GetUserMongoHandler)
-	r.Post("/account", a.RegisterAccountHandler)
-	r.Get("/{accountID}/account", a.GetUserByAccountIDHandler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(apikey.Header); key != "" {
+			a.authenticateAPIKey(w, r, key, next)
+			return
+		}
+		jwt.ServeHTTP(w, r)
+	})
+}
 
-	go func() {
-		log.Printf("Server start on localhost%s \n", ":3001")
-		err := a.Server.ListenAndServe()
-		if err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server Error: %s \n", err)
+// authenticateOptional lets anonymous callers through, e.g. for self
+// sign-up, while still identifying callers that send credentials.
+func (a *ApiServer) authenticateOptional(next http.Handler) http.Handler {
+	authenticated := a.authenticate(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(apikey.Header) == "" && r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
 		}
-	}()
+		authenticated.ServeHTTP(w, r)
+	})
+}
 
-	a.GracefullShutdown()
+func (a *ApiServer) Handler() http.Handler {
+	return a.Router
+}
+
+// Run serves until SIGINT or SIGTERM and then shuts down gracefully.
+func (a *ApiServer) Run() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := a.Start(ctx); err != nil {
+		a.Logger.Error("server error", "error", err)
+		os.Exit(1)
+	}
 
+	a.Logger.Info("server stopped gracefully")
 }
 
-func (a *ApiServer) GracefullShutdown() {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+// Start listens on the configured address and serves until ctx is done, then
+// shuts down gracefully. When the server cannot listen or serve, background
+// workers are stopped and shutdown hooks run before the error is returned.
+func (a *ApiServer) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", a.Server.Addr)
+	if err != nil {
+		return a.abort(err)
+	}
+
+	return a.Serve(ctx, ln)
+}
+
+// Serve is like Start but accepts connections on an existing listener.
+func (a *ApiServer) Serve(ctx context.Context, ln net.Listener) error {
+	errCh := make(chan error, 1)
+	go func() {
+		a.Logger.Info("server started", "addr", ln.Addr().String(), "tls", a.Certs != nil)
+		if a.Certs != nil {
+			errCh <- a.Server.ServeTLS(ln, "", "")
+			return
+		}
+		errCh <- a.Server.Serve(ln)
+	}()
 
-	<-quit
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return a.abort(err)
+	case <-ctx.Done():
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	a.Logger.Info("shutting down")
+	if err := a.Shutdown(context.Background()); err != nil {
+		return err
+	}
 
-	if err := a.Server.Shutdown(ctx); err != nil {
-		log.Fatal(err)
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
 	}
+	return nil
+}
 
-	log.Println("Server stopped gracefully")
+// writeServiceError answers authorization failures from the usecase with
+// JSON 401/403, requests that ran out of time with 504 and any other error
+// with code.
+func writeServiceError(w http.ResponseWriter, err error, code int) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeJSONError(w, http.StatusGatewayTimeout, "request timed out")
+	case errors.Is(err, authz.ErrUnauthenticated):
+		auth.Unauthorized(w, err.Error())
+	case errors.Is(err, authz.ErrForbidden):
+		auth.Forbidden(w, err.Error())
+	default:
+		writeJSONError(w, code, err.Error())
+	}
 }
 
 func (s *ApiServer) GetUserMongoHandler(w http.ResponseWriter, r *http.Request) {
@@ -98,7 +317,7 @@ func (s *ApiServer) GetUserMongoHandler(w http.ResponseWriter, r *http.Request)
 	user, err := s.Services.GetUserDataMongo(r.Context(), id)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeServiceError(w, err, http.StatusBadRequest)
 		return
 	}
 
@@ -121,15 +340,26 @@ func (s *ApiServer) RegisterUserHandler(w http.ResponseWriter, r *http.Request)
 	w.Header().Add("Content-Type", "application/json")
 
 	var req model.User
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, ErrReqBodyNotValid, http.StatusBadRequest)
+	if err := s.decodeJSON(w, r, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if err := rejectClientID(req.UserID); err != nil {
+		writeBodyError(w, err)
 		return
 	}
 
 	reg, err := s.Services.RegisterUser(r.Context(), req)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, credential.ErrWeakPassword):
+			code = http.StatusBadRequest
+		case errors.Is(err, repository.ErrCredentialExists):
+			code = http.StatusConflict
+		}
+		writeServiceError(w, err, code)
 		return
 	}
 
@@ -155,7 +385,11 @@ func (a *ApiServer) GetUserMysqlHandler(w http.ResponseWriter, r *http.Request)
 
 	user, err := a.Services.GetUserByID(r.Context(), userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		code := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrUserNotFound) {
+			code = http.StatusNotFound
+		}
+		writeServiceError(w, err, code)
 		return
 	}
 
@@ -177,14 +411,29 @@ func (a *ApiServer) RegisterAccountHandler(w http.ResponseWriter, r *http.Reques
 	w.Header().Add("Content-Type", "application/json")
 
 	var req model.Account
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, ErrReqBodyNotValid, http.StatusBadRequest)
+	if err := a.decodeJSON(w, r, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if err := rejectClientID(req.AccountID); err != nil {
+		writeBodyError(w, err)
 		return
 	}
+	// the status is decided by the usecase, not the caller
+	req.Status = ""
 
 	account, err := a.Services.RegisterAccount(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			code = http.StatusNotFound
+		case errors.Is(err, repository.ErrMsisdnTaken):
+			code = http.StatusConflict
+		case errors.Is(err, repository.ErrMsisdnLimit):
+			code = http.StatusUnprocessableEntity
+		}
+		writeServiceError(w, err, code)
 		return
 	}
 
@@ -206,12 +455,15 @@ func (a *ApiServer) RegisterAccountHandler(w http.ResponseWriter, r *http.Reques
 func (s *ApiServer) GetUserByAccountIDHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 
-	accountIDStr := chi.URLParam(r, "accountID")
-	accountID := accountIDStr // Sesuaikan tipe data accountID sesuai perubahan di model.go
+	accountID := chi.URLParam(r, "id")
 
 	user, err := s.Services.GetUserByAccountID(r.Context(), accountID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		code := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrAccountNotFound) || errors.Is(err, repository.ErrUserNotFound) {
+			code = http.StatusNotFound
+		}
+		writeServiceError(w, err, code)
 		return
 	}
 
@@ -228,3 +480,32 @@ func (s *ApiServer) GetUserByAccountIDHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 }
+
+// VerifyUserHandler handles the link from the verification email. The token
+// identifies the user, so no other authentication is needed.
+func (a *ApiServer) VerifyUserHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	userID, err := a.Verifier.Check(r.URL.Query().Get("token"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := authz.WithPrincipal(r.Context(), authz.Principal{
+		Subject: userID,
+		Roles:   []string{authz.RoleCustomer},
+	})
+
+	user, err := a.Services.VerifyUser(ctx, userID)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrUserNotFound) {
+			code = http.StatusBadRequest
+		}
+		writeServiceError(w, err, code)
+		return
+	}
+
+	writeSuccess(w, user)
+}
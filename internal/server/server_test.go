@@ -2,159 +2,1374 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
-	"github.com/vier21/tefa-ch3/db"
+	"github.com/vier21/tefa-ch3/internal/apikey"
+	"github.com/vier21/tefa-ch3/internal/auth"
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/credential"
+	"github.com/vier21/tefa-ch3/internal/graphql"
+	"github.com/vier21/tefa-ch3/internal/health"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/mail"
+	"github.com/vier21/tefa-ch3/internal/metrics"
 	"github.com/vier21/tefa-ch3/internal/model"
-	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/otp"
+	"github.com/vier21/tefa-ch3/internal/ratelimit"
+	"github.com/vier21/tefa-ch3/internal/repository/memory"
+	"github.com/vier21/tefa-ch3/internal/sms"
 	"github.com/vier21/tefa-ch3/internal/usecase"
+	"github.com/vier21/tefa-ch3/internal/verification"
+	"golang.org/x/crypto/bcrypt"
 )
 
-func TestGetUserMongoHandler(t *testing.T) {
+type testEnv struct {
+	server *ApiServer
+	ts     *httptest.Server
+	mysql  *memory.MysqlRepository
+	mongo  *memory.MongoRepository
+	tokens *auth.TokenService
+	token  string
+	keys   *apikey.Service
+	apiKey string
+	mail   string
+	sms    *sms.Fake
+}
 
-	db.InitMongoDB()
-	db.InitMysqlDB()
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
 
-	mysqlRepo := repository.NewMysqlRepository()
-	mongoRepo := repository.NewMongoRepository()
-	usecase := usecase.NewUserUsecase(mysqlRepo, mongoRepo)
+	credentials, err := credential.NewService(memory.NewCredentialRepository(), credential.LogNotifier{}, credential.Config{
+		MaxAttempts: 3,
+		Lockout:     time.Minute,
+		ResetTTL:    time.Hour,
+		BcryptCost:  bcrypt.MinCost,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	server := NewServer(usecase)
+	tokenConfig := auth.Config{
+		Keys:     []auth.Key{{ID: "test", Secret: []byte("test-secret")}},
+		Issuer:   "tefa-ch3",
+		Audience: "tefa-ch3",
+	}
+	tokens, err := auth.NewTokenService(tokenConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	req, err := http.NewRequest("GET", "http://localhost:3001/42307263-2b56-45d5-9f86-db0fcb93958b/user/mongo", nil)
+	mailDir := t.TempDir()
+	verifyConfig := tokenConfig
+	verifyConfig.Audience += verification.Path
+	verifier, err := verification.NewService(verifyConfig, mail.NewFileMailer(mailDir, "no-reply@example.com"), "http://localhost")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	rr := httptest.NewRecorder()
-	r := server.NewRouter()
-	r.HandleFunc("/{id}/user/mongo", server.GetUserMongoHandler)
+	mysqlRepo := memory.NewMysqlRepository()
+	mongoRepo := memory.NewMongoRepository()
+	gateway := &sms.Fake{}
+	otps, err := otp.NewService(mysqlRepo, memory.NewOTPRepository(), gateway, otp.Config{Length: 6, TTL: time.Minute, MaxAttempts: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	usecase := usecase.NewAuthorizedUsecase(
+		usecase.NewVerificationUsecase(
+			usecase.NewCredentialUsecase(usecase.NewOTPUsecase(usecase.NewUserUsecase(mysqlRepo, mongoRepo), otps), credentials),
+			verifier,
+		),
+		authz.DefaultPolicy,
+	)
+	token, _, err := tokens.Issue("test-admin", authz.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := apikey.NewService(memory.NewAPIKeyRepository(), []string{string(authz.UserRead), string(authz.AccountRead)})
+
+	schema, err := graphql.NewSchema(usecase, graphql.Config{MaxDepth: 12, MaxComplexity: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(usecase, WithAuth(tokens), WithAPIKeys(keys), WithCredentials(credentials), WithVerification(verifier), WithGraphQL(schema))
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
 
-	r.ServeHTTP(rr, req)
-	assert.Equal(t, http.StatusOK, rr.Code, "status code should be %d but got %d", http.StatusOK, rr.Code)
+	return &testEnv{
+		server: server,
+		ts:     ts,
+		mysql:  mysqlRepo,
+		mongo:  mongoRepo,
+		tokens: tokens,
+		token:  token,
+		keys:   keys,
+		mail:   mailDir,
+		sms:    gateway,
+	}
 }
 
-func TestGetUserMysqlHandler(t *testing.T) {
-	db.InitMongoDB()
-	db.InitMysqlDB()
+// as switches the caller to subject with roles for the following requests.
+func (e *testEnv) as(t *testing.T, subject string, roles ...string) {
+	t.Helper()
 
-	mysqlRepo := repository.NewMysqlRepository()
-	mongoRepo := repository.NewMongoRepository()
-	usecase := usecase.NewUserUsecase(mysqlRepo, mongoRepo)
+	token, _, err := e.tokens.Issue(subject, roles...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.token = token
+}
 
-	server := NewServer(usecase)
+func (e *testEnv) do(t *testing.T, method, path string, body interface{}) (*http.Response, Response) {
+	t.Helper()
 
-	req, err := http.NewRequest("GET", "http://localhost:3001/14ba3470-e99c-4ae5-aeba-0d875258dbf5/user/mysql", nil)
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, e.ts.URL+path, &buf)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+	if e.apiKey != "" {
+		req.Header.Set(apikey.Header, e.apiKey)
+	}
 
-	rr := httptest.NewRecorder()
-	r := server.NewRouter()
-	r.HandleFunc("/{id}/user/mysql", server.GetUserMysqlHandler)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var res Response
+	if resp.StatusCode == http.StatusOK {
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	}
 
-	r.ServeHTTP(rr, req)
-	assert.Equal(t, http.StatusOK, rr.Code, "status code should be %d but got %d", http.StatusOK, rr.Code)
+	return resp, res
 }
 
-func TestGetUserByAccountIDHandler(t *testing.T) {
-	db.InitMongoDB()
-	db.InitMysqlDB()
+// verifyLink returns the path of the verification link mailed to email.
+func (e *testEnv) verifyLink(t *testing.T, email string) string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(e.mail, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		raw, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(raw, []byte("To: "+email+"\r\n")) {
+			continue
+		}
 
-	mysqlRepo := repository.NewMysqlRepository()
-	mongoRepo := repository.NewMongoRepository()
-	usecase := usecase.NewUserUsecase(mysqlRepo, mongoRepo)
+		link, err := url.Parse(regexp.MustCompile(`http://localhost/\S+`).FindString(string(raw)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return link.RequestURI()
+	}
+
+	t.Fatalf("no mail sent to %s", email)
+	return ""
+}
 
-	server := NewServer(usecase)
+// otpCode returns the verification code last texted to msisdn.
+func (e *testEnv) otpCode(t *testing.T, msisdn string) string {
+	t.Helper()
 
-	req, err := http.NewRequest("GET", "http://localhost:3001/90cb8ccb-8c52-42fe-9e6a-b03d9933b6e5/account", nil)
+	msg, ok := e.sms.Last(msisdn)
+	if !ok {
+		t.Fatalf("no code sent to %s", msisdn)
+	}
+	return regexp.MustCompile(`\d{6}`).FindString(msg.Text)
+}
+
+func decodeData(t *testing.T, res Response, v interface{}) {
+	t.Helper()
+
+	raw, err := json.Marshal(res.Data)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		t.Fatal(err)
+	}
+}
 
-	rr := httptest.NewRecorder()
-	r := server.NewRouter()
-	r.HandleFunc("/{accountID}/account", server.GetUserByAccountIDHandler)
+func TestGetUserMongoHandler(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mongo.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+
+	resp, res := env.do(t, "GET", "/"+user.UserID+"/user/mongo", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "status code should be %d but got %d", http.StatusOK, resp.StatusCode)
+
+	var got model.User
+	decodeData(t, res, &got)
+	assert.Equal(t, user, got)
 
-	r.ServeHTTP(rr, req)
-	assert.Equal(t, http.StatusOK, rr.Code, "status code should be %d but got %d", http.StatusOK, rr.Code)
+	resp, _ = env.do(t, "GET", "/does-not-exist/user/mongo", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
 
-func TestRegisterUserHandler(t *testing.T) {
+func TestGetUserMysqlHandler(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+
+	resp, res := env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "status code should be %d but got %d", http.StatusOK, resp.StatusCode)
+
+	var got model.User
+	decodeData(t, res, &got)
+	assert.Equal(t, user, got)
+
+	resp, _ = env.do(t, "GET", "/does-not-exist/user/mysql", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+}
+
+func TestGetUserByAccountIDHandler(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+	account, err := env.mysql.InsertAccount(context.Background(), model.Account{MsisdnCustomer: "6281234567890", UserID: user.UserID})
+	assert.NoError(t, err)
+
+	resp, res := env.do(t, "GET", "/"+account.AccountID+"/account", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "status code should be %d but got %d", http.StatusOK, resp.StatusCode)
 
-	db.InitMongoDB()
-	db.InitMysqlDB()
+	var got model.User
+	decodeData(t, res, &got)
+	assert.Equal(t, user, got)
 
-	mysqlRepo := repository.NewMysqlRepository()
-	mongoRepo := repository.NewMongoRepository()
-	usecase := usecase.NewUserUsecase(mysqlRepo, mongoRepo)
+	resp, _ = env.do(t, "GET", "/does-not-exist/account", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
 
-	server := NewServer(usecase)
+func TestRegisterUserHandler(t *testing.T) {
+	env := newTestEnv(t)
 	usr := model.User{
 		Name:    "sdasd",
 		Address: "sdasd",
 		Email:   "sdasd",
 	}
 
-	jsonBytes, err := json.Marshal(usr)
-	if err != nil {
-		panic(err)
+	resp, res := env.do(t, "POST", "/user", usr)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "status code should be %d but got %d", http.StatusOK, resp.StatusCode)
+
+	var got usecase.Result
+	decodeData(t, res, &got)
+
+	stored, err := env.mysql.GetUserByID(context.Background(), got.UserMysql.UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, usr.Name, stored.Name)
+
+	stored, err = env.mongo.GetUser(context.Background(), got.UserMongo.UserID)
+	assert.NoError(t, err)
+	assert.Equal(t, usr.Name, stored.Name)
+}
+
+func TestRegisterUserHandler_InvalidBody(t *testing.T) {
+	env := newTestEnv(t)
+
+	resp, _ := env.do(t, "POST", "/user", "not a user")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestStrictJSONBody(t *testing.T) {
+	env := newTestEnv(t)
+	server := NewServer(env.server.Services, WithAuth(env.tokens), WithHTTP(HTTPConfig{MaxBodyBytes: 256}))
+
+	tests := []struct {
+		name  string
+		path  string
+		body  string
+		code  int
+		field string
+	}{
+		{name: "empty", path: "/user", body: "", code: http.StatusBadRequest},
+		{name: "malformed", path: "/user", body: `{"name": "Budi",}`, code: http.StatusBadRequest},
+		{name: "unknown field", path: "/user", body: `{"name": "Budi", "role": "admin"}`, code: http.StatusBadRequest, field: "role"},
+		{name: "wrong type", path: "/user", body: `{"name": 42}`, code: http.StatusBadRequest, field: "name"},
+		{name: "trailing data", path: "/user", body: `{"name": "Budi"} {"name": "Ani"}`, code: http.StatusBadRequest},
+		{name: "client user ID", path: "/user", body: `{"id": "1", "name": "Budi"}`, code: http.StatusBadRequest, field: "id"},
+		{name: "client account ID", path: "/account", body: `{"id": "1", "msisdn_customer": "08123456789"}`, code: http.StatusBadRequest, field: "id"},
+		{name: "too large", path: "/user", body: `{"name": "` + strings.Repeat("a", 300) + `"}`, code: http.StatusRequestEntityTooLarge},
 	}
 
-	// Create a bytes.Buffer from the JSON bytes
-	jsonBuffer := bytes.NewBuffer(jsonBytes)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+env.token)
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
 
-	req, err := http.NewRequest("POST", "http://localhost:3001/user", jsonBuffer)
-	if err != nil {
-		panic(err)
+			assert.Equal(t, tt.code, rr.Code)
+			var res bodyErrorResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+			assert.Contains(t, res.Error, ErrReqBodyNotValid)
+			assert.Equal(t, tt.field, res.Field)
+		})
 	}
+}
 
+func TestRegisterAccountHandler(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
 
-	rr := httptest.NewRecorder()
-	r := server.NewRouter()
-	r.Post("/user", server.RegisterUserHandler)
+	acc := model.Account{
+		MsisdnCustomer: "xxxxx",
+		UserID:         user.UserID,
+	}
+
+	resp, res := env.do(t, "POST", "/account", acc)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "status code should be %d but got %d", http.StatusOK, resp.StatusCode)
 
-	r.ServeHTTP(rr, req)
-	assert.Equal(t, http.StatusOK, rr.Code, "status code should be %d but got %d", http.StatusOK, rr.Code)
+	var got model.Account
+	decodeData(t, res, &got)
+	assert.NotEmpty(t, got.AccountID)
+	assert.Equal(t, acc.MsisdnCustomer, got.MsisdnCustomer)
+	assert.Equal(t, model.AccountPending, got.Status)
+
+	_, err = env.mysql.GetUserByAccountID(context.Background(), got.AccountID)
+	assert.Error(t, err, "pending accounts are not resolved")
+
+	resp, _ = env.do(t, "POST", "/account/"+got.AccountID+"/verify", verifyAccountRequest{Code: env.otpCode(t, acc.MsisdnCustomer)})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	owner, err := env.mysql.GetUserByAccountID(context.Background(), got.AccountID)
+	assert.NoError(t, err)
+	assert.Equal(t, user, owner)
 }
 
-func TestRegisterAccountHandler(t *testing.T) {
+func TestVerifyAccountHandler(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
 
-	db.InitMongoDB()
-	db.InitMysqlDB()
+	env.as(t, user.UserID, authz.RoleCustomer)
+	resp, res := env.do(t, "POST", "/account", model.Account{MsisdnCustomer: "6281234567890", UserID: user.UserID, Status: model.AccountActive})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var account model.Account
+	decodeData(t, res, &account)
+	assert.Equal(t, model.AccountPending, account.Status, "accounts cannot be created active")
 
-	mysqlRepo := repository.NewMysqlRepository()
-	mongoRepo := repository.NewMongoRepository()
-	usecase := usecase.NewUserUsecase(mysqlRepo, mongoRepo)
+	code := env.otpCode(t, account.MsisdnCustomer)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	path := "/account/" + account.AccountID + "/verify"
 
-	server := NewServer(usecase)
-	usr := model.Account{
-		MsisdnCustomer: "xxxxx",
-		UserID: "bf53c3d8-aa04-4064-a1e1-f3c2c4072edc",
+	resp, _ = env.do(t, "POST", path, verifyAccountRequest{Code: wrong})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	env.as(t, "someone-else", authz.RoleCustomer)
+	resp, _ = env.do(t, "POST", path, verifyAccountRequest{Code: code})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	env.as(t, user.UserID, authz.RoleCustomer)
+	resp, res = env.do(t, "POST", path, verifyAccountRequest{Code: code})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	decodeData(t, res, &account)
+	assert.Equal(t, model.AccountActive, account.Status)
+
+	resp, _ = env.do(t, "POST", "/account/does-not-exist/verify", verifyAccountRequest{Code: code})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	for i := 0; i < 3; i++ {
+		resp, res = env.do(t, "POST", "/account", model.Account{MsisdnCustomer: "6281234567891", UserID: user.UserID})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		decodeData(t, res, &account)
+		for j := 0; j < 2; j++ {
+			env.do(t, "POST", "/account/"+account.AccountID+"/verify", verifyAccountRequest{Code: "wrong"})
+		}
+		resp, _ = env.do(t, "POST", "/account/"+account.AccountID+"/verify", verifyAccountRequest{Code: "wrong"})
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "the MSISDN is released after too many attempts")
 	}
+}
 
-	jsonBytes, err := json.Marshal(usr)
-	if err != nil {
-		panic(err)
+func TestRegisterAccountHandler_Errors(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+
+	for _, msisdn := range []string{"1", "2", "3"} {
+		resp, _ := env.do(t, "POST", "/account", model.Account{MsisdnCustomer: msisdn, UserID: user.UserID})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
 	}
 
-	// Create a bytes.Buffer from the JSON bytes
-	jsonBuffer := bytes.NewBuffer(jsonBytes)
+	resp, _ := env.do(t, "POST", "/account", model.Account{MsisdnCustomer: "4", UserID: user.UserID})
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp, _ = env.do(t, "POST", "/v1/accounts", model.Account{MsisdnCustomer: "1", UserID: "does-not-exist"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	other, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Sari", Address: "Bogor", Email: "sari@example.com"})
+	assert.NoError(t, err)
+	resp, _ = env.do(t, "POST", "/v1/accounts", model.Account{MsisdnCustomer: "1", UserID: other.UserID})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "pending accounts do not hold their MSISDN")
+
+	_, err = env.mysql.InsertAccount(context.Background(), model.Account{MsisdnCustomer: "9", UserID: other.UserID})
+	assert.NoError(t, err)
+	late, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Ani", Address: "Depok", Email: "ani@example.com"})
+	assert.NoError(t, err)
+	resp, _ = env.do(t, "POST", "/v1/accounts", model.Account{MsisdnCustomer: "9", UserID: late.UserID})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestV1Routes(t *testing.T) {
+	env := newTestEnv(t)
+
+	resp, res := env.do(t, "POST", "/v1/users", model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+	var reg usecase.Result
+	decodeData(t, res, &reg)
+	userID := reg.UserMysql.UserID
+
+	resp, res = env.do(t, "GET", "/v1/users/"+userID+"/accounts", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var accounts []model.Account
+	decodeData(t, res, &accounts)
+	assert.Empty(t, accounts)
+
+	resp, res = env.do(t, "POST", "/v1/accounts", model.Account{MsisdnCustomer: "6281234567890", UserID: userID})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var account model.Account
+	decodeData(t, res, &account)
+
+	resp, res = env.do(t, "GET", "/v1/accounts/"+account.AccountID, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got model.Account
+	decodeData(t, res, &got)
+	assert.Equal(t, model.AccountPending, got.Status)
+
+	resp, _ = env.do(t, "POST", "/v1/accounts/"+account.AccountID+"/verify", verifyAccountRequest{Code: env.otpCode(t, account.MsisdnCustomer)})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, res = env.do(t, "GET", "/v1/accounts/"+account.AccountID+"/user", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var owner model.User
+	decodeData(t, res, &owner)
+	assert.Equal(t, userID, owner.UserID)
+
+	resp, res = env.do(t, "GET", "/v1/users/"+userID+"/accounts", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	decodeData(t, res, &accounts)
+	if assert.Len(t, accounts, 1) {
+		assert.Equal(t, model.AccountActive, accounts[0].Status)
+	}
+
+	resp, _ = env.do(t, "GET", "/v1/users/"+userID, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = env.do(t, "GET", "/v1/accounts/does-not-exist", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = env.do(t, "GET", "/v1/users/does-not-exist/accounts", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 
-	req, err := http.NewRequest("POST", "http://localhost:3001/user", jsonBuffer)
+	env.as(t, "someone-else", authz.RoleCustomer)
+	resp, _ = env.do(t, "GET", "/v1/users/"+userID+"/accounts", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestLegacyRoutes(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+	account, err := env.mysql.InsertAccount(context.Background(), model.Account{MsisdnCustomer: "6281234567890", UserID: user.UserID})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		method    string
+		path      string
+		successor string
+	}{
+		{"GET", "/" + user.UserID + "/user/mysql", "/v1/users/" + user.UserID},
+		{"GET", "/" + user.UserID + "/user/mongo", "/v1/users/" + user.UserID},
+		{"GET", "/" + account.AccountID + "/account", "/v1/accounts/" + account.AccountID + "/user"},
+		{"POST", "/auth/login", "/v1/auth/login"},
+		{"GET", "/apikeys/", "/v1/apikeys/"},
+	}
+
+	for _, tt := range tests {
+		resp, _ := env.do(t, tt.method, tt.path, nil)
+		assert.Equal(t, "@"+strconv.FormatInt(legacyDeprecated.Unix(), 10), resp.Header.Get("Deprecation"), tt.path)
+		assert.Equal(t, "<"+tt.successor+`>; rel="successor-version"`, resp.Header.Get("Link"), tt.path)
+	}
+}
+
+func TestAPIVersions(t *testing.T) {
+	env := newTestEnv(t)
+	sunset := time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	v1 := V1
+	v1.Deprecated = time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	v1.Sunset = sunset
+	v2 := APIVersion{Name: "v2", Routes: func(a *ApiServer, r chi.Router) {
+		r.Get("/users/{id}", a.GetUserMysqlHandler)
+	}}
+
+	server := NewServer(env.server.Services, WithAuth(env.tokens), WithAPIVersion(v1), WithAPIVersion(v2))
+	env.ts = httptest.NewServer(server.Handler())
+	t.Cleanup(env.ts.Close)
+
+	resp, _ := env.do(t, "GET", "/v1/users/does-not-exist", nil)
+	assert.Equal(t, "@"+strconv.FormatInt(v1.Deprecated.Unix(), 10), resp.Header.Get("Deprecation"))
+	assert.Equal(t, "Thu, 01 Apr 2027 00:00:00 GMT", resp.Header.Get("Sunset"))
+
+	resp, _ = env.do(t, "GET", "/v2/users/does-not-exist", nil)
+	assert.NotEqual(t, http.StatusNotFound, resp.StatusCode, "v2 is served next to v1")
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	env := newTestEnv(t)
+	server := NewServer(env.server.Services,
+		WithAuth(env.tokens),
+		WithAPIKeys(env.keys),
+		WithCredentials(env.server.Credentials),
+		WithVerification(env.server.Verifier),
+		WithGraphQL(env.server.GraphQL),
+		WithMetrics(metrics.New()),
+	)
+
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatal(err)
+	}
+	assert.Regexp(t, `^3\.`, spec.OpenAPI)
+
+	routes := map[string]bool{}
+	err := chi.Walk(server.Router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes[strings.ToLower(method)+" "+route] = true
+
+		op, ok := spec.Paths[route][strings.ToLower(method)]
+		if !assert.True(t, ok, "%s %s is missing from openapi.json", method, route) {
+			return nil
+		}
+		for _, m := range regexp.MustCompile(`\{(\w+)\}`).FindAllStringSubmatch(route, -1) {
+			declared := false
+			for _, p := range op.Parameters {
+				declared = declared || (p.In == "path" && p.Name == m[1])
+			}
+			assert.True(t, declared, "%s %s does not declare the path parameter %s", method, route, m[1])
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			assert.True(t, routes[method+" "+path], "openapi.json documents %s %s, which is not routed", method, path)
+		}
+	}
+
+	// every reference resolves
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(openAPISpec, &doc))
+	for _, m := range regexp.MustCompile(`"\$ref": "#/components/(\w+)/(\w+)"`).FindAllStringSubmatch(string(openAPISpec), -1) {
+		components := doc["components"].(map[string]interface{})[m[1]].(map[string]interface{})
+		assert.Contains(t, components, m[2])
+	}
+
+	rr := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(openAPISpec), rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	server.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/docs", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `url: "/openapi.json"`)
+
+	tags := regexp.MustCompile(`<(?:script|link)[^>]*https://[^>]*>`).FindAllString(rr.Body.String(), -1)
+	assert.Len(t, tags, 2)
+	for _, tag := range tags {
+		assert.Regexp(t, `@\d+\.\d+\.\d+/`, tag, "CDN assets are pinned to a release")
+		assert.Contains(t, tag, `integrity="sha384-`)
+		assert.Contains(t, tag, `crossorigin="anonymous"`)
+	}
+}
+
+// TestOpenAPISpec_ErrorResponses sends every route a request it refuses and
+// checks that the answer is documented, down to its content type.
+func TestOpenAPISpec_ErrorResponses(t *testing.T) {
+	env := newTestEnv(t)
+	server := NewServer(env.server.Services,
+		WithAuth(env.tokens),
+		WithAPIKeys(env.keys),
+		WithCredentials(env.server.Credentials),
+		WithVerification(env.server.Verifier),
+		WithGraphQL(env.server.GraphQL),
+		WithReadinessChecks(health.CheckFunc("down", func(context.Context) error { return errors.New("down") })),
+	)
+	admin, _, err := env.tokens.Issue("test-admin", authz.RoleAdmin)
 	if err != nil {
-		panic(err)
+		t.Fatal(err)
+	}
+
+	var spec struct {
+		Paths      map[string]map[string]struct{ Responses map[string]json.RawMessage } `json:"paths"`
+		Components struct{ Responses map[string]json.RawMessage }                       `json:"components"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatal(err)
+	}
+	// mediaTypes returns the content types documented by a response object
+	mediaTypes := func(raw json.RawMessage) []string {
+		var res struct {
+			Ref     string                     `json:"$ref"`
+			Content map[string]json.RawMessage `json:"content"`
+		}
+		assert.NoError(t, json.Unmarshal(raw, &res))
+		if res.Ref != "" {
+			assert.NoError(t, json.Unmarshal(spec.Components.Responses[strings.TrimPrefix(res.Ref, "#/components/responses/")], &res))
+		}
+		var types []string
+		for mt := range res.Content {
+			types = append(types, mt)
+		}
+		return types
 	}
 
+	tests := []struct {
+		route string
+		path  string
+		auth  bool
+		body  string
+	}{
+		{"POST /account", "/account", true, `{"msisdn_customer":"1","user_id":"does-not-exist"}`},
+		{"POST /account/{id}/verify", "/account/does-not-exist/verify", true, `{"code":"123456"}`},
+		{"GET /apikeys/", "/apikeys/", false, ""},
+		{"POST /apikeys/", "/apikeys/", false, `{"name":"billing"}`},
+		{"DELETE /apikeys/{id}", "/apikeys/does-not-exist", false, ""},
+		{"POST /auth/login", "/auth/login", false, `{"email":"nobody@example.com","password":"long enough password"}`},
+		{"POST /auth/password", "/auth/password", false, `{}`},
+		{"POST /auth/password/forgot", "/auth/password/forgot", false, `{`},
+		{"POST /auth/password/reset", "/auth/password/reset", false, `{`},
+		{"POST /graphql", "/graphql", false, `{`},
+		{"GET /readyz", "/readyz", false, ""},
+		{"POST /user", "/user", false, `{"user_id":"chosen"}`},
+		{"GET /user/verify", "/user/verify", false, ""},
+		{"POST /v1/accounts", "/v1/accounts", true, `{"msisdn_customer":"1","user_id":"does-not-exist"}`},
+		{"GET /v1/accounts/{id}", "/v1/accounts/does-not-exist", true, ""},
+		{"GET /v1/accounts/{id}/user", "/v1/accounts/does-not-exist/user", true, ""},
+		{"POST /v1/accounts/{id}/verify", "/v1/accounts/does-not-exist/verify", true, `{"code":"123456"}`},
+		{"GET /v1/apikeys/", "/v1/apikeys/", false, ""},
+		{"POST /v1/apikeys/", "/v1/apikeys/", true, `{`},
+		{"DELETE /v1/apikeys/{id}", "/v1/apikeys/does-not-exist", true, ""},
+		{"POST /v1/auth/login", "/v1/auth/login", false, `{"email":"nobody@example.com","password":"long enough password"}`},
+		{"POST /v1/auth/password", "/v1/auth/password", false, `{}`},
+		{"POST /v1/auth/password/forgot", "/v1/auth/password/forgot", false, `{`},
+		{"POST /v1/auth/password/reset", "/v1/auth/password/reset", false, `{"token":"unknown","password":"long enough password"}`},
+		{"POST /v1/users", "/v1/users", false, `{"user_id":"chosen"}`},
+		{"GET /v1/users/{id}", "/v1/users/does-not-exist", true, ""},
+		{"GET /v1/users/{id}/accounts", "/v1/users/does-not-exist/accounts", true, ""},
+		{"GET /{id}/account", "/does-not-exist/account", true, ""},
+		{"GET /{id}/user/mongo", "/does-not-exist/user/mongo", true, ""},
+		{"GET /{id}/user/mysql", "/does-not-exist/user/mysql", true, ""},
+	}
+
+	covered := map[string]bool{}
+	for _, tt := range tests {
+		t.Run(tt.route, func(t *testing.T) {
+			covered[tt.route] = true
+			method, route, _ := strings.Cut(tt.route, " ")
+
+			req := httptest.NewRequest(method, tt.path, strings.NewReader(tt.body))
+			if tt.auth {
+				req.Header.Set("Authorization", "Bearer "+admin)
+			}
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+			assert.GreaterOrEqual(t, rr.Code, 400)
+
+			doc, ok := spec.Paths[route][strings.ToLower(method)].Responses[strconv.Itoa(rr.Code)]
+			if !assert.True(t, ok, "%d is not documented", rr.Code) {
+				return
+			}
+			contentType, _, _ := strings.Cut(rr.Header().Get("Content-Type"), ";")
+			assert.Contains(t, mediaTypes(doc), contentType)
+		})
+	}
+
+	// every route documenting an error is covered above
+	for route, ops := range spec.Paths {
+		for method, op := range ops {
+			for code := range op.Responses {
+				if code >= "400" {
+					assert.True(t, covered[strings.ToUpper(method)+" "+route], "no error case for %s %s", method, route)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+
+	query := map[string]interface{}{
+		"query":     `query ($id: ID!) { user(id: $id) { name accounts { id } } }`,
+		"variables": map[string]string{"id": user.UserID},
+	}
+	resp, res := env.do(t, "POST", "/graphql", query)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, map[string]interface{}{
+		"user": map[string]interface{}{"name": "Budi", "accounts": []interface{}{}},
+	}, res.Data)
+
+	// anonymous callers may only register themselves
+	env.token = ""
+	_, res = env.do(t, "POST", "/graphql", query)
+	assert.Equal(t, map[string]interface{}{"user": nil}, res.Data)
+
+	_, res = env.do(t, "POST", "/graphql", map[string]interface{}{
+		"query": `mutation { registerUser(input: {name: "Ani", address: "Jakarta", email: "ani@example.com"}) { name } }`,
+	})
+	assert.Equal(t, map[string]interface{}{"registerUser": map[string]interface{}{"name": "Ani"}}, res.Data)
+
+	for name, body := range map[string]interface{}{
+		"no query":      map[string]interface{}{},
+		"unknown field": map[string]interface{}{"query": "{ __typename }", "root": "x"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, _ := env.do(t, "POST", "/graphql", body)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+
+	env.token = ""
+	resp, _ := env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	env.token = "not-a-jwt"
+	resp, _ = env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	env.token = ""
+	resp, _ = env.do(t, "POST", "/user", model.User{Name: "Sari", Address: "Jakarta", Email: "sari@example.com"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "registration stays public")
+}
+
+func TestAuthorization(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	budi, err := env.mysql.InsertUser(ctx, model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+	sari, err := env.mysql.InsertUser(ctx, model.User{Name: "Sari", Address: "Jakarta", Email: "sari@example.com"})
+	assert.NoError(t, err)
+	_, err = env.mongo.InsertUser(ctx, budi)
+	assert.NoError(t, err)
+	_, err = env.mongo.InsertUser(ctx, sari)
+	assert.NoError(t, err)
+	sariAccount, err := env.mysql.InsertAccount(ctx, model.Account{MsisdnCustomer: "6281200000001", UserID: sari.UserID})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		roles  []string
+		method string
+		path   string
+		body   interface{}
+		code   int
+	}{
+		{"admin reads any user", []string{authz.RoleAdmin}, "GET", "/" + sari.UserID + "/user/mysql", nil, http.StatusOK},
+		{"admin adds account for any user", []string{authz.RoleAdmin}, "POST", "/account", model.Account{MsisdnCustomer: "6281200000002", UserID: sari.UserID}, http.StatusOK},
+		{"admin registers user", []string{authz.RoleAdmin}, "POST", "/user", model.User{Name: "Eko"}, http.StatusOK},
+
+		{"customer reads own user", []string{authz.RoleCustomer}, "GET", "/" + budi.UserID + "/user/mysql", nil, http.StatusOK},
+		{"customer reads own mongo user", []string{authz.RoleCustomer}, "GET", "/" + budi.UserID + "/user/mongo", nil, http.StatusOK},
+		{"customer reads other user", []string{authz.RoleCustomer}, "GET", "/" + sari.UserID + "/user/mysql", nil, http.StatusForbidden},
+		{"customer reads other mongo user", []string{authz.RoleCustomer}, "GET", "/" + sari.UserID + "/user/mongo", nil, http.StatusForbidden},
+		{"customer adds own account", []string{authz.RoleCustomer}, "POST", "/account", model.Account{MsisdnCustomer: "6281200000003", UserID: budi.UserID}, http.StatusOK},
+		{"customer adds account for other", []string{authz.RoleCustomer}, "POST", "/account", model.Account{MsisdnCustomer: "6281200000004", UserID: sari.UserID}, http.StatusForbidden},
+		{"customer reads other account", []string{authz.RoleCustomer}, "GET", "/" + sariAccount.AccountID + "/account", nil, http.StatusNotFound},
+		{"customer reads other account like a missing one", []string{authz.RoleCustomer}, "GET", "/v1/accounts/" + sariAccount.AccountID, nil, http.StatusNotFound},
+		{"customer adds account without owner", []string{authz.RoleCustomer}, "POST", "/account", model.Account{MsisdnCustomer: "6281200000006"}, http.StatusForbidden},
+		{"customer registers user", []string{authz.RoleCustomer}, "POST", "/user", model.User{Name: "Eko"}, http.StatusOK},
+
+		{"support reads any user", []string{authz.RoleSupport}, "GET", "/" + sari.UserID + "/user/mongo", nil, http.StatusOK},
+		{"support reads any account", []string{authz.RoleSupport}, "GET", "/" + sariAccount.AccountID + "/account", nil, http.StatusOK},
+		{"support cannot add account", []string{authz.RoleSupport}, "POST", "/account", model.Account{MsisdnCustomer: "6281200000005", UserID: sari.UserID}, http.StatusForbidden},
+
+		{"no role", nil, "GET", "/" + budi.UserID + "/user/mysql", nil, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env.as(t, budi.UserID, tt.roles...)
+
+			resp, _ := env.do(t, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.code, resp.StatusCode)
+		})
+	}
+}
+
+// TestAuthorization_HiddenAccounts checks that accounts of other owners
+// cannot be told apart from missing ones.
+func TestAuthorization_HiddenAccounts(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	sari, err := env.mysql.InsertUser(ctx, model.User{Name: "Sari", Address: "Jakarta", Email: "sari@example.com"})
+	assert.NoError(t, err)
+	account, err := env.mysql.InsertAccount(ctx, model.Account{MsisdnCustomer: "6281200000001", UserID: sari.UserID})
+	assert.NoError(t, err)
+
+	env.as(t, "someone-else", authz.RoleCustomer)
+	for _, path := range []string{"/%s/account", "/v1/accounts/%s", "/v1/accounts/%s/user"} {
+		hidden, hiddenRes := env.do(t, "GET", fmt.Sprintf(path, account.AccountID), nil)
+		missing, missingRes := env.do(t, "GET", fmt.Sprintf(path, "does-not-exist"), nil)
+
+		assert.Equal(t, http.StatusNotFound, hidden.StatusCode, path)
+		assert.Equal(t, missing.StatusCode, hidden.StatusCode, path)
+		assert.Equal(t, missingRes, hiddenRes, path)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+
+	resp, res := env.do(t, "POST", "/apikeys", createAPIKeyRequest{Name: "billing", Scopes: []string{string(authz.UserRead)}, RateLimit: 3})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var created createAPIKeyResponse
+	decodeData(t, res, &created)
+	assert.NotEmpty(t, created.Key)
+
+	resp, _ = env.do(t, "POST", "/apikeys", createAPIKeyRequest{Name: "billing", Scopes: []string{string(authz.APIKeyManage)}})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, res = env.do(t, "GET", "/apikeys", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var keys []model.APIKey
+	decodeData(t, res, &keys)
+	assert.Len(t, keys, 1)
+
+	env.token = ""
+	env.apiKey = created.Key
+
+	resp, _ = env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = env.do(t, "POST", "/account", model.Account{MsisdnCustomer: "6281200000001", UserID: user.UserID})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "key is not scoped for accounts")
+
+	resp, _ = env.do(t, "GET", "/apikeys", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "keys cannot manage keys")
+
+	resp, _ = env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	env.apiKey = "tefa_unknown"
+	resp, _ = env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	env.apiKey = ""
+	env.as(t, "test-admin", authz.RoleAdmin)
+	resp, _ = env.do(t, "DELETE", "/apikeys/"+created.APIKey.ID, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	env.token = ""
+	env.apiKey = created.Key
+	resp, _ = env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestRateLimit(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+
+	limiter := ratelimit.NewLimiter(memory.NewRateLimitRepository(), ratelimit.Config{Default: 3, Write: 1})
+	server := NewServer(env.server.Services, WithAuth(env.tokens), WithRateLimit(limiter), WithGraphQL(env.server.GraphQL))
+	env.ts = httptest.NewServer(server.Handler())
+	t.Cleanup(env.ts.Close)
+
+	for i := 0; i < 3; i++ {
+		resp, _ := env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	resp, _ := env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "20", resp.Header.Get("Retry-After"))
+
+	resp, _ = env.do(t, "GET", "/healthz", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "health checks are not limited")
+
+	env.as(t, user.UserID, authz.RoleCustomer)
+	resp, _ = env.do(t, "POST", "/account", model.Account{MsisdnCustomer: "6281234567890", UserID: user.UserID})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "subjects have their own budget")
+	resp, _ = env.do(t, "POST", "/account", model.Account{MsisdnCustomer: "6281234567891", UserID: user.UserID})
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "writes have a separate, smaller budget")
+	assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+
+	resp, _ = env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	env.token = ""
+	resp, _ = env.do(t, "POST", "/user", model.User{Name: "Sari", Address: "Jakarta", Email: "sari@example.com"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = env.do(t, "POST", "/user", model.User{Name: "Sari", Address: "Jakarta", Email: "sari@example.com"})
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "anonymous callers are limited by IP")
+
+	body := strings.NewReader(`{"query": "mutation { registerUser(input: {name: \"Ani\", address: \"Jakarta\", email: \"ani@example.com\"}) { name } }"}`)
+	resp, err = http.Post(env.ts.URL+"/graphql", "application/json", body)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	var gql struct {
+		Data   interface{}
+		Errors []struct{ Extensions struct{ Code string } }
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&gql))
+	assert.Nil(t, gql.Data)
+	if assert.Len(t, gql.Errors, 1) {
+		assert.Equal(t, "RATE_LIMITED", gql.Errors[0].Extensions.Code, "graphql mutations are charged to the write budget")
+	}
+}
+
+func TestLogin(t *testing.T) {
+	env := newTestEnv(t)
+	env.token = ""
+
+	resp, _ := env.do(t, "POST", "/user", model.User{Name: "Budi", Email: "budi@example.com", Password: "short"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, res := env.do(t, "POST", "/user", model.User{Name: "Budi", Email: "budi@example.com", Password: "s3cret-pass"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var reg usecase.Result
+	decodeData(t, res, &reg)
+	assert.Empty(t, reg.UserMysql.Password)
+
+	resp, _ = env.do(t, "POST", "/user", model.User{Name: "Budi", Email: "budi@example.com", Password: "s3cret-pass"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, 1, env.mysql.UserCount(), "no second user is registered")
+	assert.Equal(t, 1, env.mongo.UserCount(), "no second user is registered")
+
+	resp, res = env.do(t, "POST", "/auth/login", loginRequest{Email: "budi@example.com", Password: "s3cret-pass"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var login loginResponse
+	decodeData(t, res, &login)
+	assert.Equal(t, "Bearer", login.TokenType)
+
+	env.token = login.AccessToken
+	resp, _ = env.do(t, "GET", "/"+reg.UserMysql.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "the token grants access to the own user")
+
+	resp, _ = env.do(t, "POST", "/auth/password", changePasswordRequest{CurrentPassword: "wrong-pass", NewPassword: "n3w-password"})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp, _ = env.do(t, "POST", "/auth/password", changePasswordRequest{CurrentPassword: "s3cret-pass", NewPassword: "short"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"), "weak passwords are refused as JSON")
+	resp, _ = env.do(t, "POST", "/auth/password", changePasswordRequest{CurrentPassword: "s3cret-pass", NewPassword: "n3w-password"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	env.token = ""
+	resp, _ = env.do(t, "POST", "/auth/password/forgot", forgotPasswordRequest{Email: "nobody@example.com"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = env.do(t, "POST", "/auth/password/reset", resetPasswordRequest{Token: "unknown", Password: "an0ther-password"})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	for i := 0; i < 2; i++ {
+		resp, _ = env.do(t, "POST", "/auth/login", loginRequest{Email: "budi@example.com", Password: "s3cret-pass"})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+	resp, _ = env.do(t, "POST", "/auth/login", loginRequest{Email: "budi@example.com", Password: "s3cret-pass"})
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+
+	resp, _ = env.do(t, "POST", "/auth/login", loginRequest{Email: "budi@example.com", Password: "n3w-password"})
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "locked out")
+}
+
+func TestVerifyUserHandler(t *testing.T) {
+	env := newTestEnv(t)
+	env.token = ""
+
+	resp, res := env.do(t, "POST", "/user", model.User{Name: "Budi", Email: "budi@example.com", Verified: true})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var reg usecase.Result
+	decodeData(t, res, &reg)
+	assert.False(t, reg.UserMysql.Verified, "users cannot register as verified")
+
+	link := env.verifyLink(t, "budi@example.com")
+
+	resp, res = env.do(t, "GET", link, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var user model.User
+	decodeData(t, res, &user)
+	assert.True(t, user.Verified)
+
+	mongoUser, err := env.mongo.GetUser(context.Background(), reg.UserMysql.UserID)
+	assert.NoError(t, err)
+	assert.True(t, mongoUser.Verified)
+
+	resp, _ = env.do(t, "GET", link, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "following the link twice is harmless")
+
+	resp, _ = env.do(t, "GET", "/user/verify?token="+env.token, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	env.as(t, reg.UserMysql.UserID, authz.RoleCustomer)
+	resp, _ = env.do(t, "GET", "/user/verify?token="+env.token, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "access tokens do not verify")
+}
+
+func TestRequestID(t *testing.T) {
+	env := newTestEnv(t)
+
+	var logs bytes.Buffer
+	server := NewServer(env.server.Services, WithAuth(env.tokens), WithLogger(logging.New(&logs, slog.LevelInfo)))
+	env.ts = httptest.NewServer(server.Handler())
+	t.Cleanup(env.ts.Close)
+
+	resp, _ := env.do(t, "GET", "/healthz", nil)
+	generated := resp.Header.Get(RequestIDHeader)
+	assert.Regexp(t, `^[0-9a-f-]{36}$`, generated)
+
+	req, err := http.NewRequest("GET", env.ts.URL+"/does-not-exist/user/mongo", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+env.token)
+	req.Header.Set(RequestIDHeader, "client-req-1")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "client-req-1", resp.Header.Get(RequestIDHeader))
+
+	var line map[string]interface{}
+	assert.NoError(t, json.NewDecoder(&logs).Decode(&line))
+	assert.Equal(t, "error retrieving user", line["msg"])
+	assert.Equal(t, "client-req-1", line["request_id"], "usecase logs carry the request ID")
+
+	req.Header.Set(RequestIDHeader, "not a valid id")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.NotEqual(t, "not a valid id", resp.Header.Get(RequestIDHeader))
+}
+
+func TestMiddleware(t *testing.T) {
+	env := newTestEnv(t)
+
+	var logs bytes.Buffer
+	server := NewServer(env.server.Services,
+		WithLogger(logging.New(&logs, slog.LevelInfo)),
+		WithMiddleware(MiddlewareConfig{
+			RequestTimeout:    time.Hour,
+			RouteTimeouts:     map[string]time.Duration{"GET /slow": 20 * time.Millisecond},
+			CORS:              CORSConfig{AllowedOrigins: []string{"https://app.example.com"}},
+			TrustProxyHeaders: true,
+			GzipLevel:         5,
+			AccessLog:         true,
+		}),
+	)
+	server.Router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	server.Router.Get("/panic-after-write", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("partial"))
+		panic("boom")
+	})
+	server.Router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	server.Router.Get("/deadline", func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ := r.Context().Deadline()
+		writeSuccess(w, map[string]interface{}{"remaining": time.Until(deadline).String(), "ip": r.RemoteAddr})
+	})
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		logs.Reset()
+		rr := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rr, req)
+		return rr
+	}
+	logLines := func() []map[string]interface{} {
+		var lines []map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(logs.Bytes()))
+		for dec.More() {
+			var line map[string]interface{}
+			assert.NoError(t, dec.Decode(&line))
+			lines = append(lines, line)
+		}
+		return lines
+	}
+
+	t.Run("panic answers JSON 500", func(t *testing.T) {
+		rr := serve(httptest.NewRequest("GET", "/panic", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		var res auth.ErrorResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		assert.Equal(t, "internal server error", res.Error)
+
+		lines := logLines()
+		if assert.Len(t, lines, 2) {
+			assert.Equal(t, "panic serving request", lines[0]["msg"])
+			assert.Equal(t, "boom", lines[0]["panic"])
+			assert.NotEmpty(t, lines[0]["request_id"])
+			assert.Equal(t, "request", lines[1]["msg"])
+			assert.EqualValues(t, http.StatusInternalServerError, lines[1]["status"])
+		}
+	})
+
+	t.Run("panic after the response started", func(t *testing.T) {
+		rr := serve(httptest.NewRequest("GET", "/panic-after-write", nil))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Equal(t, "partial", rr.Body.String())
+
+		lines := logLines()
+		if assert.Len(t, lines, 2) {
+			assert.Equal(t, "panic serving request", lines[0]["msg"])
+			assert.EqualValues(t, http.StatusAccepted, lines[1]["status"])
+		}
+	})
+
+	t.Run("route timeout", func(t *testing.T) {
+		rr := serve(httptest.NewRequest("GET", "/slow", nil))
+
+		assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+		var res auth.ErrorResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		assert.Equal(t, "request timed out", res.Error)
+	})
+
+	t.Run("default timeout and real IP", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/deadline", nil)
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		rr := serve(req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var res struct {
+			Data struct {
+				Remaining string `json:"remaining"`
+				IP        string `json:"ip"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+		remaining, err := time.ParseDuration(res.Data.Remaining)
+		assert.NoError(t, err)
+		assert.InDelta(t, time.Hour, remaining, float64(time.Minute))
+		assert.Equal(t, "203.0.113.7", res.Data.IP)
+
+		lines := logLines()
+		if assert.Len(t, lines, 1) {
+			assert.Equal(t, "/deadline", lines[0]["route"])
+			assert.Equal(t, "203.0.113.7", lines[0]["remote_addr"])
+		}
+	})
+
+	t.Run("CORS preflight", func(t *testing.T) {
+		req := httptest.NewRequest("OPTIONS", "/user", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		rr := serve(req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+
+		req.Header.Set("Origin", "https://evil.example.com")
+		rr = serve(req)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/healthz", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rr := serve(req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	})
+}
+
+func TestWriteServiceError_Timeout(t *testing.T) {
+	rr := httptest.NewRecorder()
+	writeServiceError(rr, fmt.Errorf("get user: %w", context.DeadlineExceeded), http.StatusInternalServerError)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+}
+
+func TestMetrics(t *testing.T) {
+	env := newTestEnv(t)
+	user, err := env.mysql.InsertUser(context.Background(), model.User{Name: "Budi", Address: "Bandung", Email: "budi@example.com"})
+	assert.NoError(t, err)
+
+	m := metrics.New()
+	server := NewServer(env.server.Services, WithAuth(env.tokens), WithMetrics(m))
+	env.ts = httptest.NewServer(server.Handler())
+	t.Cleanup(env.ts.Close)
+
+	resp, _ := env.do(t, "GET", "/"+user.UserID+"/user/mysql", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	env.token = ""
+	resp, _ = env.do(t, "GET", "/metrics", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "metrics are not served on the API port")
 
 	rr := httptest.NewRecorder()
-	r := server.NewRouter()
-	r.Post("/user", server.RegisterUserHandler)
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rr.Body.String(), `http_requests_total{code="200",method="GET",route="/{id}/user/mysql"} 1`)
+}
+
+func TestHealthzHandler(t *testing.T) {
+	server := NewServer(nil, WithReadinessChecks(
+		health.CheckFunc("mysql", func(ctx context.Context) error { return errors.New("down") }),
+	))
+
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/healthz")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestReadyzHandler(t *testing.T) {
+	mongoErr := errors.New("server selection timeout")
+	server := NewServer(nil, WithReadinessChecks(
+		health.CheckFunc("mysql", func(ctx context.Context) error { return nil }),
+		health.CheckFunc("mongo", func(ctx context.Context) error { return mongoErr }),
+	))
+
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/readyz")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	var report health.Report
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, health.StatusUp, report.Checks["mysql"].Status)
+	assert.Equal(t, health.StatusDown, report.Checks["mongo"].Status)
+	assert.Equal(t, health.ErrMsgFailed, report.Checks["mongo"].Error)
+}
+
+func TestShutdown(t *testing.T) {
+	var order []string
+	server := NewServer(nil,
+		WithDrainDelay(50*time.Millisecond),
+		WithShutdownHooks(
+			func(ctx context.Context) error { order = append(order, "mongo"); return nil },
+			func(ctx context.Context) error { order = append(order, "mysql"); return nil },
+		),
+	)
+
+	server.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		order = append(order, "worker")
+	})
+
+	done := make(chan error)
+	go func() { done <- server.Shutdown(context.Background()) }()
+
+	time.Sleep(10 * time.Millisecond)
+	rr := httptest.NewRecorder()
+	server.ReadyzHandler(rr, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	assert.NoError(t, <-done)
+	assert.Equal(t, []string{"worker", "mongo", "mysql"}, order)
+}
+
+func TestServe(t *testing.T) {
+	server := NewServer(nil)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- server.Serve(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/healthz")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestStart_ListenError(t *testing.T) {
+	var order []string
+	server := NewServer(nil,
+		WithAddr("256.0.0.1:0"),
+		WithDrainDelay(time.Hour),
+		WithShutdownHooks(func(ctx context.Context) error { order = append(order, "hook"); return nil }),
+	)
+
+	server.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		order = append(order, "worker")
+	})
 
-	r.ServeHTTP(rr, req)
-	assert.Equal(t, http.StatusOK, rr.Code, "status code should be %d but got %d", http.StatusOK, rr.Code)
+	assert.Error(t, server.Start(context.Background()))
+	assert.Equal(t, []string{"worker", "hook"}, order, "workers stop and hooks run without draining")
+	assert.True(t, server.Draining())
 }
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithShutdownTimeout bounds how long Shutdown waits for in-flight requests,
+// background workers and shutdown hooks altogether.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(a *ApiServer) {
+		if d > 0 {
+			a.shutdownTimeout = d
+		}
+	}
+}
+
+// WithDrainDelay keeps serving requests for d after /readyz starts failing,
+// giving load balancers time to take the instance out of rotation.
+func WithDrainDelay(d time.Duration) Option {
+	return func(a *ApiServer) {
+		a.drainDelay = d
+	}
+}
+
+// WithShutdownHooks registers functions run in order once the HTTP server and
+// background workers have stopped, e.g. to close database pools.
+func WithShutdownHooks(hooks ...func(ctx context.Context) error) Option {
+	return func(a *ApiServer) {
+		a.shutdownHooks = append(a.shutdownHooks, hooks...)
+	}
+}
+
+// Go runs fn in the background and makes Shutdown wait for it. The context
+// passed to fn is cancelled once the HTTP server has stopped accepting
+// requests.
+func (a *ApiServer) Go(fn func(ctx context.Context)) {
+	a.workers.Add(1)
+	go func() {
+		defer a.workers.Done()
+		fn(a.workerCtx)
+	}()
+}
+
+// Draining reports whether Shutdown has started.
+func (a *ApiServer) Draining() bool {
+	return a.draining.Load()
+}
+
+// Shutdown flips readiness to failing, waits for the drain delay, stops the
+// HTTP server while letting in-flight requests finish, waits for background
+// workers and finally runs the shutdown hooks.
+func (a *ApiServer) Shutdown(ctx context.Context) error {
+	a.draining.Store(true)
+
+	if a.drainDelay > 0 {
+		a.Logger.Info("draining", "delay", a.drainDelay.String())
+		select {
+		case <-time.After(a.drainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	return a.stop(ctx)
+}
+
+// abort stops the workers and runs the shutdown hooks after the server
+// failed to serve, skipping the drain delay since nothing is being served.
+// The returned error wraps err and any error of the shutdown itself.
+func (a *ApiServer) abort(err error) error {
+	a.draining.Store(true)
+	return errors.Join(err, a.stop(context.Background()))
+}
+
+// stop is Shutdown without draining.
+func (a *ApiServer) stop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, a.shutdownTimeout)
+	defer cancel()
+
+	var firstErr error
+	record := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+			return
+		}
+		a.Logger.Error("shutdown error", "error", err)
+	}
+
+	if err := a.Server.Shutdown(ctx); err != nil {
+		record(err)
+	}
+
+	a.cancelWorkers()
+	done := make(chan struct{})
+	go func() {
+		a.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		record(errors.New("timed out waiting for background workers"))
+	}
+
+	for _, hook := range a.shutdownHooks {
+		if err := hook(ctx); err != nil {
+			record(err)
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vier21/tefa-ch3/config"
+	"github.com/vier21/tefa-ch3/internal/logging"
+)
+
+// HTTPConfig configures the http.Server. Zero timeouts keep the defaults of
+// NewServer.
+type HTTPConfig struct {
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// MaxBodyBytes bounds the JSON request bodies, 1 MiB by default.
+	MaxBodyBytes int64
+
+	// DisableHTTP2 restricts TLS connections to HTTP/1.1. Plain HTTP is
+	// always HTTP/1.1.
+	DisableHTTP2 bool
+}
+
+func HTTPFromConfig(cfg *config.Config) HTTPConfig {
+	return HTTPConfig{
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxBodyBytes:      int64(cfg.HTTPMaxBodyBytes),
+		DisableHTTP2:      !cfg.HTTP2Enabled,
+	}
+}
+
+// WithHTTP sets the timeouts of the http.Server, the request body limit and
+// whether HTTP/2 is offered over TLS.
+func WithHTTP(cfg HTTPConfig) Option {
+	return func(a *ApiServer) {
+		if cfg.ReadHeaderTimeout > 0 {
+			a.Server.ReadHeaderTimeout = cfg.ReadHeaderTimeout
+		}
+		if cfg.ReadTimeout > 0 {
+			a.Server.ReadTimeout = cfg.ReadTimeout
+		}
+		if cfg.WriteTimeout > 0 {
+			a.Server.WriteTimeout = cfg.WriteTimeout
+		}
+		if cfg.IdleTimeout > 0 {
+			a.Server.IdleTimeout = cfg.IdleTimeout
+		}
+		if cfg.MaxBodyBytes > 0 {
+			a.maxBodyBytes = cfg.MaxBodyBytes
+		}
+		a.disableHTTP2 = cfg.DisableHTTP2
+	}
+}
+
+// WithTLS serves HTTPS with the certificates of certs. Start the reloader
+// with Go(certs.Run) to pick up renewed certificates.
+func WithTLS(certs *CertReloader) Option {
+	return func(a *ApiServer) {
+		a.Certs = certs
+	}
+}
+
+// TLSConfig names the PEM files of the server certificate and, for mutual
+// TLS, of the CAs issuing client certificates.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile enables client certificates. Certificates sent by
+	// clients are verified against it and rejected if invalid.
+	ClientCAFile string
+	// RequireClientCert refuses clients without a certificate.
+	RequireClientCert bool
+
+	// ReloadInterval is how often Run checks the files for changes, one
+	// minute by default.
+	ReloadInterval time.Duration
+}
+
+func TLSFromConfig(cfg *config.Config) TLSConfig {
+	return TLSConfig{
+		CertFile:          cfg.TLSCertFile,
+		KeyFile:           cfg.TLSKeyFile,
+		ClientCAFile:      cfg.TLSClientCAFile,
+		RequireClientCert: cfg.TLSRequireClientCert,
+		ReloadInterval:    cfg.TLSReloadInterval,
+	}
+}
+
+// Enabled reports whether a certificate is configured.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// CertReloader holds the current certificate and client CAs and reloads them
+// once their files change, so renewed certificates are used without a
+// restart.
+type CertReloader struct {
+	cfg TLSConfig
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+}
+
+// NewCertReloader loads the certificate and client CAs of cfg.
+func NewCertReloader(cfg TLSConfig) (*CertReloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls: both certificate and key file are required")
+	}
+	if cfg.RequireClientCert && cfg.ClientCAFile == "" {
+		return nil, errors.New("tls: requiring client certificates needs a client CA file")
+	}
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = time.Minute
+	}
+
+	c := &CertReloader{cfg: cfg}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *CertReloader) files() []string {
+	files := []string{c.cfg.CertFile, c.cfg.KeyFile}
+	if c.cfg.ClientCAFile != "" {
+		files = append(files, c.cfg.ClientCAFile)
+	}
+	return files
+}
+
+func (c *CertReloader) load() error {
+	modTimes := map[string]time.Time{}
+	for _, f := range c.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		modTimes[f] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.cfg.CertFile, c.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tls: load certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if c.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates in %s", c.cfg.ClientCAFile)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cert = &cert
+	c.clientCAs = clientCAs
+	c.modTimes = modTimes
+	return nil
+}
+
+// changed reports whether any of the files was modified since the last load.
+func (c *CertReloader) changed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, f := range c.files() {
+		info, err := os.Stat(f)
+		if err != nil || !info.ModTime().Equal(c.modTimes[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reload loads the files again if they changed. The previous certificate is
+// kept if the new files are invalid, e.g. while they are being replaced.
+func (c *CertReloader) Reload(ctx context.Context) {
+	if !c.changed() {
+		return
+	}
+
+	if err := c.load(); err != nil {
+		logging.FromContext(ctx).Error("tls certificate reload failed", "error", err)
+		return
+	}
+	logging.FromContext(ctx).Info("tls certificate reloaded", "cert_file", c.cfg.CertFile)
+}
+
+// Run reloads changed files every ReloadInterval until ctx is done.
+func (c *CertReloader) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Reload(ctx)
+		}
+	}
+}
+
+// ServerConfig returns the tls.Config of a server offering HTTP/2 if http2
+// is set, which gRPC requires. Every handshake picks up the certificate and
+// client CAs loaded last.
+func (c *CertReloader) ServerConfig(http2 bool) *tls.Config {
+	nextProtos := []string{"http/1.1"}
+	if http2 {
+		nextProtos = []string{"h2", "http/1.1"}
+	}
+
+	current := func() *tls.Config {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+
+		cfg := &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			NextProtos:   nextProtos,
+			Certificates: []tls.Certificate{*c.cert},
+		}
+		if c.clientCAs != nil {
+			cfg.ClientCAs = c.clientCAs
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven
+			if c.cfg.RequireClientCert {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+		}
+		return cfg
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return current(), nil
+		},
+	}
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM encoded certificate and key for cn.
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serveTLS serves server on a local listener until the test ends.
+func serveTLS(t *testing.T, server *ApiServer) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- server.Serve(ctx, ln) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	return "https://" + ln.Addr().String()
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	clientCertPEM, clientKeyPEM := ca.issue(t, "internal-client", x509.ExtKeyUsageClientAuth)
+
+	cfg := TLSConfig{
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+	}
+	writeFile(t, cfg.CertFile, certPEM)
+	writeFile(t, cfg.KeyFile, keyPEM)
+	writeFile(t, cfg.ClientCAFile, ca.pem)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := func(http2 bool, certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs},
+			ForceAttemptHTTP2: http2,
+		}}
+	}
+
+	t.Run("HTTP/2 with optional client certificates", func(t *testing.T) {
+		certs, err := NewCertReloader(cfg)
+		assert.NoError(t, err)
+		url := serveTLS(t, NewServer(nil, WithTLS(certs)))
+
+		for _, c := range []*http.Client{client(true), client(true, clientCert)} {
+			resp, err := c.Get(url + "/healthz")
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, 2, resp.ProtoMajor)
+			}
+		}
+	})
+
+	t.Run("HTTP/2 disabled", func(t *testing.T) {
+		certs, err := NewCertReloader(cfg)
+		assert.NoError(t, err)
+		url := serveTLS(t, NewServer(nil, WithTLS(certs), WithHTTP(HTTPConfig{DisableHTTP2: true})))
+
+		resp, err := client(true).Get(url + "/healthz")
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, 1, resp.ProtoMajor)
+		}
+	})
+
+	t.Run("required client certificate", func(t *testing.T) {
+		required := cfg
+		required.RequireClientCert = true
+		certs, err := NewCertReloader(required)
+		assert.NoError(t, err)
+		url := serveTLS(t, NewServer(nil, WithTLS(certs)))
+
+		_, err = client(false).Get(url + "/healthz")
+		assert.Error(t, err)
+
+		resp, err := client(false, clientCert).Get(url + "/healthz")
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		certs, err := NewCertReloader(cfg)
+		assert.NoError(t, err)
+		url := serveTLS(t, NewServer(nil, WithTLS(certs)))
+
+		leaf := func() *x509.Certificate {
+			resp, err := client(false).Get(url + "/healthz")
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			return resp.TLS.PeerCertificates[0]
+		}
+		before := leaf()
+
+		// a half written key pair keeps the old certificate
+		newCert, newKey := ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+		writeFile(t, cfg.CertFile, newCert)
+		future := time.Now().Add(time.Minute)
+		assert.NoError(t, os.Chtimes(cfg.CertFile, future, future))
+		certs.Reload(context.Background())
+		assert.Equal(t, before.SerialNumber, leaf().SerialNumber)
+
+		writeFile(t, cfg.KeyFile, newKey)
+		assert.NoError(t, os.Chtimes(cfg.KeyFile, future, future))
+		certs.Reload(context.Background())
+		assert.NotEqual(t, before.SerialNumber, leaf().SerialNumber)
+	})
+}
+
+func TestNewCertReloader_Invalid(t *testing.T) {
+	_, err := NewCertReloader(TLSConfig{CertFile: "tls.crt"})
+	assert.Error(t, err)
+
+	_, err = NewCertReloader(TLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", RequireClientCert: true})
+	assert.Error(t, err)
+
+	_, err = NewCertReloader(TLSConfig{CertFile: "missing.crt", KeyFile: "missing.key"})
+	assert.Error(t, err)
+}
+
+func TestWithHTTP(t *testing.T) {
+	server := NewServer(nil, WithHTTP(HTTPConfig{ReadTimeout: time.Minute, WriteTimeout: 2 * time.Minute}))
+
+	assert.Equal(t, time.Minute, server.Server.ReadTimeout)
+	assert.Equal(t, 2*time.Minute, server.Server.WriteTimeout)
+	assert.Equal(t, 5*time.Second, server.Server.ReadHeaderTimeout, "unset timeouts keep the default")
+}
@@ -0,0 +1,146 @@
+// Package sms sends text messages through an HTTP gateway or, for
+// development and tests, keeps them in files or in memory.
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vier21/tefa-ch3/config"
+)
+
+type Gateway interface {
+	Send(ctx context.Context, to, text string) error
+}
+
+var ErrNoGateway = errors.New("sms: SMS_GATEWAY_URL is not set, set SMS_FAKE=true to write messages to SMS_DIR in development")
+
+// FromConfig returns an HTTP gateway when SMS_GATEWAY_URL is set. A
+// FileGateway writing to SMS_DIR is only returned when SMS_FAKE explicitly
+// asks for it, so a missing gateway does not go unnoticed.
+func FromConfig(cfg *config.Config) (Gateway, error) {
+	switch {
+	case cfg.SMSGatewayURL != "":
+		return NewHTTPGateway(cfg.SMSGatewayURL, cfg.SMSGatewayToken), nil
+	case cfg.SMSFake:
+		return NewFileGateway(cfg.SMSDir), nil
+	}
+	return nil, ErrNoGateway
+}
+
+// HTTPGateway posts {"to": ..., "text": ...} as JSON to a provider endpoint,
+// authenticated with a bearer token.
+type HTTPGateway struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func NewHTTPGateway(url, token string) *HTTPGateway {
+	return &HTTPGateway{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *HTTPGateway) Send(ctx context.Context, to, text string) error {
+	body, err := json.Marshal(Message{To: to, Text: text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sms: gateway answered %s", resp.Status)
+	}
+	return nil
+}
+
+type Message struct {
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+// FileGateway writes every message as a JSON file into a directory, so
+// codes can be read during development.
+type FileGateway struct {
+	dir string
+	seq atomic.Int64
+}
+
+func NewFileGateway(dir string) *FileGateway {
+	return &FileGateway{dir: dir}
+}
+
+func (g *FileGateway) Send(ctx context.Context, to, text string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(Message{To: to, Text: text}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(g.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%04d.json", time.Now().UTC().Format("20060102T150405.000000000"), g.seq.Add(1))
+	return os.WriteFile(filepath.Join(g.dir, name), append(raw, '\n'), 0o600)
+}
+
+// Fake keeps every message in memory instead of sending it.
+type Fake struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (f *Fake) Send(ctx context.Context, to, text string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.messages = append(f.messages, Message{To: to, Text: text})
+	return nil
+}
+
+// Last returns the most recent message sent to the given number.
+func (f *Fake) Last(to string) (Message, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := len(f.messages) - 1; i >= 0; i-- {
+		if f.messages[i].To == to {
+			return f.messages[i], true
+		}
+	}
+	return Message{}, false
+}
@@ -0,0 +1,81 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/config"
+)
+
+func TestHTTPGateway(t *testing.T) {
+	var got Message
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if got.To == "6281200000000" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer ts.Close()
+
+	gateway := NewHTTPGateway(ts.URL, "secret")
+
+	assert.NoError(t, gateway.Send(context.Background(), "6281200000001", "hello"))
+	assert.Equal(t, Message{To: "6281200000001", Text: "hello"}, got)
+
+	assert.Error(t, gateway.Send(context.Background(), "6281200000000", "hello"))
+}
+
+func TestFake(t *testing.T) {
+	fake := &Fake{}
+
+	assert.NoError(t, fake.Send(context.Background(), "6281200000001", "first"))
+	assert.NoError(t, fake.Send(context.Background(), "6281200000002", "other"))
+	assert.NoError(t, fake.Send(context.Background(), "6281200000001", "second"))
+
+	msg, ok := fake.Last("6281200000001")
+	assert.True(t, ok)
+	assert.Equal(t, "second", msg.Text)
+
+	_, ok = fake.Last("6281200000003")
+	assert.False(t, ok)
+}
+
+func TestFileGateway(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sms")
+	gateway := NewFileGateway(dir)
+
+	assert.NoError(t, gateway.Send(context.Background(), "6281200000001", "first"))
+	assert.NoError(t, gateway.Send(context.Background(), "6281200000002", "second"))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.NoError(t, err)
+	if !assert.Len(t, files, 2) {
+		return
+	}
+
+	raw, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+	var msg Message
+	assert.NoError(t, json.Unmarshal(raw, &msg))
+	assert.Equal(t, Message{To: "6281200000001", Text: "first"}, msg)
+}
+
+func TestFromConfig(t *testing.T) {
+	gateway, err := FromConfig(&config.Config{SMSGatewayURL: "https://sms.example.com", SMSFake: true})
+	assert.NoError(t, err)
+	assert.IsType(t, &HTTPGateway{}, gateway)
+
+	gateway, err = FromConfig(&config.Config{SMSFake: true, SMSDir: t.TempDir()})
+	assert.NoError(t, err)
+	assert.IsType(t, &FileGateway{}, gateway)
+
+	_, err = FromConfig(&config.Config{})
+	assert.ErrorIs(t, err, ErrNoGateway, "a missing gateway is only accepted when asked for")
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := newRecorder(t)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, done := RepositoryHook(r.Context(), "mysql", "GetUserByID")
+		done(errors.New("connection refused"))
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	repo, server := spans[0], spans[1]
+
+	assert.Equal(t, "GET /users/{id}", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String(), "the caller's trace is continued")
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Contains(t, server.Attributes(), semconv.HTTPRoute("/users/{id}"))
+	assert.Contains(t, server.Attributes(), semconv.HTTPResponseStatusCode(500))
+	assert.Equal(t, codes.Error, server.Status().Code)
+
+	assert.Equal(t, "mysql.GetUserByID", repo.Name())
+	assert.Equal(t, server.SpanContext().SpanID(), repo.Parent().SpanID())
+	assert.Equal(t, codes.Error, repo.Status().Code)
+	assert.Equal(t, "connection refused", repo.Status().Description)
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Config{Exporter: "none"})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	shutdown, err = Setup(context.Background(), Config{Exporter: "stdout", ServiceName: "test", SampleRatio: 1})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/vier21/tefa-ch3/internal/authz"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+// authorizedUsecase enforces the authorization policy in front of another
+// UserInterface, including ownership of the requested records.
+type authorizedUsecase struct {
+	next   UserInterface
+	policy authz.Policy
+}
+
+func NewAuthorizedUsecase(next UserInterface, policy authz.Policy) *authorizedUsecase {
+	return &authorizedUsecase{
+		next:   next,
+		policy: policy,
+	}
+}
+
+// RegisterUser is open to everyone for self sign-up, so it checks nothing.
+func (u *authorizedUsecase) RegisterUser(ctx context.Context, user model.User) (Result, error) {
+	return u.next.RegisterUser(ctx, user)
+}
+
+func (u *authorizedUsecase) GetUserByID(ctx context.Context, userID string) (model.User, error) {
+	if err := u.policy.Authorize(ctx, authz.UserRead, userID); err != nil {
+		return model.User{}, err
+	}
+
+	return u.next.GetUserByID(ctx, userID)
+}
+
+// GetUsersByIDs fails as a whole if any of the users is out of reach.
+func (u *authorizedUsecase) GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]model.User, error) {
+	for _, id := range userIDs {
+		if err := u.policy.Authorize(ctx, authz.UserRead, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return u.next.GetUsersByIDs(ctx, userIDs)
+}
+
+func (u *authorizedUsecase) GetUserDataMongo(ctx context.Context, id string) (model.User, error) {
+	if err := u.policy.Authorize(ctx, authz.UserRead, id); err != nil {
+		return model.User{}, err
+	}
+
+	return u.next.GetUserDataMongo(ctx, id)
+}
+
+func (u *authorizedUsecase) RegisterAccount(ctx context.Context, account model.Account) (model.Account, error) {
+	if err := u.policy.Authorize(ctx, authz.AccountCreate, account.UserID); err != nil {
+		return model.Account{}, err
+	}
+
+	return u.next.RegisterAccount(ctx, account)
+}
+
+// GetUserByAccountID only knows the owner after the lookup, so ownership is
+// checked on the result. Accounts of other owners are reported as not found
+// so that callers cannot probe which IDs exist.
+func (u *authorizedUsecase) GetUserByAccountID(ctx context.Context, accountID string) (model.User, error) {
+	if err := u.policy.Granted(ctx, authz.AccountRead); err != nil {
+		return model.User{}, err
+	}
+
+	user, err := u.next.GetUserByAccountID(ctx, accountID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if err := u.policy.Authorize(ctx, authz.AccountRead, user.UserID); err != nil {
+		return model.User{}, repository.ErrAccountNotFound
+	}
+
+	return user, nil
+}
+
+func (u *authorizedUsecase) VerifyUser(ctx context.Context, userID string) (model.User, error) {
+	if err := u.policy.Authorize(ctx, authz.UserVerify, userID); err != nil {
+		return model.User{}, err
+	}
+
+	return u.next.VerifyUser(ctx, userID)
+}
+
+func (u *authorizedUsecase) DeleteUser(ctx context.Context, userID string) error {
+	if err := u.policy.Authorize(ctx, authz.UserDelete, userID); err != nil {
+		return err
+	}
+
+	return u.next.DeleteUser(ctx, userID)
+}
+
+// GetAccount hides accounts of other owners like GetUserByAccountID.
+func (u *authorizedUsecase) GetAccount(ctx context.Context, accountID string) (model.Account, error) {
+	if err := u.policy.Granted(ctx, authz.AccountRead); err != nil {
+		return model.Account{}, err
+	}
+
+	account, err := u.next.GetAccount(ctx, accountID)
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	if err := u.policy.Authorize(ctx, authz.AccountRead, account.UserID); err != nil {
+		return model.Account{}, repository.ErrAccountNotFound
+	}
+
+	return account, nil
+}
+
+func (u *authorizedUsecase) ListAccounts(ctx context.Context, userID string) ([]model.Account, error) {
+	if err := u.policy.Authorize(ctx, authz.AccountRead, userID); err != nil {
+		return nil, err
+	}
+
+	return u.next.ListAccounts(ctx, userID)
+}
+
+// ListAccountsByUserIDs fails as a whole if any of the users is out of reach.
+func (u *authorizedUsecase) ListAccountsByUserIDs(ctx context.Context, userIDs []string) (map[string][]model.Account, error) {
+	for _, id := range userIDs {
+		if err := u.policy.Authorize(ctx, authz.AccountRead, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return u.next.ListAccountsByUserIDs(ctx, userIDs)
+}
+
+// VerifyAccount is reserved to whoever may create accounts for the owner.
+// Accounts of other owners are reported as not found.
+func (u *authorizedUsecase) VerifyAccount(ctx context.Context, accountID, code string) (model.Account, error) {
+	if err := u.policy.Granted(ctx, authz.AccountCreate); err != nil {
+		return model.Account{}, err
+	}
+
+	account, err := u.next.GetAccount(ctx, accountID)
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	if err := u.policy.Authorize(ctx, authz.AccountCreate, account.UserID); err != nil {
+		return model.Account{}, repository.ErrAccountNotFound
+	}
+
+	return u.next.VerifyAccount(ctx, accountID, code)
+}
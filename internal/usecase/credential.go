@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/vier21/tefa-ch3/internal/credential"
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/model"
+)
+
+// credentialUsecase stores the optional password given on registration
+// after the user itself has been registered by next. The email is checked
+// first to reject taken logins early, but a concurrent sign-up can still
+// claim it before the password is stored; the new user is then deleted
+// again so that no user without its login is left behind.
+type credentialUsecase struct {
+	UserInterface
+	credentials *credential.Service
+}
+
+func NewCredentialUsecase(next UserInterface, credentials *credential.Service) *credentialUsecase {
+	return &credentialUsecase{
+		UserInterface: next,
+		credentials:   credentials,
+	}
+}
+
+func (u *credentialUsecase) RegisterUser(ctx context.Context, user model.User) (Result, error) {
+	password := user.Password
+	user.Password = ""
+
+	if password != "" {
+		if err := credential.Validate(password); err != nil {
+			return Result{}, err
+		}
+		if err := u.credentials.CheckAvailable(ctx, user.Email); err != nil {
+			return Result{}, err
+		}
+	}
+
+	res, err := u.UserInterface.RegisterUser(ctx, user)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if password != "" {
+		if err := u.credentials.SetPassword(ctx, res.UserMysql.UserID, res.UserMysql.Email, password); err != nil {
+			if derr := u.UserInterface.DeleteUser(ctx, res.UserMysql.UserID); derr != nil {
+				logging.FromContext(ctx).Error("error deleting user without credential", "user_id", res.UserMysql.UserID, "error", derr)
+			}
+			return Result{}, err
+		}
+	}
+
+	return res, nil
+}
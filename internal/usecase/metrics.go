@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vier21/tefa-ch3/internal/metrics"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+)
+
+// metricsUsecase counts the registrations handled by next. Accounts count
+// once they are active, so pending ones waiting for verification do not.
+type metricsUsecase struct {
+	UserInterface
+	metrics *metrics.Metrics
+}
+
+func NewMetricsUsecase(next UserInterface, metrics *metrics.Metrics) *metricsUsecase {
+	return &metricsUsecase{
+		UserInterface: next,
+		metrics:       metrics,
+	}
+}
+
+func (u *metricsUsecase) RegisterUser(ctx context.Context, user model.User) (Result, error) {
+	res, err := u.UserInterface.RegisterUser(ctx, user)
+	if err == nil {
+		u.metrics.UsersRegistered.Inc()
+	}
+	return res, err
+}
+
+func (u *metricsUsecase) RegisterAccount(ctx context.Context, account model.Account) (model.Account, error) {
+	account, err := u.UserInterface.RegisterAccount(ctx, account)
+	switch {
+	case errors.Is(err, repository.ErrMsisdnLimit):
+		u.metrics.MsisdnLimitRejected.Inc()
+	case err == nil && account.Status != model.AccountPending:
+		u.metrics.AccountsRegistered.Inc()
+	}
+	return account, err
+}
+
+// VerifyAccount counts the account when verifying it activates it.
+// Verifying an account that is active already is not counted again.
+func (u *metricsUsecase) VerifyAccount(ctx context.Context, accountID, code string) (model.Account, error) {
+	before, lookupErr := u.UserInterface.GetAccount(ctx, accountID)
+
+	account, err := u.UserInterface.VerifyAccount(ctx, accountID, code)
+	if err == nil && lookupErr == nil && before.Status == model.AccountPending && account.Status == model.AccountActive {
+		u.metrics.AccountsRegistered.Inc()
+	}
+	return account, err
+}
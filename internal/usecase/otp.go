@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/otp"
+)
+
+// otpUsecase registers accounts as pending until the owner confirms the
+// MSISDN with the code sent to it.
+type otpUsecase struct {
+	UserInterface
+	otp *otp.Service
+}
+
+func NewOTPUsecase(next UserInterface, otp *otp.Service) *otpUsecase {
+	return &otpUsecase{
+		UserInterface: next,
+		otp:           otp,
+	}
+}
+
+func (u *otpUsecase) RegisterAccount(ctx context.Context, account model.Account) (model.Account, error) {
+	account.Status = model.AccountPending
+
+	account, err := u.UserInterface.RegisterAccount(ctx, account)
+	if err != nil {
+		return model.Account{}, err
+	}
+
+	if err := u.otp.Start(ctx, account); err != nil {
+		return model.Account{}, err
+	}
+
+	return account, nil
+}
+
+func (u *otpUsecase) VerifyAccount(ctx context.Context, accountID, code string) (model.Account, error) {
+	return u.otp.Verify(ctx, accountID, code)
+}
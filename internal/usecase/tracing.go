@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/tracing"
+)
+
+// tracingUsecase records every call of next as a span.
+type tracingUsecase struct {
+	next UserInterface
+}
+
+func NewTracingUsecase(next UserInterface) *tracingUsecase {
+	return &tracingUsecase{next: next}
+}
+
+func (u *tracingUsecase) RegisterUser(ctx context.Context, user model.User) (Result, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.RegisterUser")
+	res, err := u.next.RegisterUser(ctx, user)
+	tracing.End(span, err)
+	return res, err
+}
+
+func (u *tracingUsecase) GetUserByID(ctx context.Context, userID string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.GetUserByID")
+	user, err := u.next.GetUserByID(ctx, userID)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (u *tracingUsecase) GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]model.User, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.GetUsersByIDs")
+	users, err := u.next.GetUsersByIDs(ctx, userIDs)
+	tracing.End(span, err)
+	return users, err
+}
+
+func (u *tracingUsecase) RegisterAccount(ctx context.Context, account model.Account) (model.Account, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.RegisterAccount")
+	account, err := u.next.RegisterAccount(ctx, account)
+	tracing.End(span, err)
+	return account, err
+}
+
+func (u *tracingUsecase) ListAccounts(ctx context.Context, userID string) ([]model.Account, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.ListAccounts")
+	accounts, err := u.next.ListAccounts(ctx, userID)
+	tracing.End(span, err)
+	return accounts, err
+}
+
+func (u *tracingUsecase) ListAccountsByUserIDs(ctx context.Context, userIDs []string) (map[string][]model.Account, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.ListAccountsByUserIDs")
+	accounts, err := u.next.ListAccountsByUserIDs(ctx, userIDs)
+	tracing.End(span, err)
+	return accounts, err
+}
+
+func (u *tracingUsecase) GetAccount(ctx context.Context, accountID string) (model.Account, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.GetAccount")
+	account, err := u.next.GetAccount(ctx, accountID)
+	tracing.End(span, err)
+	return account, err
+}
+
+func (u *tracingUsecase) VerifyAccount(ctx context.Context, accountID, code string) (model.Account, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.VerifyAccount")
+	account, err := u.next.VerifyAccount(ctx, accountID, code)
+	tracing.End(span, err)
+	return account, err
+}
+
+func (u *tracingUsecase) GetUserByAccountID(ctx context.Context, accountID string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.GetUserByAccountID")
+	user, err := u.next.GetUserByAccountID(ctx, accountID)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (u *tracingUsecase) GetUserDataMongo(ctx context.Context, id string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.GetUserDataMongo")
+	user, err := u.next.GetUserDataMongo(ctx, id)
+	tracing.End(span, err)
+	return user, err
+}
+
+func (u *tracingUsecase) DeleteUser(ctx context.Context, userID string) error {
+	ctx, span := tracing.Start(ctx, "userUsecase.DeleteUser")
+	err := u.next.DeleteUser(ctx, userID)
+	tracing.End(span, err)
+	return err
+}
+
+func (u *tracingUsecase) VerifyUser(ctx context.Context, userID string) (model.User, error) {
+	ctx, span := tracing.Start(ctx, "userUsecase.VerifyUser")
+	user, err := u.next.VerifyUser(ctx, userID)
+	tracing.End(span, err)
+	return user, err
+}
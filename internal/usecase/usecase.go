@@ -2,9 +2,10 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 
+	"github.com/vier21/tefa-ch3/internal/logging"
 	"github.com/vier21/tefa-ch3/internal/model"
 	"github.com/vier21/tefa-ch3/internal/repository"
 )
@@ -12,11 +13,20 @@ import (
 type UserInterface interface {
 	RegisterUser(ctx context.Context, user model.User) (Result, error)
 	GetUserByID(ctx context.Context, userID string) (model.User, error)
+	GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]model.User, error)
 	RegisterAccount(ctx context.Context, account model.Account) (model.Account, error)
+	GetAccount(ctx context.Context, accountID string) (model.Account, error)
+	ListAccounts(ctx context.Context, userID string) ([]model.Account, error)
+	ListAccountsByUserIDs(ctx context.Context, userIDs []string) (map[string][]model.Account, error)
+	VerifyAccount(ctx context.Context, accountID, code string) (model.Account, error)
 	GetUserByAccountID(ctx context.Context, accountID string) (model.User, error)
 	GetUserDataMongo(ctx context.Context, id string) (model.User, error)
+	VerifyUser(ctx context.Context, userID string) (model.User, error)
+	DeleteUser(ctx context.Context, userID string) error
 }
 
+var ErrAccountVerificationDisabled = errors.New("account verification is not enabled")
+
 type Result struct {
 	UserMongo model.User `json:"userMongo"`
 	UserMysql model.User `json:"userMysql"`
@@ -35,12 +45,16 @@ func NewUserUsecase(mysql repository.MysqlRepositoryInterface, mongodb repositor
 }
 
 func (u *userUsecase) RegisterUser(ctx context.Context, user model.User) (Result, error) {
+	// users only become verified through VerifyUser
+	user.Verified = false
+
 	insMysql, err := u.userMysqlRepository.InsertUser(ctx, user)
 	if err != nil {
 		return Result{}, err
 	}
 
-	insMongo, err := u.userMongoRepository.InsertUser(ctx, user)
+	// the Mongo copy shares the MySQL ID so both records identify one user
+	insMongo, err := u.userMongoRepository.InsertUser(ctx, insMysql)
 	if err != nil {
 		return Result{}, err
 	}
@@ -58,7 +72,7 @@ func (u *userUsecase) GetUserDataMongo(ctx context.Context, id string) (model.Us
 	user, err := u.userMongoRepository.GetUser(ctx, id)
 
 	if err != nil {
-		log.Println("error retrieving user: %s", err.Error())
+		logging.FromContext(ctx).Error("error retrieving user", "user_id", id, "error", err)
 		return model.User{}, err
 	}
 
@@ -74,6 +88,22 @@ func (u *userUsecase) GetUserByID(ctx context.Context, userID string) (model.Use
 	return user, nil
 }
 
+// GetUsersByIDs looks up several users at once, keyed by user ID. Unknown
+// users are missing from the map.
+func (u *userUsecase) GetUsersByIDs(ctx context.Context, userIDs []string) (map[string]model.User, error) {
+	users, err := u.userMysqlRepository.GetUsersByIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]model.User, len(users))
+	for _, user := range users {
+		byID[user.UserID] = user
+	}
+
+	return byID, nil
+}
+
 func (u *userUsecase) RegisterAccount(ctx context.Context, account model.Account) (model.Account, error) {
 	account, err := u.userMysqlRepository.InsertAccount(ctx, account)
 	if err != nil {
@@ -83,6 +113,53 @@ func (u *userUsecase) RegisterAccount(ctx context.Context, account model.Account
 	return account, nil
 }
 
+func (u *userUsecase) GetAccount(ctx context.Context, accountID string) (model.Account, error) {
+	return u.userMysqlRepository.GetAccountByID(ctx, accountID)
+}
+
+// ListAccounts returns the accounts of an existing user, pending ones
+// included.
+func (u *userUsecase) ListAccounts(ctx context.Context, userID string) ([]model.Account, error) {
+	if _, err := u.userMysqlRepository.GetUserByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	return u.userMysqlRepository.ListAccountsByUserID(ctx, userID)
+}
+
+// ListAccountsByUserIDs lists the accounts of several users at once, keyed by
+// user ID. Every requested ID is in the map; unknown users have no accounts.
+func (u *userUsecase) ListAccountsByUserIDs(ctx context.Context, userIDs []string) (map[string][]model.Account, error) {
+	accounts, err := u.userMysqlRepository.ListAccountsByUserIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[string][]model.Account, len(userIDs))
+	for _, id := range userIDs {
+		byUser[id] = []model.Account{}
+	}
+	for _, account := range accounts {
+		byUser[account.UserID] = append(byUser[account.UserID], account)
+	}
+
+	return byUser, nil
+}
+
+// VerifyAccount only accepts accounts that are active already. Pending
+// accounts are verified by the decorator returned by NewOTPUsecase.
+func (u *userUsecase) VerifyAccount(ctx context.Context, accountID, code string) (model.Account, error) {
+	account, err := u.userMysqlRepository.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return model.Account{}, err
+	}
+	if account.Status != model.AccountActive {
+		return model.Account{}, ErrAccountVerificationDisabled
+	}
+
+	return account, nil
+}
+
 func (u *userUsecase) GetUserByAccountID(ctx context.Context, accountID string) (model.User, error) {
 	user, err := u.userMysqlRepository.GetUserByAccountID(ctx, accountID)
 	if err != nil {
@@ -91,3 +168,27 @@ func (u *userUsecase) GetUserByAccountID(ctx context.Context, accountID string)
 
 	return user, nil
 }
+
+// VerifyUser marks the user as verified in both stores and returns the
+// updated user.
+func (u *userUsecase) VerifyUser(ctx context.Context, userID string) (model.User, error) {
+	if err := u.userMysqlRepository.MarkUserVerified(ctx, userID); err != nil {
+		return model.User{}, err
+	}
+
+	if err := u.userMongoRepository.MarkUserVerified(ctx, userID); err != nil {
+		return model.User{}, err
+	}
+
+	return u.userMysqlRepository.GetUserByID(ctx, userID)
+}
+
+// DeleteUser removes the user from both stores. A missing Mongo copy is not
+// an error, so a user whose registration failed halfway can still be removed.
+func (u *userUsecase) DeleteUser(ctx context.Context, userID string) error {
+	if err := u.userMongoRepository.DeleteUser(ctx, userID); err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return err
+	}
+
+	return u.userMysqlRepository.DeleteUser(ctx, userID)
+}
@@ -2,111 +2,356 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/vier21/tefa-ch3/internal/credential"
+	"github.com/vier21/tefa-ch3/internal/metrics"
 	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/repository"
+	"github.com/vier21/tefa-ch3/internal/repository/memory"
+	"github.com/vier21/tefa-ch3/internal/repository/mocks"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/crypto/bcrypt"
 )
 
-type MockMysqlRepository struct{}
-type MockMongoRepository struct{}
+var errDB = errors.New("connection refused")
 
-func (m *MockMongoRepository) InsertUser(ctx context.Context, user model.User) (model.User, error) {
-	return user, nil
-}
+func newTestUsecase(t *testing.T) (*userUsecase, *mocks.MysqlRepositoryInterface, *mocks.MongodbRepositoryInterface) {
+	mysqlRepo := mocks.NewMysqlRepositoryInterface(t)
+	mongoRepo := mocks.NewMongodbRepositoryInterface(t)
 
-func (m *MockMongoRepository) GetUserByAccountID(ctx context.Context, accountID string) (model.User, error) {
-	return model.User{}, nil
+	return NewUserUsecase(mysqlRepo, mongoRepo), mysqlRepo, mongoRepo
 }
 
-func (m *MockMongoRepository) GetUser(ctx context.Context, userID string) (model.User, error) {
-	mockUser := model.User{
-		UserID:  userID,
-		Name:    "Mock User",
-		Address: "Mock Address",
-		Email:   "mock@example.com",
+func TestUserUsecase_RegisterUser(t *testing.T) {
+	usecase, mysqlRepo, mongoRepo := newTestUsecase(t)
+
+	user := model.User{
+		Name:    "John Doe",
+		Address: "123 Main St",
+		Email:   "john@example.com",
 	}
 
-	return mockUser, nil
-}
+	stored := user
+	stored.UserID = "user-id"
+	mysqlRepo.EXPECT().InsertUser(mock.Anything, user).Return(stored, nil)
+	mongoRepo.EXPECT().InsertUser(mock.Anything, stored).Return(stored, nil)
 
-func (m *MockMysqlRepository) InsertUser(ctx context.Context, user model.User) (model.User, error) {
-	return user, nil
+	result, err := usecase.RegisterUser(context.Background(), user)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, result.UserMysql)
+	assert.Equal(t, stored, result.UserMongo)
 }
 
-func (m *MockMysqlRepository) GetUserByID(ctx context.Context, userID string) (model.User, error) {
-	return model.User{}, nil
-}
+func TestUserUsecase_RegisterUser_MysqlError(t *testing.T) {
+	usecase, mysqlRepo, _ := newTestUsecase(t)
 
-func (m *MockMysqlRepository) InsertAccount(ctx context.Context, account model.Account) (model.Account, error) {
-	return account, nil
-}
+	mysqlRepo.EXPECT().InsertUser(mock.Anything, mock.Anything).Return(model.User{}, errDB)
 
-func (m *MockMysqlRepository) GetUserByAccountID(ctx context.Context, accountID string) (model.User, error) {
-	return model.User{}, nil
+	_, err := usecase.RegisterUser(context.Background(), model.User{Name: "John Doe"})
+	assert.ErrorIs(t, err, errDB)
 }
 
-func TestUserUsecase_RegisterUser(t *testing.T) {
-	mysqlRepo := &MockMysqlRepository{}
-	mongoRepo := &MockMongoRepository{}
-	usecase := NewUserUsecase(mysqlRepo, mongoRepo)
+func TestUserUsecase_RegisterUser_MongoError(t *testing.T) {
+	usecase, mysqlRepo, mongoRepo := newTestUsecase(t)
 
-	user := model.User{
-		Name:    "John Doe",
-		Address: "123 Main St",
-		Email:   "john@example.com",
-	}
+	mysqlRepo.EXPECT().InsertUser(mock.Anything, mock.Anything).Return(model.User{UserID: "mysql-id"}, nil)
+	mongoRepo.EXPECT().InsertUser(mock.Anything, mock.Anything).Return(model.User{}, errDB)
 
-	result, err := usecase.RegisterUser(context.Background(), user)
-	assert.NoError(t, err)
-	assert.Equal(t, user.Name, result.UserMysql.Name)
-	assert.Equal(t, user.Name, result.UserMongo.Name)
+	_, err := usecase.RegisterUser(context.Background(), model.User{Name: "John Doe"})
+	assert.ErrorIs(t, err, errDB)
 }
 
 func TestUserUsecase_GetUserByID(t *testing.T) {
-	mysqlRepo := &MockMysqlRepository{}
-	mongoRepo := &MockMongoRepository{}
-	usecase := NewUserUsecase(mysqlRepo, mongoRepo)
+	usecase, mysqlRepo, _ := newTestUsecase(t)
+
+	want := model.User{UserID: "someUserID", Name: "Mock User"}
+	mysqlRepo.EXPECT().GetUserByID(mock.Anything, "someUserID").Return(want, nil)
+	mysqlRepo.EXPECT().GetUserByID(mock.Anything, "missing").Return(model.User{}, repository.ErrUserNotFound)
 
-	userID := "someUserID"
-	user, err := usecase.GetUserByID(context.Background(), userID)
+	user, err := usecase.GetUserByID(context.Background(), "someUserID")
 	assert.NoError(t, err)
-	assert.Equal(t, model.User{}, user)
+	assert.Equal(t, want, user)
+
+	_, err = usecase.GetUserByID(context.Background(), "missing")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
 }
 
-func TestUserUsecase_GetUserByIDMongo(t *testing.T) {
-	mysqlRepo := &MockMysqlRepository{}
-	mongoRepo := &MockMongoRepository{}
-	usecase := NewUserUsecase(mysqlRepo, mongoRepo)
+func TestUserUsecase_GetUserDataMongo(t *testing.T) {
+	usecase, _, mongoRepo := newTestUsecase(t)
+
+	want := model.User{UserID: "someUserID", Name: "Mock User"}
+	mongoRepo.EXPECT().GetUser(mock.Anything, "someUserID").Return(want, nil)
+	mongoRepo.EXPECT().GetUser(mock.Anything, "missing").Return(model.User{}, repository.ErrUserNotFound)
 
-	userID := "someUserID"
-	user, err := usecase.GetUserByID(context.Background(), userID)
+	user, err := usecase.GetUserDataMongo(context.Background(), "someUserID")
 	assert.NoError(t, err)
-	assert.Equal(t, model.User{}, user)
+	assert.Equal(t, want, user)
+
+	_, err = usecase.GetUserDataMongo(context.Background(), "missing")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
+func TestUserUsecase_GetUserDataMongo_EmptyID(t *testing.T) {
+	usecase, _, _ := newTestUsecase(t)
+
+	_, err := usecase.GetUserDataMongo(context.Background(), "")
+	assert.Error(t, err)
 }
 
 func TestUserUsecase_RegisterAccount(t *testing.T) {
-	mysqlRepo := &MockMysqlRepository{}
-	mongoRepo := &MockMongoRepository{}
-	usecase := NewUserUsecase(mysqlRepo, mongoRepo)
+	usecase, mysqlRepo, _ := newTestUsecase(t)
 
 	account := model.Account{
 		MsisdnCustomer: "1234567890",
 		UserID:         "someUserID",
 	}
+	stored := account
+	stored.AccountID = "someAccountID"
+	mysqlRepo.EXPECT().InsertAccount(mock.Anything, account).Return(stored, nil).Once()
 
 	result, err := usecase.RegisterAccount(context.Background(), account)
 	assert.NoError(t, err)
-	assert.Equal(t, account.MsisdnCustomer, result.MsisdnCustomer)
+	assert.Equal(t, stored, result)
+}
+
+func TestUserUsecase_RegisterAccount_Limit(t *testing.T) {
+	usecase, mysqlRepo, _ := newTestUsecase(t)
+
+	mysqlRepo.EXPECT().InsertAccount(mock.Anything, mock.Anything).Return(model.Account{}, repository.ErrMsisdnLimit)
+
+	_, err := usecase.RegisterAccount(context.Background(), model.Account{MsisdnCustomer: "1234567890", UserID: "someUserID"})
+	assert.ErrorIs(t, err, repository.ErrMsisdnLimit)
 }
 
 func TestUserUsecase_GetUserByAccountID(t *testing.T) {
-	mysqlRepo := &MockMysqlRepository{}
-	mongoRepo := &MockMongoRepository{}
-	usecase := NewUserUsecase(mysqlRepo, mongoRepo)
+	usecase, mysqlRepo, _ := newTestUsecase(t)
+
+	want := model.User{UserID: "someUserID"}
+	mysqlRepo.EXPECT().GetUserByAccountID(mock.Anything, "someAccountID").Return(want, nil)
+	mysqlRepo.EXPECT().GetUserByAccountID(mock.Anything, "missing").Return(model.User{}, repository.ErrAccountNotFound)
+
+	user, err := usecase.GetUserByAccountID(context.Background(), "someAccountID")
+	assert.NoError(t, err)
+	assert.Equal(t, want, user)
+
+	_, err = usecase.GetUserByAccountID(context.Background(), "missing")
+	assert.ErrorIs(t, err, repository.ErrAccountNotFound)
+}
+
+func TestUserUsecase_RegisterUser_IgnoresVerified(t *testing.T) {
+	usecase, mysqlRepo, mongoRepo := newTestUsecase(t)
+
+	mysqlRepo.EXPECT().InsertUser(mock.Anything, model.User{Name: "John Doe"}).Return(model.User{UserID: "user-id", Name: "John Doe"}, nil)
+	mongoRepo.EXPECT().InsertUser(mock.Anything, mock.Anything).Return(model.User{UserID: "user-id", Name: "John Doe"}, nil)
+
+	_, err := usecase.RegisterUser(context.Background(), model.User{Name: "John Doe", Verified: true})
+	assert.NoError(t, err)
+}
+
+func TestUserUsecase_VerifyUser(t *testing.T) {
+	usecase, mysqlRepo, mongoRepo := newTestUsecase(t)
 
-	accountID := "someAccountID"
-	user, err := usecase.GetUserByAccountID(context.Background(), accountID)
+	verified := model.User{UserID: "user-id", Name: "John Doe", Verified: true}
+	mysqlRepo.EXPECT().MarkUserVerified(mock.Anything, "user-id").Return(nil)
+	mongoRepo.EXPECT().MarkUserVerified(mock.Anything, "user-id").Return(nil)
+	mysqlRepo.EXPECT().GetUserByID(mock.Anything, "user-id").Return(verified, nil)
+
+	user, err := usecase.VerifyUser(context.Background(), "user-id")
+	assert.NoError(t, err)
+	assert.Equal(t, verified, user)
+}
+
+func TestUserUsecase_VerifyUser_NotFound(t *testing.T) {
+	usecase, mysqlRepo, _ := newTestUsecase(t)
+
+	mysqlRepo.EXPECT().MarkUserVerified(mock.Anything, "missing").Return(repository.ErrUserNotFound)
+
+	_, err := usecase.VerifyUser(context.Background(), "missing")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
+func TestUserUsecase_GetAccount(t *testing.T) {
+	usecase, mysqlRepo, _ := newTestUsecase(t)
+
+	want := model.Account{AccountID: "someAccountID", UserID: "someUserID", Status: model.AccountPending}
+	mysqlRepo.EXPECT().GetAccountByID(mock.Anything, "someAccountID").Return(want, nil)
+
+	account, err := usecase.GetAccount(context.Background(), "someAccountID")
 	assert.NoError(t, err)
-	assert.Equal(t, model.User{}, user)
+	assert.Equal(t, want, account)
+}
+
+func TestUserUsecase_ListAccounts(t *testing.T) {
+	usecase, mysqlRepo, _ := newTestUsecase(t)
+
+	want := []model.Account{{AccountID: "someAccountID", UserID: "someUserID"}}
+	mysqlRepo.EXPECT().GetUserByID(mock.Anything, "someUserID").Return(model.User{UserID: "someUserID"}, nil)
+	mysqlRepo.EXPECT().GetUserByID(mock.Anything, "unknown").Return(model.User{}, repository.ErrUserNotFound)
+	mysqlRepo.EXPECT().ListAccountsByUserID(mock.Anything, "someUserID").Return(want, nil)
+
+	accounts, err := usecase.ListAccounts(context.Background(), "someUserID")
+	assert.NoError(t, err)
+	assert.Equal(t, want, accounts)
+
+	_, err = usecase.ListAccounts(context.Background(), "unknown")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
+func TestUserUsecase_ListAccountsByUserIDs(t *testing.T) {
+	usecase, mysqlRepo, _ := newTestUsecase(t)
+
+	first := model.Account{AccountID: "first", UserID: "someUserID"}
+	second := model.Account{AccountID: "second", UserID: "someUserID"}
+	mysqlRepo.EXPECT().ListAccountsByUserIDs(mock.Anything, []string{"someUserID", "otherUserID"}).Return([]model.Account{first, second}, nil)
+
+	accounts, err := usecase.ListAccountsByUserIDs(context.Background(), []string{"someUserID", "otherUserID"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]model.Account{
+		"someUserID":  {first, second},
+		"otherUserID": {},
+	}, accounts)
+}
+
+func TestUserUsecase_GetUsersByIDs(t *testing.T) {
+	usecase, mysqlRepo, _ := newTestUsecase(t)
+
+	budi := model.User{UserID: "someUserID", Name: "Budi"}
+	mysqlRepo.EXPECT().GetUsersByIDs(mock.Anything, []string{"someUserID", "otherUserID"}).Return([]model.User{budi}, nil)
+
+	users, err := usecase.GetUsersByIDs(context.Background(), []string{"someUserID", "otherUserID"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]model.User{"someUserID": budi}, users)
+}
+
+func TestUserUsecase_VerifyAccount(t *testing.T) {
+	usecase, mysqlRepo, _ := newTestUsecase(t)
+
+	active := model.Account{AccountID: "active", Status: model.AccountActive}
+	mysqlRepo.EXPECT().GetAccountByID(mock.Anything, "active").Return(active, nil)
+	mysqlRepo.EXPECT().GetAccountByID(mock.Anything, "pending").Return(model.Account{AccountID: "pending", Status: model.AccountPending}, nil)
+
+	account, err := usecase.VerifyAccount(context.Background(), "active", "123456")
+	assert.NoError(t, err)
+	assert.Equal(t, active, account)
+
+	_, err = usecase.VerifyAccount(context.Background(), "pending", "123456")
+	assert.ErrorIs(t, err, ErrAccountVerificationDisabled)
+}
+
+func TestMetricsUsecase(t *testing.T) {
+	base, mysqlRepo, mongoRepo := newTestUsecase(t)
+	m := metrics.New()
+	usecase := NewMetricsUsecase(base, m)
+
+	mysqlRepo.EXPECT().InsertUser(mock.Anything, mock.Anything).Return(model.User{UserID: "user-id"}, nil)
+	mongoRepo.EXPECT().InsertUser(mock.Anything, mock.Anything).Return(model.User{UserID: "user-id"}, nil)
+	mysqlRepo.EXPECT().InsertAccount(mock.Anything, mock.Anything).Return(model.Account{}, repository.ErrMsisdnLimit).Once()
+	mysqlRepo.EXPECT().InsertAccount(mock.Anything, mock.Anything).Return(model.Account{AccountID: "account-id"}, nil).Once()
+
+	_, err := usecase.RegisterUser(context.Background(), model.User{Name: "John Doe"})
+	assert.NoError(t, err)
+	_, err = usecase.RegisterAccount(context.Background(), model.Account{UserID: "user-id"})
+	assert.ErrorIs(t, err, repository.ErrMsisdnLimit)
+	_, err = usecase.RegisterAccount(context.Background(), model.Account{UserID: "user-id"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.UsersRegistered))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.MsisdnLimitRejected))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.AccountsRegistered))
+}
+
+// pendingAccounts registers accounts as pending and activates them on
+// verification, like the OTP flow.
+type pendingAccounts struct {
+	UserInterface
+	accounts map[string]model.Account
+}
+
+func (p *pendingAccounts) RegisterAccount(ctx context.Context, account model.Account) (model.Account, error) {
+	account.Status = model.AccountPending
+	p.accounts[account.AccountID] = account
+	return account, nil
+}
+
+func (p *pendingAccounts) GetAccount(ctx context.Context, accountID string) (model.Account, error) {
+	return p.accounts[accountID], nil
+}
+
+func (p *pendingAccounts) VerifyAccount(ctx context.Context, accountID, code string) (model.Account, error) {
+	account := p.accounts[accountID]
+	account.Status = model.AccountActive
+	p.accounts[accountID] = account
+	return account, nil
+}
+
+func TestMetricsUsecase_PendingAccounts(t *testing.T) {
+	m := metrics.New()
+	usecase := NewMetricsUsecase(&pendingAccounts{accounts: map[string]model.Account{}}, m)
+
+	_, err := usecase.RegisterAccount(context.Background(), model.Account{AccountID: "account-id"})
+	assert.NoError(t, err)
+	assert.Zero(t, testutil.ToFloat64(m.AccountsRegistered), "pending accounts are not counted")
+
+	for i := 0; i < 2; i++ {
+		_, err = usecase.VerifyAccount(context.Background(), "account-id", "123456")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.AccountsRegistered), "accounts count once verified")
+}
+
+func TestTracingUsecase(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	base, mysqlRepo, _ := newTestUsecase(t)
+	usecase := NewTracingUsecase(base)
+
+	mysqlRepo.EXPECT().InsertAccount(mock.Anything, mock.Anything).Return(model.Account{}, repository.ErrMsisdnLimit)
+
+	_, err := usecase.RegisterAccount(context.Background(), model.Account{UserID: "user-id"})
+	assert.ErrorIs(t, err, repository.ErrMsisdnLimit)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "userUsecase.RegisterAccount", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+// racingSignup lets another sign-up claim the email while the user is being
+// registered, after the availability check passed.
+type racingSignup struct {
+	UserInterface
+	credentials *credential.Service
+}
+
+func (r *racingSignup) RegisterUser(ctx context.Context, user model.User) (Result, error) {
+	if err := r.credentials.SetPassword(ctx, "other-user", user.Email, "another password"); err != nil {
+		return Result{}, err
+	}
+	return r.UserInterface.RegisterUser(ctx, user)
+}
+
+func TestCredentialUsecase_RegisterUser_EmailClaimed(t *testing.T) {
+	mysqlRepo := memory.NewMysqlRepository()
+	mongoRepo := memory.NewMongoRepository()
+	credentials, err := credential.NewService(memory.NewCredentialRepository(), credential.LogNotifier{}, credential.Config{MaxAttempts: 5, BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+
+	usecase := NewCredentialUsecase(&racingSignup{UserInterface: NewUserUsecase(mysqlRepo, mongoRepo), credentials: credentials}, credentials)
+
+	_, err = usecase.RegisterUser(context.Background(), model.User{Name: "John Doe", Email: "john@example.com", Password: "correct horse"})
+	assert.ErrorIs(t, err, repository.ErrCredentialExists)
+	assert.Zero(t, mysqlRepo.UserCount(), "the user is deleted again")
+	assert.Zero(t, mongoRepo.UserCount(), "the user is deleted again")
 }
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/vier21/tefa-ch3/internal/logging"
+	"github.com/vier21/tefa-ch3/internal/model"
+	"github.com/vier21/tefa-ch3/internal/verification"
+)
+
+// verificationUsecase mails a verification link to every user registered
+// by next.
+type verificationUsecase struct {
+	UserInterface
+	verification *verification.Service
+}
+
+func NewVerificationUsecase(next UserInterface, verification *verification.Service) *verificationUsecase {
+	return &verificationUsecase{
+		UserInterface: next,
+		verification:  verification,
+	}
+}
+
+// RegisterUser does not fail when the mail cannot be sent, the user is
+// registered by then and stays unverified.
+func (u *verificationUsecase) RegisterUser(ctx context.Context, user model.User) (Result, error) {
+	res, err := u.UserInterface.RegisterUser(ctx, user)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := u.verification.Send(ctx, res.UserMysql); err != nil {
+		logging.FromContext(ctx).Error("error sending verification mail", "user_id", res.UserMysql.UserID, "error", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,5 @@
+// Package userpb holds the protobuf definition of the gRPC API and the code
+// generated from it.
+package userpb
+
+//go:generate buf generate
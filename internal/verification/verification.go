@@ -0,0 +1,78 @@
+// Package verification confirms user email addresses with signed, expiring
+// links sent by mail.
+package verification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/vier21/tefa-ch3/config"
+	"github.com/vier21/tefa-ch3/internal/auth"
+	"github.com/vier21/tefa-ch3/internal/mail"
+	"github.com/vier21/tefa-ch3/internal/model"
+)
+
+// Path is where the links in verification emails point to.
+const Path = "/user/verify"
+
+var ErrInvalidToken = errors.New("invalid or expired verification token")
+
+// FromConfig signs verification tokens with the JWT keys but for a separate
+// audience, so they cannot be used as access tokens and vice versa.
+func FromConfig(cfg *config.Config) auth.Config {
+	tokens := auth.FromConfig(cfg)
+	tokens.Audience = cfg.JWTAudience + Path
+	tokens.TTL = cfg.VerifyTTL
+	return tokens
+}
+
+type Service struct {
+	tokens    *auth.TokenService
+	mailer    mail.Mailer
+	publicURL string
+}
+
+// NewService sends links to publicURL, the externally reachable base URL of
+// the API.
+func NewService(tokens auth.Config, mailer mail.Mailer, publicURL string) (*Service, error) {
+	svc, err := auth.NewTokenService(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		tokens:    svc,
+		mailer:    mailer,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}, nil
+}
+
+// Send mails a verification link to user.
+func (s *Service) Send(ctx context.Context, user model.User) error {
+	token, _, err := s.tokens.Issue(user.UserID)
+	if err != nil {
+		return err
+	}
+
+	link := s.publicURL + Path + "?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your email address by opening the link below.\n\n%s\n",
+			user.Name, link),
+	})
+}
+
+// Check returns the user a token was issued for.
+func (s *Service) Check(token string) (string, error) {
+	claims, err := s.tokens.Verify(token)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	return claims.Subject, nil
+}
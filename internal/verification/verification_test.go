@@ -0,0 +1,72 @@
+package verification
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vier21/tefa-ch3/internal/auth"
+	"github.com/vier21/tefa-ch3/internal/mail"
+	"github.com/vier21/tefa-ch3/internal/model"
+)
+
+var testTokens = auth.Config{
+	Keys:     []auth.Key{{ID: "test", Secret: []byte("test-secret")}},
+	Issuer:   "tefa-ch3",
+	Audience: "tefa-ch3" + Path,
+	TTL:      time.Hour,
+}
+
+func TestSendAndCheck(t *testing.T) {
+	dir := t.TempDir()
+	svc, err := NewService(testTokens, mail.NewFileMailer(dir, "no-reply@example.com"), "https://api.example.com/")
+	assert.NoError(t, err)
+
+	err = svc.Send(context.Background(), model.User{UserID: "budi", Name: "Budi", Email: "budi@example.com"})
+	assert.NoError(t, err)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if !assert.Len(t, files, 1) {
+		return
+	}
+	raw, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+
+	link := regexp.MustCompile(`https://api\.example\.com/user/verify\?token=\S+`).FindString(string(raw))
+	if !assert.NotEmpty(t, link, "mail should contain the verification link") {
+		return
+	}
+	u, err := url.Parse(link)
+	assert.NoError(t, err)
+
+	userID, err := svc.Check(u.Query().Get("token"))
+	assert.NoError(t, err)
+	assert.Equal(t, "budi", userID)
+}
+
+func TestCheck_Rejects(t *testing.T) {
+	svc, err := NewService(testTokens, mail.NewFileMailer(t.TempDir(), "no-reply@example.com"), "https://api.example.com")
+	assert.NoError(t, err)
+
+	expired, _, err := svc.tokens.IssueWithTTL("budi", -time.Minute)
+	assert.NoError(t, err)
+	_, err = svc.Check(expired)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	access := testTokens
+	access.Audience = "tefa-ch3"
+	accessTokens, err := auth.NewTokenService(access)
+	assert.NoError(t, err)
+	token, _, err := accessTokens.Issue("budi")
+	assert.NoError(t, err)
+	_, err = svc.Check(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "access tokens are not verification tokens")
+
+	_, err = svc.Check("")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}